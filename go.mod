@@ -1,70 +1,100 @@
 module github.com/go-kratos/kratos
 
-go 1.13
+go 1.18
 
 require (
 	github.com/BurntSushi/toml v0.3.1
+	github.com/dgryski/go-farm v0.0.0-20190423205320-6a90982ecee2
+	github.com/fatih/color v1.10.0
+	github.com/fsnotify/fsnotify v1.4.7
+	github.com/go-sql-driver/mysql v1.4.1
+	github.com/go-zookeeper/zk v1.0.1
+	github.com/gobuffalo/packr/v2 v2.7.1
+	github.com/gogo/protobuf v1.3.1
+	github.com/golang/protobuf v1.5.2
+	github.com/klauspost/compress v1.11.13
+	github.com/lib/pq v1.10.9
+	github.com/montanaflynn/stats v0.5.0
+	github.com/opentracing/opentracing-go v1.1.0
+	github.com/openzipkin/zipkin-go v0.2.1
+	github.com/otokaze/mock v0.0.0-20190125081256-8282b7a7c7c3
+	github.com/philchia/agollo/v4 v4.1.1
+	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.14.0
+	github.com/prometheus/client_model v0.3.0
+	github.com/shirou/gopsutil v2.19.11+incompatible
+	github.com/siddontang/go v0.0.0-20180604090527-bdc77568d726
+	github.com/sirupsen/logrus v1.6.0
+	github.com/stretchr/testify v1.6.1
+	github.com/tsuna/gohbase v0.0.0-20190502052937-24ffed0537aa
+	github.com/uber/jaeger-client-go v2.25.0+incompatible
+	github.com/ugorji/go/codec v1.1.7
+	github.com/urfave/cli/v2 v2.3.0
+	go.etcd.io/etcd v0.0.0-20200402134248-51bdeb39e698
+	go.uber.org/atomic v1.6.0
+	golang.org/x/net v0.0.0-20220225172249-27dd8689420f
+	golang.org/x/tools v0.0.0-20200825202427-b303f430e36d
+	google.golang.org/genproto v0.0.0-20200825200019-8632dd797987
+	google.golang.org/grpc v1.31.0
+	gopkg.in/go-playground/validator.v9 v9.29.1
+	gopkg.in/yaml.v2 v2.4.0
+)
+
+require (
 	github.com/HdrHistogram/hdrhistogram-go v1.0.1 // indirect
 	github.com/StackExchange/wmi v0.0.0-20190523213315-cbe66965904d // indirect
 	github.com/aristanetworks/goarista v0.0.0-20190912214011-b54698eaaca6 // indirect
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.1.2 // indirect
 	github.com/coreos/go-semver v0.3.0 // indirect
+	github.com/coreos/go-systemd/v22 v22.0.0 // indirect
 	github.com/cpuguy83/go-md2man/v2 v2.0.0 // indirect
 	github.com/cznic/b v0.0.0-20181122101859-a26611c4d92d // indirect
 	github.com/cznic/mathutil v0.0.0-20181122101859-297441e03548 // indirect
 	github.com/cznic/strutil v0.0.0-20181122101858-275e90344537 // indirect
-	github.com/dgryski/go-farm v0.0.0-20190423205320-6a90982ecee2
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/dustin/go-humanize v1.0.0 // indirect
-	github.com/fatih/color v1.10.0
-	github.com/fsnotify/fsnotify v1.4.7
 	github.com/go-ole/go-ole v1.2.4 // indirect
 	github.com/go-playground/locales v0.12.1 // indirect
 	github.com/go-playground/universal-translator v0.16.0 // indirect
-	github.com/go-sql-driver/mysql v1.4.1
-	github.com/go-zookeeper/zk v1.0.1
-	github.com/gobuffalo/packr/v2 v2.7.1
-	github.com/gogo/protobuf v1.3.1
-	github.com/golang/groupcache v0.0.0-20200121045136-8c9f03a8e57e // indirect
-	github.com/golang/mock v1.3.1 // indirect
-	github.com/golang/protobuf v1.3.5
+	github.com/gobuffalo/envy v1.7.1 // indirect
+	github.com/gobuffalo/logger v1.0.1 // indirect
+	github.com/gobuffalo/packd v0.3.0 // indirect
 	github.com/google/uuid v1.1.1 // indirect
 	github.com/gorilla/websocket v1.4.2 // indirect
 	github.com/grpc-ecosystem/go-grpc-middleware v1.2.0 // indirect
 	github.com/grpc-ecosystem/grpc-gateway v1.14.3 // indirect
+	github.com/joho/godotenv v1.3.0 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.3 // indirect
 	github.com/leodido/go-urn v1.1.0 // indirect
-	github.com/montanaflynn/stats v0.5.0
-	github.com/opentracing/opentracing-go v1.1.0
-	github.com/openzipkin/zipkin-go v0.2.1
-	github.com/otokaze/mock v0.0.0-20190125081256-8282b7a7c7c3
-	github.com/philchia/agollo/v4 v4.1.1
-	github.com/pkg/errors v0.8.1
-	github.com/prometheus/client_golang v1.5.1
-	github.com/prometheus/procfs v0.0.11 // indirect
+	github.com/mattn/go-colorable v0.1.8 // indirect
+	github.com/mattn/go-isatty v0.0.12 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.1 // indirect
+	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/prometheus/common v0.37.0 // indirect
+	github.com/prometheus/procfs v0.8.0 // indirect
 	github.com/remyoudompheng/bigfft v0.0.0-20190728182440-6a916e37a237 // indirect
 	github.com/rogpeppe/go-internal v1.5.0 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/samuel/go-zookeeper v0.0.0-20180130194729-c4fab1ac1bec // indirect
-	github.com/shirou/gopsutil v2.19.11+incompatible
-	github.com/siddontang/go v0.0.0-20180604090527-bdc77568d726
-	github.com/sirupsen/logrus v1.5.0
 	github.com/spf13/pflag v1.0.5 // indirect
-	github.com/stretchr/testify v1.6.1
 	github.com/tmc/grpc-websocket-proxy v0.0.0-20200122045848-3419fae592fc // indirect
-	github.com/tsuna/gohbase v0.0.0-20190502052937-24ffed0537aa
-	github.com/uber/jaeger-client-go v2.25.0+incompatible
 	github.com/uber/jaeger-lib v2.4.0+incompatible // indirect
-	github.com/urfave/cli/v2 v2.3.0
-	go.etcd.io/etcd v0.0.0-20200402134248-51bdeb39e698
-	go.uber.org/atomic v1.6.0
-	golang.org/x/crypto v0.0.0-20200323165209-0ec3e9974c59 // indirect
-	golang.org/x/net v0.0.0-20200324143707-d3edc9973b7e
-	golang.org/x/sys v0.0.0-20201202213521-69691e467435 // indirect
+	go.uber.org/multierr v1.5.0 // indirect
+	go.uber.org/zap v1.14.1 // indirect
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9 // indirect
+	golang.org/x/mod v0.3.0 // indirect
+	golang.org/x/sys v0.0.0-20220520151302-bc2c85ada10a // indirect
+	golang.org/x/text v0.3.7 // indirect
 	golang.org/x/time v0.0.0-20191024005414-555d28b269f0 // indirect
-	golang.org/x/tools v0.0.0-20191105231337-689d0f08e67a
-	google.golang.org/appengine v1.6.1 // indirect
-	google.golang.org/genproto v0.0.0-20200402124713-8ff61da6d932
-	google.golang.org/grpc v1.29.1
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	google.golang.org/appengine v1.6.6 // indirect
+	google.golang.org/protobuf v1.28.1 // indirect
+	gopkg.in/check.v1 v1.0.0-20200227125254-8fa46927fb4f // indirect
 	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
-	gopkg.in/go-playground/validator.v9 v9.29.1
-	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 	sigs.k8s.io/yaml v1.2.0 // indirect
 )
+
+replace google.golang.org/grpc => google.golang.org/grpc v1.29.1
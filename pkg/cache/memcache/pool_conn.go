@@ -3,7 +3,6 @@ package memcache
 import (
 	"context"
 	"fmt"
-	"io"
 	"time"
 
 	"github.com/go-kratos/kratos/pkg/container/pool"
@@ -12,7 +11,7 @@ import (
 // Pool memcache connection pool struct.
 // Deprecated: Use Memcache instead
 type Pool struct {
-	p pool.Pool
+	p *pool.Typed[*traceConn]
 	c *Config
 }
 
@@ -22,14 +21,21 @@ func NewPool(cfg *Config) (p *Pool) {
 	if cfg.DialTimeout <= 0 || cfg.ReadTimeout <= 0 || cfg.WriteTimeout <= 0 {
 		panic("must config memcache timeout")
 	}
-	p1 := pool.NewList(cfg.Config)
 	cnop := DialConnectTimeout(time.Duration(cfg.DialTimeout))
 	rdop := DialReadTimeout(time.Duration(cfg.ReadTimeout))
 	wrop := DialWriteTimeout(time.Duration(cfg.WriteTimeout))
-	p1.New = func(ctx context.Context) (io.Closer, error) {
-		conn, err := Dial(cfg.Proto, cfg.Addr, cnop, rdop, wrop)
-		return newTraceConn(conn, fmt.Sprintf("%s://%s", cfg.Proto, cfg.Addr)), err
-	}
+	address := fmt.Sprintf("%s://%s", cfg.Proto, cfg.Addr)
+	p1 := pool.NewTyped(cfg.Config, pool.Factory[*traceConn]{
+		Name: "memcache:" + cfg.Addr,
+		New: func(ctx context.Context) (*traceConn, error) {
+			conn, err := Dial(cfg.Proto, cfg.Addr, cnop, rdop, wrop)
+			if err != nil {
+				return nil, err
+			}
+			return newTraceConn(conn, address), nil
+		},
+		Close: func(c *traceConn) error { return c.Close() },
+	})
 	p = &Pool{p: p1, c: cfg}
 	return
 }
@@ -44,8 +50,17 @@ func (p *Pool) Get(ctx context.Context) Conn {
 	if err != nil {
 		return errConn{err}
 	}
-	c1, _ := c.(Conn)
-	return &poolConn{p: p, c: c1, ctx: ctx}
+	return &poolConn{p: p, c: c, rc: c, ctx: ctx}
+}
+
+// Reload reloads the config of the pool.
+func (p *Pool) Reload(c *pool.Config) error {
+	return p.p.Reload(c)
+}
+
+// Stats returns the stats of the pool.
+func (p *Pool) Stats() pool.TypedStats {
+	return p.p.Stats()
 }
 
 // Close release the resources used by the pool.
@@ -55,6 +70,7 @@ func (p *Pool) Close() error {
 
 type poolConn struct {
 	c   Conn
+	rc  *traceConn
 	p   *Pool
 	ctx context.Context
 }
@@ -76,7 +92,7 @@ func (pc *poolConn) Close() error {
 		return nil
 	}
 	pc.c = errConn{ErrConnClosed}
-	pc.p.p.Put(context.Background(), c, c.Err() != nil)
+	pc.p.p.Put(context.Background(), pc.rc, c.Err() != nil)
 	return nil
 }
 
@@ -3,7 +3,6 @@ package memcache
 import (
 	"context"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/go-kratos/kratos/pkg/log"
@@ -14,12 +13,13 @@ const (
 	_slowLogDuration = time.Millisecond * 250
 )
 
-func newTraceConn(conn Conn, address string) Conn {
+func newTraceConn(conn Conn, address string) *traceConn {
 	tags := []trace.Tag{
 		trace.String(trace.TagSpanKind, "client"),
 		trace.String(trace.TagComponent, "cache/memcache"),
 		trace.String(trace.TagPeerService, "memcache"),
 		trace.String(trace.TagPeerAddress, address),
+		trace.String(trace.TagDBType, "memcache"),
 	}
 	return &traceConn{Conn: conn, tags: tags}
 }
@@ -29,75 +29,93 @@ type traceConn struct {
 	tags []trace.Tag
 }
 
-func (t *traceConn) setTrace(ctx context.Context, action, statement string) func(error) error {
+// setTrace starts a span of action on keys, args are the literal values.
+// The returned func finishes the span with the result err and tags.
+func (t *traceConn) setTrace(ctx context.Context, action string, keys []string, args ...string) func(error, ...trace.Tag) error {
 	now := time.Now()
 	parent, ok := trace.FromContext(ctx)
 	if !ok {
-		return func(err error) error { return err }
+		return func(err error, _ ...trace.Tag) error { return err }
 	}
+	statement := trace.CacheStatement(action, keys, args...)
 	span := parent.Fork("", "Memcache:"+action)
 	span.SetTag(t.tags...)
-	span.SetTag(trace.String(trace.TagDBStatement, action+" "+statement))
-	return func(err error) error {
-		span.Finish(&err)
+	span.SetTag(trace.String(trace.TagDBStatement, statement))
+	return func(err error, tags ...trace.Tag) error {
+		span.SetTag(tags...)
+		if err == ErrNotFound {
+			// cache miss is recorded by cache.hit, not an error of trace.
+			terr := error(nil)
+			span.Finish(&terr)
+		} else {
+			span.Finish(&err)
+		}
 		t := time.Since(now)
 		if t > _slowLogDuration {
-			log.Warn("memcache slow log action: %s key: %s time: %v", action, statement, t)
+			log.Warn("memcache slow log statement: %s time: %v", statement, t)
 		}
 		return err
 	}
 }
 
 func (t *traceConn) AddContext(ctx context.Context, item *Item) error {
-	finishFn := t.setTrace(ctx, "Add", item.Key)
+	finishFn := t.setTrace(ctx, "Add", []string{item.Key})
 	return finishFn(t.Conn.Add(item))
 }
 
 func (t *traceConn) SetContext(ctx context.Context, item *Item) error {
-	finishFn := t.setTrace(ctx, "Set", item.Key)
+	finishFn := t.setTrace(ctx, "Set", []string{item.Key})
 	return finishFn(t.Conn.Set(item))
 }
 
 func (t *traceConn) ReplaceContext(ctx context.Context, item *Item) error {
-	finishFn := t.setTrace(ctx, "Replace", item.Key)
+	finishFn := t.setTrace(ctx, "Replace", []string{item.Key})
 	return finishFn(t.Conn.Replace(item))
 }
 
 func (t *traceConn) GetContext(ctx context.Context, key string) (*Item, error) {
-	finishFn := t.setTrace(ctx, "Get", key)
+	finishFn := t.setTrace(ctx, "Get", []string{key})
 	item, err := t.Conn.Get(key)
-	return item, finishFn(err)
+	return item, finishFn(err, hitTag(err == nil, err)...)
 }
 
 func (t *traceConn) GetMultiContext(ctx context.Context, keys []string) (map[string]*Item, error) {
-	finishFn := t.setTrace(ctx, "GetMulti", strings.Join(keys, " "))
+	finishFn := t.setTrace(ctx, "GetMulti", keys)
 	items, err := t.Conn.GetMulti(keys)
-	return items, finishFn(err)
+	return items, finishFn(err, hitTag(len(items) == len(keys), err)...)
 }
 
 func (t *traceConn) DeleteContext(ctx context.Context, key string) error {
-	finishFn := t.setTrace(ctx, "Delete", key)
+	finishFn := t.setTrace(ctx, "Delete", []string{key})
 	return finishFn(t.Conn.Delete(key))
 }
 
 func (t *traceConn) IncrementContext(ctx context.Context, key string, delta uint64) (newValue uint64, err error) {
-	finishFn := t.setTrace(ctx, "Increment", key+" "+strconv.FormatUint(delta, 10))
+	finishFn := t.setTrace(ctx, "Increment", []string{key}, strconv.FormatUint(delta, 10))
 	newValue, err = t.Conn.Increment(key, delta)
 	return newValue, finishFn(err)
 }
 
 func (t *traceConn) DecrementContext(ctx context.Context, key string, delta uint64) (newValue uint64, err error) {
-	finishFn := t.setTrace(ctx, "Decrement", key+" "+strconv.FormatUint(delta, 10))
+	finishFn := t.setTrace(ctx, "Decrement", []string{key}, strconv.FormatUint(delta, 10))
 	newValue, err = t.Conn.Decrement(key, delta)
 	return newValue, finishFn(err)
 }
 
 func (t *traceConn) CompareAndSwapContext(ctx context.Context, item *Item) error {
-	finishFn := t.setTrace(ctx, "CompareAndSwap", item.Key)
+	finishFn := t.setTrace(ctx, "CompareAndSwap", []string{item.Key})
 	return finishFn(t.Conn.CompareAndSwap(item))
 }
 
 func (t *traceConn) TouchContext(ctx context.Context, key string, seconds int32) (err error) {
-	finishFn := t.setTrace(ctx, "Touch", key+" "+strconv.Itoa(int(seconds)))
+	finishFn := t.setTrace(ctx, "Touch", []string{key}, strconv.Itoa(int(seconds)))
 	return finishFn(t.Conn.Touch(key, seconds))
 }
+
+// hitTag returns the cache.hit tag of lookup, no tag if lookup failed.
+func hitTag(hit bool, err error) []trace.Tag {
+	if err != nil && err != ErrNotFound {
+		return nil
+	}
+	return []trace.Tag{trace.TagBool(trace.TagCacheHit, hit)}
+}
@@ -0,0 +1,16 @@
+package memcache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-kratos/kratos/pkg/net/trace"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHitTag(t *testing.T) {
+	assert.Equal(t, []trace.Tag{trace.TagBool(trace.TagCacheHit, true)}, hitTag(true, nil))
+	assert.Equal(t, []trace.Tag{trace.TagBool(trace.TagCacheHit, false)}, hitTag(false, ErrNotFound))
+	assert.Nil(t, hitTag(false, errors.New("conn closed")))
+}
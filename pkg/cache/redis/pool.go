@@ -20,6 +20,7 @@ import (
 	"crypto/rand"
 	"crypto/sha1"
 	"errors"
+	"fmt"
 	"io"
 	"strconv"
 	"sync"
@@ -38,11 +39,13 @@ var (
 
 // Pool .
 type Pool struct {
-	*pool.Slice
+	p *pool.Typed[*traceConn]
 	// config
 	c *Config
 	// statfunc
 	statfunc func(name, addr, cmd string, t time.Time, err error) func()
+	// customStat use statfunc set by SetStatFunc instead of pstat, nil statfunc disables stat.
+	customStat bool
 }
 
 // NewPool creates a new pool.
@@ -61,22 +64,28 @@ func NewPool(c *Config, options ...DialOption) (p *Pool) {
 		DialDatabase(c.Db),
 	}
 	ops = append(ops, options...)
-	p1 := pool.NewSlice(c.Config)
+	return newPool(c, func() (Conn, error) {
+		return Dial(c.Proto, c.Addr, ops...)
+	})
+}
 
-	// new pool
-	p1.New = func(ctx context.Context) (io.Closer, error) {
-		conn, err := Dial(c.Proto, c.Addr, ops...)
-		if err != nil {
-			return nil, err
-		}
-		return &traceConn{
-			Conn:             conn,
-			connTags:         []trace.Tag{trace.TagString(trace.TagPeerAddress, c.Addr)},
-			slowLogThreshold: time.Duration(c.SlowLog),
-		}, nil
-	}
-	p = &Pool{Slice: p1, c: c, statfunc: pstat}
-	return
+func newPool(c *Config, dial func() (Conn, error)) *Pool {
+	p1 := pool.NewTyped(c.Config, pool.Factory[*traceConn]{
+		Name: fmt.Sprintf("redis:%s", c.Addr),
+		New: func(ctx context.Context) (*traceConn, error) {
+			conn, err := dial()
+			if err != nil {
+				return nil, err
+			}
+			return &traceConn{
+				Conn:             conn,
+				connTags:         []trace.Tag{trace.TagString(trace.TagPeerAddress, c.Addr)},
+				slowLogThreshold: time.Duration(c.SlowLog),
+			}, nil
+		},
+		Close: func(c *traceConn) error { return c.Close() },
+	})
+	return &Pool{p: p1, c: c}
 }
 
 // Get gets a connection. The application must close the returned connection.
@@ -85,23 +94,33 @@ func NewPool(c *Config, options ...DialOption) (p *Pool) {
 // getting an underlying connection, then the connection Err, Do, Send, Flush
 // and Receive methods return that error.
 func (p *Pool) Get(ctx context.Context) Conn {
-	c, err := p.Slice.Get(ctx)
+	c, err := p.p.Get(ctx)
 	if err != nil {
 		return errorConnection{err}
 	}
-	c1, _ := c.(Conn)
-	return &pooledConnection{p: p, c: c1.WithContext(ctx), rc: c1, now: beginTime}
+	return &pooledConnection{p: p, c: c.WithContext(ctx), rc: c, ctx: ctx, now: beginTime}
+}
+
+// Reload reloads the config of the pool.
+func (p *Pool) Reload(c *pool.Config) error {
+	return p.p.Reload(c)
+}
+
+// Stats returns the stats of the pool.
+func (p *Pool) Stats() pool.TypedStats {
+	return p.p.Stats()
 }
 
 // Close releases the resources used by the pool.
 func (p *Pool) Close() error {
-	return p.Slice.Close()
+	return p.p.Close()
 }
 
 type pooledConnection struct {
 	p     *Pool
-	rc    Conn
+	rc    *traceConn
 	c     Conn
+	ctx   context.Context
 	state int
 
 	now  time.Time
@@ -128,11 +147,24 @@ func initSentinel() {
 // SetStatFunc set stat func.
 func (p *Pool) SetStatFunc(fn func(name, addr, cmd string, t time.Time, err error) func()) {
 	p.statfunc = fn
+	p.customStat = true
 }
 
-func pstat(name, addr, cmd string, t time.Time, err error) func() {
+func (p *Pool) stat(ctx context.Context, cmd string, t time.Time, err error) {
+	if p.customStat {
+		if p.statfunc == nil {
+			return
+		}
+		p.statfunc(p.c.Name, p.c.Addr, cmd, t, err)()
+		return
+	}
+	pstat(ctx, p.c.Name, p.c.Addr, cmd, t, err)()
+}
+
+// pstat stat with the trace of ctx as exemplar.
+func pstat(ctx context.Context, name, addr, cmd string, t time.Time, err error) func() {
 	return func() {
-		_metricReqDur.Observe(int64(time.Since(t)/time.Millisecond), name, addr, cmd)
+		_metricReqDur.ObserveContext(ctx, int64(time.Since(t)/time.Millisecond), name, addr, cmd)
 		if err != nil {
 			if msg := formatErr(err, name, addr); msg != "" {
 				_metricReqErr.Inc(name, addr, cmd, msg)
@@ -177,7 +209,7 @@ func (pc *pooledConnection) Close() error {
 		}
 	}
 	_, err := c.Do("")
-	pc.p.Slice.Put(context.Background(), pc.rc, pc.state != 0 || c.Err() != nil)
+	pc.p.p.Put(context.Background(), pc.rc, pc.state != 0 || c.Err() != nil)
 	return err
 }
 
@@ -190,9 +222,7 @@ func (pc *pooledConnection) Do(commandName string, args ...interface{}) (reply i
 	ci := LookupCommandInfo(commandName)
 	pc.state = (pc.state | ci.Set) &^ ci.Clear
 	reply, err = pc.c.Do(commandName, args...)
-	if pc.p.statfunc != nil {
-		pc.p.statfunc(pc.p.c.Name, pc.p.c.Addr, commandName, now, err)()
-	}
+	pc.p.stat(pc.ctx, commandName, now, err)
 	return
 }
 
@@ -216,9 +246,7 @@ func (pc *pooledConnection) Receive() (reply interface{}, err error) {
 	if len(pc.cmds) > 0 {
 		cmd := pc.cmds[0]
 		pc.cmds = pc.cmds[1:]
-		if pc.p.statfunc != nil {
-			pc.p.statfunc(pc.p.c.Name, pc.p.c.Addr, cmd, pc.now, err)()
-		}
+		pc.p.stat(pc.ctx, cmd, pc.now, err)
 	}
 	return
 }
@@ -115,10 +115,7 @@ func (d *poolDialer) check(message string, p *Pool, dialed, open int) {
 
 func TestPoolReuse(t *testing.T) {
 	d := poolDialer{t: t}
-	p := NewPool(testConfig)
-	p.Slice.New = func(ctx context.Context) (io.Closer, error) {
-		return d.dial()
-	}
+	p := newPool(testConfig, d.dial)
 	var err error
 
 	for i := 0; i < 10; i++ {
@@ -140,10 +137,7 @@ func TestPoolReuse(t *testing.T) {
 
 func TestPoolMaxIdle(t *testing.T) {
 	d := poolDialer{t: t}
-	p := NewPool(testConfig)
-	p.Slice.New = func(ctx context.Context) (io.Closer, error) {
-		return d.dial()
-	}
+	p := newPool(testConfig, d.dial)
 	defer p.Close()
 
 	for i := 0; i < 10; i++ {
@@ -164,10 +158,7 @@ func TestPoolMaxIdle(t *testing.T) {
 
 func TestPoolError(t *testing.T) {
 	d := poolDialer{t: t}
-	p := NewPool(testConfig)
-	p.Slice.New = func(ctx context.Context) (io.Closer, error) {
-		return d.dial()
-	}
+	p := newPool(testConfig, d.dial)
 	defer p.Close()
 
 	c := p.Get(context.TODO())
@@ -186,10 +177,7 @@ func TestPoolError(t *testing.T) {
 
 func TestPoolClose(t *testing.T) {
 	d := poolDialer{t: t}
-	p := NewPool(testConfig)
-	p.Slice.New = func(ctx context.Context) (io.Closer, error) {
-		return d.dial()
-	}
+	p := newPool(testConfig, d.dial)
 	defer p.Close()
 
 	c1 := p.Get(context.TODO())
@@ -226,10 +214,7 @@ func TestPoolClose(t *testing.T) {
 }
 
 func TestPoolConcurrenSendReceive(t *testing.T) {
-	p := NewPool(testConfig)
-	p.Slice.New = func(ctx context.Context) (io.Closer, error) {
-		return DialDefaultServer()
-	}
+	p := newPool(testConfig, DialDefaultServer)
 	defer p.Close()
 
 	c := p.Get(context.TODO())
@@ -258,10 +243,7 @@ func TestPoolMaxActive(t *testing.T) {
 		Active: 2,
 		Idle:   2,
 	}
-	p := NewPool(conf)
-	p.Slice.New = func(ctx context.Context) (io.Closer, error) {
-		return d.dial()
-	}
+	p := newPool(conf, d.dial)
 	defer p.Close()
 
 	c1 := p.Get(context.TODO())
@@ -292,10 +274,7 @@ func TestPoolMaxActive(t *testing.T) {
 
 func TestPoolMonitorCleanup(t *testing.T) {
 	d := poolDialer{t: t}
-	p := NewPool(testConfig)
-	p.Slice.New = func(ctx context.Context) (io.Closer, error) {
-		return d.dial()
-	}
+	p := newPool(testConfig, d.dial)
 	defer p.Close()
 	c := p.Get(context.TODO())
 	c.Send("MONITOR")
@@ -306,10 +285,7 @@ func TestPoolMonitorCleanup(t *testing.T) {
 
 func TestPoolPubSubCleanup(t *testing.T) {
 	d := poolDialer{t: t}
-	p := NewPool(testConfig)
-	p.Slice.New = func(ctx context.Context) (io.Closer, error) {
-		return d.dial()
-	}
+	p := newPool(testConfig, d.dial)
 	defer p.Close()
 
 	c := p.Get(context.TODO())
@@ -335,10 +311,7 @@ func TestPoolPubSubCleanup(t *testing.T) {
 
 func TestPoolTransactionCleanup(t *testing.T) {
 	d := poolDialer{t: t}
-	p := NewPool(testConfig)
-	p.Slice.New = func(ctx context.Context) (io.Closer, error) {
-		return d.dial()
-	}
+	p := newPool(testConfig, d.dial)
 	defer p.Close()
 
 	c := p.Get(context.TODO())
@@ -429,10 +402,7 @@ func TestWaitPoolDialError(t *testing.T) {
 		Idle:   1,
 		Wait:   true,
 	}
-	p := NewPool(config1)
-	p.Slice.New = func(ctx context.Context) (io.Closer, error) {
-		return d.dial()
-	}
+	p := newPool(config1, d.dial)
 	defer p.Close()
 
 	c := p.Get(context.TODO())
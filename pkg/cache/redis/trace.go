@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-kratos/kratos/pkg/log"
@@ -19,6 +20,19 @@ var _internalTags = []trace.Tag{
 	trace.TagString(trace.TagSpanKind, _traceSpanKind),
 	trace.TagString(trace.TagComponent, _traceComponentName),
 	trace.TagString(trace.TagPeerService, _tracePeerService),
+	trace.TagString(trace.TagDBType, _tracePeerService),
+}
+
+// _lookupCommands commands whose nil reply means cache miss.
+var _lookupCommands = map[string]bool{
+	"GET":     true,
+	"MGET":    true,
+	"HGET":    true,
+	"HMGET":   true,
+	"HGETALL": true,
+	"LINDEX":  true,
+	"ZSCORE":  true,
+	"EXISTS":  true,
 }
 
 type traceConn struct {
@@ -56,10 +70,39 @@ func (t *traceConn) Do(commandName string, args ...interface{}) (reply interface
 	tr.SetTag(t.connTags...)
 	tr.SetTag(trace.TagString(trace.TagDBStatement, statement))
 	reply, err = t.Conn.Do(commandName, args...)
+	if err == nil {
+		if hit, ok := isHit(commandName, reply, len(args)); ok {
+			tr.SetTag(trace.TagBool(trace.TagCacheHit, hit))
+		}
+	}
 	tr.Finish(&err)
 	return
 }
 
+// isHit reports whether the reply of lookup command hits all the keys.
+func isHit(commandName string, reply interface{}, nargs int) (hit bool, ok bool) {
+	if !_lookupCommands[strings.ToUpper(commandName)] {
+		return false, false
+	}
+	switch r := reply.(type) {
+	case nil:
+		return false, true
+	case int64:
+		// EXISTS returns the number of keys existing
+		return r == int64(nargs), true
+	case []interface{}:
+		if len(r) == 0 {
+			return false, true
+		}
+		for _, v := range r {
+			if v == nil {
+				return false, true
+			}
+		}
+	}
+	return true, true
+}
+
 func (t *traceConn) Send(commandName string, args ...interface{}) (err error) {
 	statement := getStatement(commandName, args...)
 	defer t.slowLog(statement, time.Now())
@@ -69,6 +69,41 @@ func TestTraceDo(t *testing.T) {
 	assert.True(t, tr.finished)
 }
 
+func TestTraceDoCacheHit(t *testing.T) {
+	tr := &mockTrace{}
+	ctx := trace.NewContext(context.Background(), tr)
+	tc := &traceConn{Conn: &mockConn{}, slowLogThreshold: testTraceSlowLogThreshold}
+	conn := tc.WithContext(ctx)
+
+	conn.Do("GET", "test")
+
+	assert.Contains(t, tr.tags, trace.TagBool(trace.TagCacheHit, false))
+	assert.Contains(t, tr.tags, trace.TagString(trace.TagDBType, "redis"))
+}
+
+func TestIsHit(t *testing.T) {
+	for _, c := range []struct {
+		command string
+		reply   interface{}
+		nargs   int
+		hit     bool
+		ok      bool
+	}{
+		{"SET", "OK", 2, false, false},
+		{"get", []byte("v"), 1, true, true},
+		{"GET", nil, 1, false, true},
+		{"MGET", []interface{}{[]byte("a"), nil}, 2, false, true},
+		{"MGET", []interface{}{[]byte("a"), []byte("b")}, 2, true, true},
+		{"HGETALL", []interface{}{}, 1, false, true},
+		{"EXISTS", int64(1), 2, false, true},
+		{"EXISTS", int64(2), 2, true, true},
+	} {
+		hit, ok := isHit(c.command, c.reply, c.nargs)
+		assert.Equal(t, c.hit, hit, c.command)
+		assert.Equal(t, c.ok, ok, c.command)
+	}
+}
+
 func TestTraceDoErr(t *testing.T) {
 	tr := &mockTrace{}
 	ctx := trace.NewContext(context.Background(), tr)
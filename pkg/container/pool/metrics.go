@@ -0,0 +1,23 @@
+package pool
+
+import "github.com/go-kratos/kratos/pkg/stat/metric"
+
+const namespace = "pool"
+
+var (
+	_metricPoolItems = metric.NewGaugeVec(&metric.GaugeVecOpts{
+		Namespace: namespace,
+		Subsystem: "items",
+		Name:      "current",
+		Help:      "pool items current by state.",
+		Labels:    []string{"name", "state"},
+	})
+	_metricPoolWaitDur = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: namespace,
+		Subsystem: "waits",
+		Name:      "duration_ms",
+		Help:      "pool waits for items duration(ms).",
+		Labels:    []string{"name"},
+		Buckets:   []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000},
+	})
+)
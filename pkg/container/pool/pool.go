@@ -36,6 +36,11 @@ type Config struct {
 	// If WaitTimeout is not set, then Wait effects.
 	// if Wait is set true, then wait until ctx timeout, or default flase and return directly.
 	Wait bool
+	// MinIdle number of idle items pre-warmed by Typed, it must be <= Idle.
+	MinIdle int
+	// Close items after they have been opened for this duration by Typed.
+	// If the value is zero, then items are not closed due to their age.
+	MaxLifetime xtime.Duration
 }
 
 type item struct {
@@ -0,0 +1,476 @@
+package pool
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPoolUnknownItem the item put back was not got from the pool.
+var ErrPoolUnknownItem = errors.New("container/pool unknown item")
+
+// maintainInterval is the interval of closing the expired idle items and
+// pre-warming the idle items; it's overridden in tests.
+var maintainInterval = time.Second
+
+// Factory creates, checks and closes the items of a Typed pool.
+type Factory[T comparable] struct {
+	// Name is the name of the pool in metrics.
+	Name string
+	// New creates an item, it must not be nil.
+	New func(ctx context.Context) (T, error)
+	// Close closes an item, it must not be nil.
+	Close func(item T) error
+	// TestOnBorrow checks an idle item before it's returned by Get, idleAt
+	// is the time when the item was put back. The item is closed and
+	// another one is used if it returns an error. It's optional.
+	TestOnBorrow func(ctx context.Context, item T, idleAt time.Time) error
+	// TestOnReturn checks an item when it's put back, the item is closed if
+	// it returns an error. It's optional.
+	TestOnReturn func(item T) error
+}
+
+// TypedStats the stats of a Typed pool.
+type TypedStats struct {
+	// Active number of opened and pending open items.
+	Active int
+	// InUse number of items got and not put back.
+	InUse int
+	// Idle number of idle items.
+	Idle int
+	// WaitCount total number of Get waited for an item.
+	WaitCount int64
+	// WaitDuration total time waited for an item.
+	WaitDuration time.Duration
+}
+
+type typedItem[T comparable] struct {
+	v         T
+	createdAt time.Time
+	idleAt    time.Time
+}
+
+// typedGrant hands an item, or the permit to open a new one when item is
+// nil, to a waiter.
+type typedGrant[T comparable] struct {
+	item *typedItem[T]
+	err  error
+}
+
+// Typed is a pool of items of type T. Waiters of Get are served in FIFO
+// order, the stats are exported as metrics labeled with Factory.Name.
+//
+//	p := pool.NewTyped(c, pool.Factory[*Conn]{
+//		Name:  "conn",
+//		New:   func(ctx context.Context) (*Conn, error) { return Dial(addr) },
+//		Close: func(c *Conn) error { return c.Close() },
+//	})
+//	c, err := p.Get(ctx)
+//	...
+//	p.Put(ctx, c, false)
+type Typed[T comparable] struct {
+	f Factory[T]
+
+	mu      sync.Mutex
+	conf    *Config
+	idle    []*typedItem[T] // oldest first
+	inUse   map[T]*typedItem[T]
+	waiters *list.List // of chan typedGrant[T]
+	active  int
+	closed  bool
+	stop    chan struct{}
+
+	waitCount    int64
+	waitDuration time.Duration
+}
+
+// NewTyped creates a new typed pool, the MinIdle items are opened in the
+// background.
+func NewTyped[T comparable](c *Config, f Factory[T]) *Typed[T] {
+	if c == nil || (c.Active > 0 && c.Active < c.Idle) || c.MinIdle > c.Idle {
+		panic("config nil or Idle Must <= Active or MinIdle Must <= Idle")
+	}
+	if f.New == nil || f.Close == nil {
+		panic("factory New and Close must not be nil")
+	}
+	p := &Typed[T]{
+		f:       f,
+		conf:    c,
+		inUse:   make(map[T]*typedItem[T]),
+		waiters: list.New(),
+		stop:    make(chan struct{}),
+	}
+	go p.maintainer(maintainInterval)
+	return p
+}
+
+// Reload reload config.
+func (p *Typed[T]) Reload(c *Config) error {
+	if (c.Active > 0 && c.Active < c.Idle) || c.MinIdle > c.Idle {
+		return errors.New("container/pool Idle Must <= Active or MinIdle Must <= Idle")
+	}
+	var closing []*typedItem[T]
+	p.mu.Lock()
+	p.conf = c
+	for len(p.idle) > c.Idle {
+		p.releaseLocked(p.idle[0])
+		closing = append(closing, p.idle[0])
+		p.idle = p.idle[1:]
+	}
+	p.grantLocked()
+	p.statLocked()
+	p.mu.Unlock()
+	p.closeItems(closing)
+	return nil
+}
+
+// Get returns an idle or newly-opened item. If the pool is at the Active
+// limit, Get waits for an item in FIFO order when Wait or WaitTimeout is set,
+// otherwise it returns ErrPoolExhausted.
+func (p *Typed[T]) Get(ctx context.Context) (v T, err error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return v, ErrPoolClosed
+		}
+		var it *typedItem[T]
+		if it, err = p.takeLocked(ctx); err != nil {
+			return v, err
+		}
+		if it == nil {
+			return p.open(ctx)
+		}
+		if p.test(ctx, it) {
+			return it.v, nil
+		}
+	}
+}
+
+// takeLocked takes an idle item, or waits for an item. It returns nil item
+// with p.active increased if a new item should be opened. It unlocks p.mu.
+func (p *Typed[T]) takeLocked(ctx context.Context) (*typedItem[T], error) {
+	var expired []*typedItem[T]
+	unlock := func() {
+		p.mu.Unlock()
+		p.closeItems(expired)
+	}
+	for n := len(p.idle); n > 0; n = len(p.idle) {
+		it := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		if p.expiredLocked(it) {
+			p.releaseLocked(it)
+			expired = append(expired, it)
+			continue
+		}
+		p.inUse[it.v] = it
+		p.statLocked()
+		unlock()
+		return it, nil
+	}
+	if p.conf.Active <= 0 || p.active < p.conf.Active {
+		p.active++ // optimistically
+		unlock()
+		return nil, nil
+	}
+	if p.conf.WaitTimeout == 0 && !p.conf.Wait {
+		unlock()
+		return nil, ErrPoolExhausted
+	}
+	// Buffered so that the grant never blocks.
+	req := make(chan typedGrant[T], 1)
+	elem := p.waiters.PushBack(req)
+	wt := p.conf.WaitTimeout
+	unlock()
+
+	if wt > 0 {
+		var cancel func()
+		_, ctx, cancel = wt.Shrink(ctx)
+		defer cancel()
+	}
+	start := nowFunc()
+	var g typedGrant[T]
+	select {
+	case <-ctx.Done():
+		var closing *typedItem[T]
+		p.mu.Lock()
+		// The grants are sent with p.mu held, check whether it's granted
+		// before giving up.
+		select {
+		case g = <-req:
+			if g.err == nil {
+				closing = p.regrantLocked(g.item)
+			}
+		default:
+			p.waiters.Remove(elem)
+		}
+		p.recordWaitLocked(start)
+		p.mu.Unlock()
+		if closing != nil {
+			p.f.Close(closing.v)
+		}
+		return nil, ctx.Err()
+	case g = <-req:
+	}
+	p.mu.Lock()
+	p.recordWaitLocked(start)
+	p.mu.Unlock()
+	return g.item, g.err
+}
+
+// regrantLocked gives back a grant received by a canceled waiter, it returns
+// the item to be closed after unlocking p.mu, if any.
+func (p *Typed[T]) regrantLocked(it *typedItem[T]) *typedItem[T] {
+	if it == nil {
+		// The permit to open an item.
+		p.active--
+		p.grantLocked()
+		p.statLocked()
+		return nil
+	}
+	delete(p.inUse, it.v)
+	if p.putLocked(it) {
+		return it
+	}
+	return nil
+}
+
+func (p *Typed[T]) recordWaitLocked(start time.Time) {
+	d := nowFunc().Sub(start)
+	p.waitCount++
+	p.waitDuration += d
+	_metricPoolWaitDur.Observe(int64(d/time.Millisecond), p.f.Name)
+}
+
+// open opens a new item, p.active has been increased by the caller.
+func (p *Typed[T]) open(ctx context.Context) (v T, err error) {
+	if v, err = p.f.New(ctx); err != nil {
+		p.mu.Lock()
+		p.active--
+		p.grantLocked()
+		p.statLocked()
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Lock()
+	if p.closed {
+		p.active--
+		p.mu.Unlock()
+		p.f.Close(v)
+		var zero T
+		return zero, ErrPoolClosed
+	}
+	p.inUse[v] = &typedItem[T]{v: v, createdAt: nowFunc()}
+	p.statLocked()
+	p.mu.Unlock()
+	return
+}
+
+// test checks an item got from the pool, the item is closed and false is
+// returned if it's unhealthy.
+func (p *Typed[T]) test(ctx context.Context, it *typedItem[T]) bool {
+	if p.f.TestOnBorrow == nil || it.idleAt.IsZero() {
+		return true
+	}
+	if err := p.f.TestOnBorrow(ctx, it.v, it.idleAt); err == nil {
+		return true
+	}
+	p.mu.Lock()
+	delete(p.inUse, it.v)
+	p.releaseLocked(it)
+	p.statLocked()
+	p.mu.Unlock()
+	p.f.Close(it.v)
+	return false
+}
+
+// Put puts back an item got from the pool, the item is closed if
+// forceClose is true or it is unhealthy, expired or beyond the Idle limit.
+func (p *Typed[T]) Put(ctx context.Context, v T, forceClose bool) error {
+	p.mu.Lock()
+	it, ok := p.inUse[v]
+	if !ok {
+		p.mu.Unlock()
+		return ErrPoolUnknownItem
+	}
+	delete(p.inUse, v)
+	p.mu.Unlock()
+	if !forceClose && p.f.TestOnReturn != nil && p.f.TestOnReturn(v) != nil {
+		forceClose = true
+	}
+	p.mu.Lock()
+	var closing bool
+	if forceClose || p.closed || p.lifetimeExpiredLocked(it) {
+		p.releaseLocked(it)
+		p.statLocked()
+		closing = true
+	} else {
+		closing = p.putLocked(it)
+	}
+	p.mu.Unlock()
+	if closing {
+		return p.f.Close(it.v)
+	}
+	return nil
+}
+
+// putLocked hands the item to the first waiter, or keeps it idle. It
+// reports whether the item is released and must be closed by the caller
+// after unlocking p.mu.
+func (p *Typed[T]) putLocked(it *typedItem[T]) bool {
+	defer p.statLocked()
+	if p.closed || (p.conf.Active > 0 && p.active > p.conf.Active) {
+		p.releaseLocked(it)
+		return true
+	}
+	if e := p.waiters.Front(); e != nil {
+		p.waiters.Remove(e)
+		it.idleAt = nowFunc()
+		p.inUse[it.v] = it
+		e.Value.(chan typedGrant[T]) <- typedGrant[T]{item: it}
+		return false
+	}
+	if len(p.idle) >= p.conf.Idle {
+		p.releaseLocked(it)
+		return true
+	}
+	it.idleAt = nowFunc()
+	p.idle = append(p.idle, it)
+	return false
+}
+
+// releaseLocked gives up the slot of the item and grants the permit to open
+// an item to the first waiter. The item is closed by the caller after
+// unlocking p.mu, since Factory.Close may block.
+func (p *Typed[T]) releaseLocked(it *typedItem[T]) {
+	p.active--
+	p.grantLocked()
+}
+
+// closeItems closes the released items, it returns the last error.
+func (p *Typed[T]) closeItems(items []*typedItem[T]) (err error) {
+	for _, it := range items {
+		if err1 := p.f.Close(it.v); err1 != nil {
+			err = err1
+		}
+	}
+	return
+}
+
+// grantLocked grants the permits to open items to the waiters under the
+// Active limit.
+func (p *Typed[T]) grantLocked() {
+	for e := p.waiters.Front(); e != nil; e = p.waiters.Front() {
+		if p.conf.Active > 0 && p.active >= p.conf.Active {
+			return
+		}
+		p.waiters.Remove(e)
+		p.active++ // the waiter opens it
+		e.Value.(chan typedGrant[T]) <- typedGrant[T]{}
+	}
+}
+
+// lifetimeExpiredLocked reports whether the item is older than MaxLifetime.
+func (p *Typed[T]) lifetimeExpiredLocked(it *typedItem[T]) bool {
+	lt := time.Duration(p.conf.MaxLifetime)
+	return lt > 0 && it.createdAt.Add(lt).Before(nowFunc())
+}
+
+// expiredLocked reports whether the idle item is older than MaxLifetime or
+// has been idle for longer than IdleTimeout.
+func (p *Typed[T]) expiredLocked(it *typedItem[T]) bool {
+	if p.lifetimeExpiredLocked(it) {
+		return true
+	}
+	it2 := item{createdAt: it.idleAt}
+	return it2.expired(time.Duration(p.conf.IdleTimeout))
+}
+
+// Stats returns the stats of the pool.
+func (p *Typed[T]) Stats() TypedStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return TypedStats{
+		Active:       p.active,
+		InUse:        len(p.inUse),
+		Idle:         len(p.idle),
+		WaitCount:    p.waitCount,
+		WaitDuration: p.waitDuration,
+	}
+}
+
+func (p *Typed[T]) statLocked() {
+	_metricPoolItems.Set(float64(len(p.inUse)), p.f.Name, "inuse")
+	_metricPoolItems.Set(float64(len(p.idle)), p.f.Name, "idle")
+}
+
+// maintainer closes the expired idle items and pre-warms MinIdle items.
+func (p *Typed[T]) maintainer(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		p.maintain()
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Typed[T]) maintain() {
+	var closing []*typedItem[T]
+	defer func() { p.closeItems(closing) }()
+	p.mu.Lock()
+	idle := p.idle[:0]
+	for _, it := range p.idle {
+		if p.expiredLocked(it) {
+			p.releaseLocked(it)
+			closing = append(closing, it)
+		} else {
+			idle = append(idle, it)
+		}
+	}
+	p.idle = idle
+	p.statLocked()
+	for !p.closed && len(p.idle) < p.conf.MinIdle && (p.conf.Active <= 0 || p.active < p.conf.Active) {
+		p.active++ // optimistically
+		p.mu.Unlock()
+		v, err := p.f.New(context.Background())
+		p.mu.Lock()
+		if err != nil {
+			p.active--
+			p.grantLocked()
+			break
+		}
+		it := &typedItem[T]{v: v, createdAt: nowFunc()}
+		if p.putLocked(it) {
+			closing = append(closing, it)
+		}
+	}
+	p.mu.Unlock()
+}
+
+// Close closes the idle items and wakes up the waiters with ErrPoolClosed,
+// the items in use are closed when they're put back.
+func (p *Typed[T]) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrPoolClosed
+	}
+	p.closed = true
+	close(p.stop)
+	closing := p.idle
+	p.active -= len(closing)
+	p.idle = nil
+	for e := p.waiters.Front(); e != nil; e = p.waiters.Front() {
+		p.waiters.Remove(e)
+		e.Value.(chan typedGrant[T]) <- typedGrant[T]{err: ErrPoolClosed}
+	}
+	p.statLocked()
+	p.mu.Unlock()
+	return p.closeItems(closing)
+}
@@ -0,0 +1,225 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedConn struct {
+	id     int32
+	closed int32
+}
+
+type typedFactory struct {
+	opened int32
+	closed int32
+	bad    int32 // id of the unhealthy conn
+}
+
+func (f *typedFactory) factory() Factory[*typedConn] {
+	return Factory[*typedConn]{
+		Name: "test",
+		New: func(ctx context.Context) (*typedConn, error) {
+			return &typedConn{id: atomic.AddInt32(&f.opened, 1)}, nil
+		},
+		Close: func(c *typedConn) error {
+			atomic.AddInt32(&f.closed, 1)
+			atomic.StoreInt32(&c.closed, 1)
+			return nil
+		},
+		TestOnBorrow: func(ctx context.Context, c *typedConn, idleAt time.Time) error {
+			if c.id == atomic.LoadInt32(&f.bad) {
+				return errors.New("bad conn")
+			}
+			return nil
+		},
+	}
+}
+
+func TestTypedGetPut(t *testing.T) {
+	f := &typedFactory{}
+	p := NewTyped(&Config{Active: 1, Idle: 1}, f.factory())
+	defer p.Close()
+
+	c1, err := p.Get(context.Background())
+	assert.NoError(t, err)
+	_, err = p.Get(context.Background())
+	assert.Equal(t, ErrPoolExhausted, err)
+	assert.Equal(t, TypedStats{Active: 1, InUse: 1}, p.Stats())
+
+	assert.NoError(t, p.Put(context.Background(), c1, false))
+	assert.Equal(t, ErrPoolUnknownItem, p.Put(context.Background(), c1, false))
+	c2, err := p.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, c1, c2)
+
+	assert.NoError(t, p.Put(context.Background(), c2, true))
+	assert.Equal(t, int32(1), f.closed)
+	assert.Equal(t, TypedStats{}, p.Stats())
+}
+
+func TestTypedHealthCheck(t *testing.T) {
+	f := &typedFactory{}
+	fc := f.factory()
+	fc.TestOnReturn = func(c *typedConn) error {
+		if c.id == 2 {
+			return errors.New("bad conn")
+		}
+		return nil
+	}
+	p := NewTyped(&Config{Active: 2, Idle: 2}, fc)
+	defer p.Close()
+
+	c1, _ := p.Get(context.Background())
+	c2, _ := p.Get(context.Background())
+	p.Put(context.Background(), c1, false)
+	p.Put(context.Background(), c2, false)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&c2.closed))
+
+	// c1 fails on borrow, a new conn is opened instead.
+	atomic.StoreInt32(&f.bad, c1.id)
+	c3, err := p.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), c3.id)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&c1.closed))
+	assert.Equal(t, TypedStats{Active: 1, InUse: 1}, p.Stats())
+}
+
+func TestTypedMaxLifetime(t *testing.T) {
+	f := &typedFactory{}
+	p := NewTyped(&Config{Active: 1, Idle: 1, MaxLifetime: xtime.Duration(10 * time.Millisecond)}, f.factory())
+	defer p.Close()
+
+	c1, _ := p.Get(context.Background())
+	time.Sleep(20 * time.Millisecond)
+	p.Put(context.Background(), c1, false)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&c1.closed))
+
+	c2, _ := p.Get(context.Background())
+	p.Put(context.Background(), c2, false)
+	time.Sleep(20 * time.Millisecond)
+	c3, _ := p.Get(context.Background())
+	assert.NotEqual(t, c2, c3)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&c2.closed))
+}
+
+func TestTypedWaitFIFO(t *testing.T) {
+	f := &typedFactory{}
+	p := NewTyped(&Config{Active: 1, Idle: 1, Wait: true}, f.factory())
+	defer p.Close()
+
+	c, _ := p.Get(context.Background())
+	var (
+		mu    sync.Mutex
+		order []int
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c, err := p.Get(context.Background())
+			assert.NoError(t, err)
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			p.Put(context.Background(), c, false)
+		}(i)
+		// make sure the waiters are queued in order.
+		for p.waitersLen() != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	p.Put(context.Background(), c, false)
+	wg.Wait()
+	assert.Equal(t, []int{0, 1, 2}, order)
+	assert.Equal(t, int64(3), p.Stats().WaitCount)
+	assert.Equal(t, int32(1), f.opened)
+}
+
+func TestTypedWaitCancel(t *testing.T) {
+	f := &typedFactory{}
+	p := NewTyped(&Config{Active: 1, Idle: 1, WaitTimeout: xtime.Duration(10 * time.Millisecond)}, f.factory())
+
+	c, _ := p.Get(context.Background())
+	_, err := p.Get(context.Background())
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 0, p.waitersLen())
+
+	// the canceled waiter doesn't take the slot.
+	p.Put(context.Background(), c, true)
+	c, err = p.Get(context.Background())
+	assert.NoError(t, err)
+
+	// Close wakes up the waiters.
+	p.Reload(&Config{Active: 1, Idle: 1, Wait: true})
+	done := make(chan error)
+	go func() {
+		_, err := p.Get(context.Background())
+		done <- err
+	}()
+	for p.waitersLen() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.NoError(t, p.Close())
+	assert.Equal(t, ErrPoolClosed, <-done)
+	p.Put(context.Background(), c, false)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&c.closed))
+}
+
+func TestTypedMinIdle(t *testing.T) {
+	interval := maintainInterval
+	maintainInterval = 5 * time.Millisecond
+	defer func() { maintainInterval = interval }()
+
+	f := &typedFactory{}
+	p := NewTyped(&Config{Active: 3, Idle: 2, MinIdle: 2, IdleTimeout: xtime.Duration(20 * time.Millisecond)}, f.factory())
+	defer p.Close()
+	for p.Stats().Idle != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	// the idle conns are expired and reopened.
+	for atomic.LoadInt32(&f.opened) < 4 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.True(t, atomic.LoadInt32(&f.closed) >= 2)
+}
+
+func TestTypedCloseUnlocked(t *testing.T) {
+	var p *Typed[*typedConn]
+	var closed int32
+	f := (&typedFactory{}).factory()
+	f.Close = func(c *typedConn) error {
+		// deadlocks if the item is closed with p.mu held.
+		p.Stats()
+		atomic.AddInt32(&closed, 1)
+		return nil
+	}
+	p = NewTyped(&Config{Active: 2, Idle: 1}, f)
+	c1, err := p.Get(context.Background())
+	assert.NoError(t, err)
+	c2, err := p.Get(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, p.Put(context.Background(), c1, false))
+	assert.NoError(t, p.Put(context.Background(), c2, false)) // beyond Idle
+	assert.NoError(t, p.Reload(&Config{Active: 2}))
+	c3, err := p.Get(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, p.Put(context.Background(), c3, true))
+	assert.NoError(t, p.Close())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&closed))
+}
+
+func (p *Typed[T]) waitersLen() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.waiters.Len()
+}
@@ -0,0 +1,97 @@
+package hbase
+
+import (
+	"context"
+
+	"github.com/tsuna/gohbase/hrpc"
+
+	"github.com/go-kratos/kratos/pkg/sync/errgroup"
+)
+
+const _defaultBatchConcurrency = 16
+
+// Row the values of a row in a batch.
+type Row struct {
+	Key    string
+	Values map[string]map[string][]byte
+}
+
+type batchRowsKey struct{}
+
+// BatchRows returns the number of rows of the BatchPut or BatchGet call the
+// hook is invoked for, the call passed to the hook is the first row only.
+func BatchRows(ctx context.Context) (int, bool) {
+	n, ok := ctx.Value(batchRowsKey{}).(int)
+	return n, ok
+}
+
+func (c *Client) batchConcurrency() int {
+	if c.config.BatchConcurrency > 0 {
+		return c.config.BatchConcurrency
+	}
+	return _defaultBatchConcurrency
+}
+
+// BatchPut puts the rows which may be in different regions concurrently,
+// all the rows are tried and the first error is returned. The batch is a
+// single call of the hooks named BatchPut with the first row, the number of
+// rows is got by BatchRows.
+func (c *Client) BatchPut(ctx context.Context, table string, rows []*Row, options ...func(hrpc.Call) error) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	puts := make([]*hrpc.Mutate, len(rows))
+	for i, row := range rows {
+		put, err := hrpc.NewPutStr(ctx, table, row.Key, row.Values, options...)
+		if err != nil {
+			return err
+		}
+		puts[i] = put
+	}
+	finishHook := c.invokeHook(context.WithValue(ctx, batchRowsKey{}, len(puts)), puts[0], "BatchPut")
+	g := errgroup.WithContext(ctx)
+	g.GOMAXPROCS(c.batchConcurrency())
+	for _, put := range puts {
+		put := put
+		g.Go(func(context.Context) error {
+			_, err := c.hc.Put(put)
+			return err
+		})
+	}
+	err := g.Wait()
+	finishHook(err)
+	return err
+}
+
+// BatchGet gets the rows of keys which may be in different regions
+// concurrently, the results are in the order of keys and the result of a
+// failed key is nil, the first error is returned. The batch is a single call
+// of the hooks named BatchGet with the first key, the number of keys is got
+// by BatchRows.
+func (c *Client) BatchGet(ctx context.Context, table string, keys []string, options ...func(hrpc.Call) error) ([]*hrpc.Result, error) {
+	results := make([]*hrpc.Result, len(keys))
+	if len(keys) == 0 {
+		return results, nil
+	}
+	gets := make([]*hrpc.Get, len(keys))
+	for i, key := range keys {
+		get, err := hrpc.NewGetStr(ctx, table, key, options...)
+		if err != nil {
+			return nil, err
+		}
+		gets[i] = get
+	}
+	finishHook := c.invokeHook(context.WithValue(ctx, batchRowsKey{}, len(gets)), gets[0], "BatchGet")
+	g := errgroup.WithContext(ctx)
+	g.GOMAXPROCS(c.batchConcurrency())
+	for i, get := range gets {
+		i, get := i, get
+		g.Go(func(context.Context) (err error) {
+			results[i], err = c.hc.Get(get)
+			return
+		})
+	}
+	err := g.Wait()
+	finishHook(err)
+	return results, err
+}
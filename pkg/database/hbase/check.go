@@ -0,0 +1,93 @@
+package hbase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase"
+	"github.com/tsuna/gohbase/filter"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+)
+
+// checkAndDelete a delete applied only if the condition matches, gohbase
+// only provides CheckAndPut.
+type checkAndDelete struct {
+	*hrpc.Mutate
+	condition *pb.Condition
+}
+
+func newCheckAndDelete(m *hrpc.Mutate, family, qualifier string, expected []byte) (*checkAndDelete, error) {
+	// a nil expected value means the cell must not exist.
+	var comparator filter.Comparator = filter.NewNullComparator()
+	if expected != nil {
+		comparator = filter.NewBinaryComparator(filter.NewByteArrayComparable(expected))
+	}
+	cmp, err := comparator.ConstructPBComparator()
+	if err != nil {
+		return nil, err
+	}
+	return &checkAndDelete{
+		Mutate: m,
+		condition: &pb.Condition{
+			Row:         m.Key(),
+			Family:      []byte(family),
+			Qualifier:   []byte(qualifier),
+			CompareType: pb.CompareType_EQUAL.Enum(),
+			Comparator:  cmp,
+		},
+	}, nil
+}
+
+// ToProto converts the call into a MutateRequest with the condition.
+func (cm *checkAndDelete) ToProto() proto.Message {
+	req := cm.Mutate.ToProto().(*pb.MutateRequest)
+	req.Condition = cm.condition
+	return req
+}
+
+// CheckAndPut puts values only if the value of family:qualifier of the row
+// equals expected, nil expected means the cell must not exist. It reports
+// whether the put is applied.
+func (c *Client) CheckAndPut(ctx context.Context, table, key, family, qualifier string, expected []byte, values map[string]map[string][]byte, options ...func(hrpc.Call) error) (processed bool, err error) {
+	put, err := hrpc.NewPutStr(ctx, table, key, values, options...)
+	if err != nil {
+		return false, err
+	}
+	finishHook := c.invokeHook(ctx, put, "CheckAndPut")
+	defer func() { finishHook(err) }()
+	return c.hc.CheckAndPut(put, family, qualifier, expected)
+}
+
+// CheckAndDelete deletes values like Delete only if the value of
+// family:qualifier of the row equals expected, nil expected means the cell
+// must not exist. It reports whether the delete is applied.
+func (c *Client) CheckAndDelete(ctx context.Context, table, key, family, qualifier string, expected []byte, values map[string]map[string][]byte, options ...func(hrpc.Call) error) (processed bool, err error) {
+	rc, ok := c.hc.(gohbase.RPCClient)
+	if !ok {
+		return false, fmt.Errorf("hbase: %T does not support CheckAndDelete", c.hc)
+	}
+	del, err := hrpc.NewDelStr(ctx, table, key, values, append(options, hrpc.SkipBatch())...)
+	if err != nil {
+		return false, err
+	}
+	cd, err := newCheckAndDelete(del, family, qualifier, expected)
+	if err != nil {
+		return false, err
+	}
+	finishHook := c.invokeHook(ctx, del, "CheckAndDelete")
+	defer func() { finishHook(err) }()
+	msg, err := rc.SendRPC(cd)
+	if err != nil {
+		return false, err
+	}
+	resp, ok := msg.(*pb.MutateResponse)
+	if !ok {
+		return false, fmt.Errorf("hbase: CheckAndDelete returned %T instead of MutateResponse", msg)
+	}
+	if resp.Processed == nil {
+		return false, fmt.Errorf("hbase: CheckAndDelete response without processed")
+	}
+	return resp.GetProcessed(), nil
+}
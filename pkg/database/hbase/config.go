@@ -20,4 +20,6 @@ type Config struct {
 	RegionLookupTimeout xtime.Duration
 	RegionReadTimeout   xtime.Duration
 	TestRowKey          string
+	// BatchConcurrency the max concurrent requests of BatchPut and BatchGet, default 16.
+	BatchConcurrency int
 }
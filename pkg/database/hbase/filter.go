@@ -0,0 +1,103 @@
+package hbase
+
+import (
+	"github.com/tsuna/gohbase/filter"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+)
+
+// CompareOp the compare operator of value filters.
+type CompareOp = filter.CompareType
+
+// compare operators.
+const (
+	Less           = filter.Less
+	LessOrEqual    = filter.LessOrEqual
+	Equal          = filter.Equal
+	NotEqual       = filter.NotEqual
+	GreaterOrEqual = filter.GreaterOrEqual
+	Greater        = filter.Greater
+)
+
+// Filter builds the filters of scan and get, such as
+//
+//	f := hbase.AllOf().Prefix([]byte("user_")).Value("info", "state", hbase.Equal, []byte("1"))
+//	results, err := client.ScanStrAll(ctx, "user", f.Option())
+type Filter struct {
+	op      filter.ListOperator
+	filters []filter.Filter
+}
+
+// AllOf returns a filter passing the rows which pass all the filters.
+func AllOf() *Filter {
+	return &Filter{op: filter.MustPassAll}
+}
+
+// AnyOf returns a filter passing the rows which pass any of the filters.
+func AnyOf() *Filter {
+	return &Filter{op: filter.MustPassOne}
+}
+
+// Prefix filters the rows whose key has prefix.
+func (f *Filter) Prefix(prefix []byte) *Filter {
+	return f.Add(filter.NewPrefixFilter(prefix))
+}
+
+// ColumnPrefix filters the columns whose qualifier has prefix.
+func (f *Filter) ColumnPrefix(prefix []byte) *Filter {
+	return f.Add(filter.NewColumnPrefixFilter(prefix))
+}
+
+// ColumnRange filters the columns whose qualifier is in [min, max), nil
+// means no limit.
+func (f *Filter) ColumnRange(min, max []byte) *Filter {
+	return f.Add(filter.NewColumnRangeFilter(min, max, true, false))
+}
+
+// Value filters the rows whose value of family:qualifier compared with value
+// by op is true, the rows without the column are filtered out.
+func (f *Filter) Value(family, qualifier string, op CompareOp, value []byte) *Filter {
+	return f.Add(filter.NewSingleColumnValueFilter([]byte(family), []byte(qualifier), op,
+		filter.NewBinaryComparator(filter.NewByteArrayComparable(value)), true, true))
+}
+
+// ValuePrefix filters the cells whose value has prefix.
+func (f *Filter) ValuePrefix(prefix []byte) *Filter {
+	return f.Add(filter.NewValueFilter(filter.NewCompareFilter(Equal,
+		filter.NewBinaryPrefixComparator(filter.NewByteArrayComparable(prefix)))))
+}
+
+// KeyOnly returns the keys only and the values are empty.
+func (f *Filter) KeyOnly() *Filter {
+	return f.Add(filter.NewKeyOnlyFilter(false))
+}
+
+// Page returns at most n rows per region, the scan still needs a limit on
+// the client side for the rows across regions.
+func (f *Filter) Page(n int64) *Filter {
+	return f.Add(filter.NewPageFilter(n))
+}
+
+// Add adds a gohbase filter or a nested *Filter.
+func (f *Filter) Add(fl filter.Filter) *Filter {
+	f.filters = append(f.filters, fl)
+	return f
+}
+
+// ConstructPBFilter implements filter.Filter so that a *Filter can be nested.
+func (f *Filter) ConstructPBFilter() (*pb.Filter, error) {
+	return f.Build().ConstructPBFilter()
+}
+
+// Build returns the gohbase filter.
+func (f *Filter) Build() filter.Filter {
+	if len(f.filters) == 1 {
+		return f.filters[0]
+	}
+	return filter.NewList(f.op, f.filters...)
+}
+
+// Option returns the option of scan and get with the filter.
+func (f *Filter) Option() func(hrpc.Call) error {
+	return hrpc.Filters(f.Build())
+}
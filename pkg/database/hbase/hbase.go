@@ -163,6 +163,35 @@ func (c *Client) ScanRange(ctx context.Context, table, startRow, stopRow []byte,
 	return st, nil
 }
 
+// ScanRangeReverse get a scanner for the given table and key range in
+// reverse order, from startRow down to stopRow. The range is half-open, i.e.
+// ]stopRow; startRow] -- stopRow is not included in the range, nil startRow
+// means from the last row and nil stopRow means to the first row.
+func (c *Client) ScanRangeReverse(ctx context.Context, table, startRow, stopRow []byte, options ...func(hrpc.Call) error) (scanner hrpc.Scanner, err error) {
+	var scan *hrpc.Scan
+	scan, err = hrpc.NewScanRange(ctx, table, startRow, stopRow, append(options, hrpc.Reversed())...)
+	if err != nil {
+		return nil, err
+	}
+	st := &scanTrace{}
+	st.finishHook = c.invokeHook(ctx, scan, "ScanRangeReverse")
+	st.Scanner = c.hc.Scan(scan)
+	return st, nil
+}
+
+// ScanRangeReverseStr get a scanner for the given table and key range in
+// reverse order, see ScanRangeReverse.
+func (c *Client) ScanRangeReverseStr(ctx context.Context, table, startRow, stopRow string, options ...func(hrpc.Call) error) (hrpc.Scanner, error) {
+	var start, stop []byte
+	if startRow != "" {
+		start = []byte(startRow)
+	}
+	if stopRow != "" {
+		stop = []byte(stopRow)
+	}
+	return c.ScanRangeReverse(ctx, []byte(table), start, stop, options...)
+}
+
 // ScanRangeStr get a scanner for the given table and key range.
 // The range is half-open, i.e. [startRow; stopRow[ -- stopRow is not
 // included in the range.
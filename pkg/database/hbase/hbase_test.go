@@ -0,0 +1,176 @@
+package hbase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"github.com/tsuna/gohbase/region"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClient struct {
+	mu      sync.Mutex
+	puts    []string
+	rpcs    []*pb.MutateRequest
+	cas     []string
+	scans   []*hrpc.Scan
+	failKey string
+}
+
+func (f *fakeClient) Scan(s *hrpc.Scan) hrpc.Scanner {
+	f.mu.Lock()
+	f.scans = append(f.scans, s)
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fakeClient) Get(g *hrpc.Get) (*hrpc.Result, error) {
+	if string(g.Key()) == f.failKey {
+		return nil, errors.New("get failed")
+	}
+	return &hrpc.Result{Cells: []*hrpc.Cell{{Row: g.Key()}}}, nil
+}
+
+func (f *fakeClient) Put(p *hrpc.Mutate) (*hrpc.Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if string(p.Key()) == f.failKey {
+		return nil, errors.New("put failed")
+	}
+	f.puts = append(f.puts, string(p.Key()))
+	return &hrpc.Result{}, nil
+}
+
+func (f *fakeClient) Delete(d *hrpc.Mutate) (*hrpc.Result, error) { return &hrpc.Result{}, nil }
+func (f *fakeClient) Append(a *hrpc.Mutate) (*hrpc.Result, error) { return &hrpc.Result{}, nil }
+func (f *fakeClient) Increment(i *hrpc.Mutate) (int64, error)     { return 0, nil }
+func (f *fakeClient) CheckAndPut(p *hrpc.Mutate, family string, qualifier string, expectedValue []byte) (bool, error) {
+	f.mu.Lock()
+	f.cas = append(f.cas, string(p.Key())+" "+family+":"+qualifier+"="+string(expectedValue))
+	f.mu.Unlock()
+	return string(p.Key()) != f.failKey, nil
+}
+func (f *fakeClient) Close() {}
+
+func (f *fakeClient) SendRPC(call hrpc.Call) (proto.Message, error) {
+	call.SetRegion(region.NewInfo(0, nil, call.Table(), []byte("region"), nil, nil))
+	req := call.ToProto().(*pb.MutateRequest)
+	f.mu.Lock()
+	f.rpcs = append(f.rpcs, req)
+	f.mu.Unlock()
+	return &pb.MutateResponse{Processed: proto.Bool(string(call.Key()) != f.failKey)}, nil
+}
+
+type hookRecord struct {
+	mu    sync.Mutex
+	names []string
+	rows  []int
+	errs  []error
+}
+
+func newFakeClient(f *fakeClient) (*Client, *hookRecord) {
+	c := &Client{hc: f, config: &Config{BatchConcurrency: 2}}
+	hr := &hookRecord{}
+	c.AddHook(func(ctx context.Context, call hrpc.Call, customName string) func(error) {
+		rows, _ := BatchRows(ctx)
+		return func(err error) {
+			hr.mu.Lock()
+			hr.names = append(hr.names, customName)
+			hr.rows = append(hr.rows, rows)
+			hr.errs = append(hr.errs, err)
+			hr.mu.Unlock()
+		}
+	})
+	return c, hr
+}
+
+func TestFilter(t *testing.T) {
+	f := AllOf().Prefix([]byte("user_")).Add(AnyOf().Value("info", "state", Equal, []byte("1")).ColumnRange([]byte("a"), nil))
+	pf, err := f.ConstructPBFilter()
+	assert.NoError(t, err)
+	assert.Equal(t, "org.apache.hadoop.hbase.filter.FilterList", pf.GetName())
+
+	pf, err = AllOf().KeyOnly().ConstructPBFilter()
+	assert.NoError(t, err)
+	assert.Equal(t, "org.apache.hadoop.hbase.filter.KeyOnlyFilter", pf.GetName())
+
+	scan, err := hrpc.NewScanStr(context.Background(), "user", AllOf().Page(10).Option())
+	assert.NoError(t, err)
+	scan.SetRegion(region.NewInfo(0, nil, scan.Table(), []byte("region"), nil, nil))
+	assert.Equal(t, "org.apache.hadoop.hbase.filter.PageFilter", scan.ToProto().(*pb.ScanRequest).GetScan().GetFilter().GetName())
+}
+
+func TestBatchPut(t *testing.T) {
+	f := &fakeClient{}
+	c, hr := newFakeClient(f)
+	values := map[string]map[string][]byte{"cf": {"q": []byte("v")}}
+	err := c.BatchPut(context.Background(), "user", []*Row{{"a", values}, {"b", values}, {"c", values}})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, f.puts)
+	assert.Equal(t, []string{"BatchPut"}, hr.names)
+	assert.Equal(t, []int{3}, hr.rows)
+
+	f.failKey = "b"
+	err = c.BatchPut(context.Background(), "user", []*Row{{"a", values}, {"b", values}})
+	assert.EqualError(t, err, "put failed")
+	assert.Equal(t, err, hr.errs[1])
+	assert.NoError(t, c.BatchPut(context.Background(), "user", nil))
+}
+
+func TestBatchGet(t *testing.T) {
+	f := &fakeClient{failKey: "c"}
+	c, hr := newFakeClient(f)
+	results, err := c.BatchGet(context.Background(), "user", []string{"a", "b"})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, []byte("a"), results[0].Cells[0].Row)
+		assert.Equal(t, []byte("b"), results[1].Cells[0].Row)
+	}
+	results, err = c.BatchGet(context.Background(), "user", []string{"a", "c"})
+	assert.EqualError(t, err, "get failed")
+	assert.Nil(t, results[1])
+	assert.Equal(t, []string{"BatchGet", "BatchGet"}, hr.names)
+	assert.Equal(t, []int{2, 2}, hr.rows)
+}
+
+func TestCheckAndMutate(t *testing.T) {
+	f := &fakeClient{failKey: "b"}
+	c, hr := newFakeClient(f)
+	values := map[string]map[string][]byte{"cf": {"q": []byte("v")}}
+	ok, err := c.CheckAndPut(context.Background(), "user", "a", "cf", "q", []byte("old"), values)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = c.CheckAndDelete(context.Background(), "user", "b", "cf", "q", nil, nil)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, []string{"CheckAndPut", "CheckAndDelete"}, hr.names)
+	assert.Equal(t, []int{0, 0}, hr.rows)
+	assert.Equal(t, []string{"a cf:q=old"}, f.cas)
+
+	if assert.Len(t, f.rpcs, 1) {
+		cond := f.rpcs[0].GetCondition()
+		assert.Equal(t, []byte("b"), cond.GetRow())
+		assert.Equal(t, []byte("cf"), cond.GetFamily())
+		assert.Equal(t, []byte("q"), cond.GetQualifier())
+		assert.Equal(t, pb.CompareType_EQUAL, cond.GetCompareType())
+		assert.Equal(t, "org.apache.hadoop.hbase.filter.NullComparator", cond.GetComparator().GetName())
+		assert.Equal(t, pb.MutationProto_DELETE, f.rpcs[0].GetMutation().GetMutateType())
+	}
+}
+
+func TestScanRangeReverse(t *testing.T) {
+	f := &fakeClient{}
+	c, _ := newFakeClient(f)
+	_, err := c.ScanRangeReverseStr(context.Background(), "user", "z", "a")
+	assert.NoError(t, err)
+	if assert.Len(t, f.scans, 1) {
+		assert.True(t, f.scans[0].Reversed())
+		assert.Equal(t, []byte("z"), f.scans[0].StartRow())
+	}
+}
@@ -18,6 +18,10 @@ func NewSlowLogHook(threshold time.Duration) HookFunc {
 			if duration < threshold {
 				return
 			}
+			if rows, ok := BatchRows(ctx); ok {
+				log.Warn("hbase slow log: %s %s %s rows: %d time: %s", customName, call.Table(), call.Key(), rows, duration)
+				return
+			}
 			log.Warn("hbase slow log: %s %s %s time: %s", customName, call.Table(), call.Key(), duration)
 		}
 	}
@@ -9,6 +9,8 @@ import (
 	"github.com/go-kratos/kratos/pkg/net/trace"
 )
 
+const _tagBatchRows = "hbase.batch_rows"
+
 // TraceHook create new hbase trace hook.
 func TraceHook(component, instance string) HookFunc {
 	var internalTags []trace.Tag
@@ -16,6 +18,8 @@ func TraceHook(component, instance string) HookFunc {
 	internalTags = append(internalTags, trace.TagString(trace.TagDBInstance, instance))
 	internalTags = append(internalTags, trace.TagString(trace.TagPeerService, "hbase"))
 	internalTags = append(internalTags, trace.TagString(trace.TagSpanKind, "client"))
+	internalTags = append(internalTags, trace.TagString(trace.TagDBType, "hbase"))
+	internalTags = append(internalTags, trace.TagString(trace.TagPeerAddress, instance))
 	return func(ctx context.Context, call hrpc.Call, customName string) func(err error) {
 		noop := func(error) {}
 		root, ok := trace.FromContext(ctx)
@@ -27,8 +31,10 @@ func TraceHook(component, instance string) HookFunc {
 		}
 		span := root.Fork("", "Hbase:"+customName)
 		span.SetTag(internalTags...)
-		statement := string(call.Table()) + " " + string(call.Key())
-		span.SetTag(trace.TagString(trace.TagDBStatement, statement))
+		span.SetTag(trace.TagString(trace.TagDBStatement, trace.CacheStatement(customName, []string{string(call.Table())}, string(call.Key()))))
+		if rows, ok := BatchRows(ctx); ok {
+			span.SetTag(trace.TagInt(_tagBatchRows, rows))
+		}
 		return func(err error) {
 			if err == io.EOF {
 				// reset error for trace.
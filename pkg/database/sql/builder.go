@@ -0,0 +1,619 @@
+package sql
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// In expands the slice args in query to multiple placeholders, such as
+// In("SELECT * FROM user WHERE id IN (?)", []int64{1, 2}) returns
+// "SELECT * FROM user WHERE id IN (?,?)" and args [1, 2].
+// []byte and driver.Valuer are not expanded. ?? is kept as is without an
+// arg, it's converted to ? by the postgres driver.
+func In(query string, args ...interface{}) (string, []interface{}, error) {
+	var (
+		b      strings.Builder
+		out    = make([]interface{}, 0, len(args))
+		n      int
+		quote  rune
+		escape bool
+		skip   bool
+	)
+	for i, r := range query {
+		if skip {
+			skip = false
+			b.WriteRune(r)
+			continue
+		}
+		if quote != 0 {
+			switch {
+			case escape:
+				escape = false
+			case r == '\\':
+				escape = true
+			case r == quote:
+				quote = 0
+			}
+			b.WriteRune(r)
+			continue
+		}
+		switch r {
+		case '\'', '"', '`':
+			quote = r
+		case '?':
+			if i+1 < len(query) && query[i+1] == '?' {
+				skip = true
+				break
+			}
+			if n >= len(args) {
+				return "", nil, errors.Errorf("sql: too few args for %s", query)
+			}
+			arg := args[n]
+			n++
+			v, ok := expandable(arg)
+			if !ok {
+				out = append(out, arg)
+				break
+			}
+			if v.Len() == 0 {
+				return "", nil, errors.Errorf("sql: empty slice arg %d for %s", n, query)
+			}
+			b.WriteString(strings.Repeat("?,", v.Len()-1))
+			for i := 0; i < v.Len(); i++ {
+				out = append(out, v.Index(i).Interface())
+			}
+		}
+		b.WriteRune(r)
+	}
+	if n != len(args) {
+		return "", nil, errors.Errorf("sql: too many args for %s", query)
+	}
+	return b.String(), out, nil
+}
+
+func expandable(arg interface{}) (reflect.Value, bool) {
+	if arg == nil {
+		return reflect.Value{}, false
+	}
+	if _, ok := arg.(driver.Valuer); ok {
+		return reflect.Value{}, false
+	}
+	v := reflect.ValueOf(arg)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return v, false
+	}
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		return v, false
+	}
+	return v, true
+}
+
+// Named replaces the named parameters :name in query with placeholders,
+// the values are from arg which is a map[string]interface{} or a struct
+// with db tags, slice values are expanded as In.
+func Named(query string, arg interface{}) (string, []interface{}, error) {
+	value, err := namedValue(arg)
+	if err != nil {
+		return "", nil, err
+	}
+	var (
+		b      strings.Builder
+		args   []interface{}
+		quote  byte
+		escape bool
+	)
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if quote != 0 {
+			switch {
+			case escape:
+				escape = false
+			case c == '\\':
+				escape = true
+			case c == quote:
+				quote = 0
+			}
+			b.WriteByte(c)
+			continue
+		}
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == ':' && i+1 < len(query) && query[i+1] == ':':
+			// postgres type cast.
+			b.WriteString("::")
+			i++
+			continue
+		case c == ':' && i+1 < len(query) && isNameChar(query[i+1]):
+			j := i + 1
+			for j < len(query) && isNameChar(query[j]) {
+				j++
+			}
+			name := query[i+1 : j]
+			v, ok := value(name)
+			if !ok {
+				return "", nil, errors.Errorf("sql: missing named parameter %s for %s", name, query)
+			}
+			b.WriteByte('?')
+			args = append(args, v)
+			i = j - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return In(b.String(), args...)
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c == '.' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+func namedValue(arg interface{}) (func(string) (interface{}, bool), error) {
+	if m, ok := arg.(map[string]interface{}); ok {
+		return func(name string) (interface{}, bool) {
+			v, ok := m[name]
+			return v, ok
+		}, nil
+	}
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, errors.Errorf("sql: named arg must be a map[string]interface{} or struct, got %T", arg)
+	}
+	fs := structFields(v.Type())
+	return func(name string) (interface{}, bool) {
+		idx, ok := fs[name]
+		if !ok {
+			return nil, false
+		}
+		f := v
+		for i, x := range idx {
+			if i > 0 && f.Kind() == reflect.Ptr {
+				if f.IsNil() {
+					return nil, true
+				}
+				f = f.Elem()
+			}
+			f = f.Field(x)
+		}
+		return f.Interface(), true
+	}, nil
+}
+
+// Cond a condition of WHERE or HAVING.
+type Cond interface {
+	appendTo(b *strings.Builder, args []interface{}) []interface{}
+}
+
+type expr struct {
+	sql  string
+	args []interface{}
+	// raw the condition is written by the caller, its precedence is unknown.
+	raw bool
+}
+
+func (e expr) appendTo(b *strings.Builder, args []interface{}) []interface{} {
+	b.WriteString(e.sql)
+	return append(args, e.args...)
+}
+
+// Expr a raw condition with placeholders, such as Expr("id IN (?)", ids).
+func Expr(sql string, args ...interface{}) Cond {
+	return expr{sql: sql, args: args, raw: true}
+}
+
+// Eq a condition of column = v, column IS NULL if v is nil, or column IN (v)
+// if v is a slice.
+func Eq(column string, v interface{}) Cond {
+	if v == nil {
+		return expr{sql: column + " IS NULL"}
+	}
+	if _, ok := expandable(v); ok {
+		return expr{sql: column + " IN (?)", args: []interface{}{v}}
+	}
+	return expr{sql: column + " = ?", args: []interface{}{v}}
+}
+
+type junction struct {
+	sep   string
+	conds []Cond
+}
+
+func (j junction) appendTo(b *strings.Builder, args []interface{}) []interface{} {
+	if len(j.conds) == 0 {
+		b.WriteString("1=1")
+		return args
+	}
+	b.WriteByte('(')
+	for i, c := range j.conds {
+		if i > 0 {
+			b.WriteString(j.sep)
+		}
+		args = appendCond(b, c, len(j.conds) > 1, args)
+	}
+	b.WriteByte(')')
+	return args
+}
+
+// appendCond appends c, the raw condition is in parentheses if it's joined
+// with others to keep its precedence, such as a=? OR b=?.
+func appendCond(b *strings.Builder, c Cond, joined bool, args []interface{}) []interface{} {
+	if e, ok := c.(expr); ok && e.raw && joined {
+		b.WriteByte('(')
+		args = e.appendTo(b, args)
+		b.WriteByte(')')
+		return args
+	}
+	return c.appendTo(b, args)
+}
+
+// And joins the conditions by AND.
+func And(conds ...Cond) Cond {
+	return junction{sep: " AND ", conds: conds}
+}
+
+// Or joins the conditions by OR.
+func Or(conds ...Cond) Cond {
+	return junction{sep: " OR ", conds: conds}
+}
+
+// toCond converts pred which is a string with placeholders or a Cond.
+func toCond(pred interface{}, args []interface{}) (Cond, error) {
+	switch p := pred.(type) {
+	case string:
+		return expr{sql: p, args: args, raw: true}, nil
+	case Cond:
+		if len(args) > 0 {
+			return nil, errors.New("sql: args with a Cond predicate")
+		}
+		return p, nil
+	}
+	return nil, errors.Errorf("sql: unsupported predicate %T", pred)
+}
+
+// where the WHERE clause shared by the builders.
+type where struct {
+	conds []Cond
+	err   error
+}
+
+func (w *where) add(pred interface{}, args []interface{}) {
+	c, err := toCond(pred, args)
+	if err != nil {
+		if w.err == nil {
+			w.err = err
+		}
+		return
+	}
+	w.conds = append(w.conds, c)
+}
+
+func (w *where) appendTo(b *strings.Builder, keyword string, args []interface{}) []interface{} {
+	if len(w.conds) == 0 {
+		return args
+	}
+	b.WriteString(keyword)
+	for i, c := range w.conds {
+		if i > 0 {
+			b.WriteString(" AND ")
+		}
+		args = appendCond(b, c, len(w.conds) > 1, args)
+	}
+	return args
+}
+
+// SelectBuilder builds a SELECT statement.
+type SelectBuilder struct {
+	columns []string
+	from    string
+	joins   []expr
+	where   where
+	groupBy []string
+	having  where
+	orderBy []string
+	limit   string
+	offset  string
+	suffix  string
+}
+
+// Select starts a SELECT statement of columns, such as
+//
+//	query, args, err := sql.Select("id", "name").From("user").
+//		Where("mid IN (?)", mids).Where(sql.Eq("state", 1)).
+//		OrderBy("id DESC").Limit(10).ToSQL()
+func Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{columns: columns}
+}
+
+// From sets the table.
+func (s *SelectBuilder) From(table string) *SelectBuilder {
+	s.from = table
+	return s
+}
+
+// Join adds a join clause, such as Join("LEFT JOIN role ON role.mid = user.mid").
+func (s *SelectBuilder) Join(join string, args ...interface{}) *SelectBuilder {
+	s.joins = append(s.joins, expr{sql: join, args: args})
+	return s
+}
+
+// Where adds a condition joined by AND, pred is a string with placeholders or a Cond.
+func (s *SelectBuilder) Where(pred interface{}, args ...interface{}) *SelectBuilder {
+	s.where.add(pred, args)
+	return s
+}
+
+// GroupBy adds the GROUP BY columns.
+func (s *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	s.groupBy = append(s.groupBy, columns...)
+	return s
+}
+
+// Having adds a HAVING condition joined by AND.
+func (s *SelectBuilder) Having(pred interface{}, args ...interface{}) *SelectBuilder {
+	s.having.add(pred, args)
+	return s
+}
+
+// OrderBy adds the ORDER BY columns, such as OrderBy("ctime DESC").
+func (s *SelectBuilder) OrderBy(columns ...string) *SelectBuilder {
+	s.orderBy = append(s.orderBy, columns...)
+	return s
+}
+
+// Limit sets the LIMIT.
+func (s *SelectBuilder) Limit(n uint64) *SelectBuilder {
+	s.limit = strconv.FormatUint(n, 10)
+	return s
+}
+
+// Offset sets the OFFSET.
+func (s *SelectBuilder) Offset(n uint64) *SelectBuilder {
+	s.offset = strconv.FormatUint(n, 10)
+	return s
+}
+
+// Suffix appends a raw clause, such as Suffix("FOR UPDATE").
+func (s *SelectBuilder) Suffix(suffix string) *SelectBuilder {
+	s.suffix = suffix
+	return s
+}
+
+// ToSQL returns the statement and args, the slice args are expanded as In.
+func (s *SelectBuilder) ToSQL() (string, []interface{}, error) {
+	if s.where.err != nil {
+		return "", nil, s.where.err
+	}
+	if s.having.err != nil {
+		return "", nil, s.having.err
+	}
+	if s.from == "" {
+		return "", nil, errors.New("sql: select without table")
+	}
+	var (
+		b    strings.Builder
+		args []interface{}
+	)
+	b.WriteString("SELECT ")
+	if len(s.columns) == 0 {
+		b.WriteByte('*')
+	} else {
+		b.WriteString(strings.Join(s.columns, ","))
+	}
+	b.WriteString(" FROM ")
+	b.WriteString(s.from)
+	for _, j := range s.joins {
+		b.WriteByte(' ')
+		args = j.appendTo(&b, args)
+	}
+	args = s.where.appendTo(&b, " WHERE ", args)
+	if len(s.groupBy) > 0 {
+		b.WriteString(" GROUP BY ")
+		b.WriteString(strings.Join(s.groupBy, ","))
+	}
+	args = s.having.appendTo(&b, " HAVING ", args)
+	if len(s.orderBy) > 0 {
+		b.WriteString(" ORDER BY ")
+		b.WriteString(strings.Join(s.orderBy, ","))
+	}
+	if s.limit != "" {
+		b.WriteString(" LIMIT ")
+		b.WriteString(s.limit)
+	}
+	if s.offset != "" {
+		b.WriteString(" OFFSET ")
+		b.WriteString(s.offset)
+	}
+	if s.suffix != "" {
+		b.WriteByte(' ')
+		b.WriteString(s.suffix)
+	}
+	return In(b.String(), args...)
+}
+
+// InsertBuilder builds an INSERT statement.
+type InsertBuilder struct {
+	table   string
+	columns []string
+	values  [][]interface{}
+	suffix  string
+}
+
+// Insert starts an INSERT statement into table.
+func Insert(table string) *InsertBuilder {
+	return &InsertBuilder{table: table}
+}
+
+// Columns sets the columns.
+func (i *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	i.columns = columns
+	return i
+}
+
+// Values adds a row of values, it can be called multiple times to insert
+// multiple rows.
+func (i *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
+	i.values = append(i.values, values)
+	return i
+}
+
+// Suffix appends a raw clause, such as Suffix("ON DUPLICATE KEY UPDATE name=VALUES(name)").
+func (i *InsertBuilder) Suffix(suffix string) *InsertBuilder {
+	i.suffix = suffix
+	return i
+}
+
+// ToSQL returns the statement and args.
+func (i *InsertBuilder) ToSQL() (string, []interface{}, error) {
+	if len(i.values) == 0 {
+		return "", nil, errors.Errorf("sql: insert into %s without values", i.table)
+	}
+	var (
+		b    strings.Builder
+		args []interface{}
+	)
+	b.WriteString("INSERT INTO ")
+	b.WriteString(i.table)
+	if len(i.columns) > 0 {
+		b.WriteString(" (")
+		b.WriteString(strings.Join(i.columns, ","))
+		b.WriteByte(')')
+	}
+	b.WriteString(" VALUES ")
+	for n, row := range i.values {
+		if len(i.columns) > 0 && len(row) != len(i.columns) {
+			return "", nil, errors.Errorf("sql: insert into %s with %d values for %d columns", i.table, len(row), len(i.columns))
+		}
+		if n > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('(')
+		b.WriteString(strings.TrimSuffix(strings.Repeat("?,", len(row)), ","))
+		b.WriteByte(')')
+		args = append(args, row...)
+	}
+	if i.suffix != "" {
+		b.WriteByte(' ')
+		b.WriteString(i.suffix)
+	}
+	return b.String(), args, nil
+}
+
+// UpdateBuilder builds an UPDATE statement.
+type UpdateBuilder struct {
+	table string
+	sets  []expr
+	where where
+	limit string
+}
+
+// Update starts an UPDATE statement of table.
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+// Set sets column to v.
+func (u *UpdateBuilder) Set(column string, v interface{}) *UpdateBuilder {
+	u.sets = append(u.sets, expr{sql: column + "=?", args: []interface{}{v}})
+	return u
+}
+
+// SetExpr sets column to a raw expression, such as SetExpr("count", "count+?", 1).
+func (u *UpdateBuilder) SetExpr(column, sql string, args ...interface{}) *UpdateBuilder {
+	u.sets = append(u.sets, expr{sql: column + "=" + sql, args: args})
+	return u
+}
+
+// Where adds a condition joined by AND, pred is a string with placeholders or a Cond.
+func (u *UpdateBuilder) Where(pred interface{}, args ...interface{}) *UpdateBuilder {
+	u.where.add(pred, args)
+	return u
+}
+
+// Limit sets the LIMIT.
+func (u *UpdateBuilder) Limit(n uint64) *UpdateBuilder {
+	u.limit = strconv.FormatUint(n, 10)
+	return u
+}
+
+// ToSQL returns the statement and args, the slice args are expanded as In.
+func (u *UpdateBuilder) ToSQL() (string, []interface{}, error) {
+	if u.where.err != nil {
+		return "", nil, u.where.err
+	}
+	if len(u.sets) == 0 {
+		return "", nil, errors.Errorf("sql: update %s without set", u.table)
+	}
+	var (
+		b    strings.Builder
+		args []interface{}
+	)
+	b.WriteString("UPDATE ")
+	b.WriteString(u.table)
+	b.WriteString(" SET ")
+	for i, s := range u.sets {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		args = s.appendTo(&b, args)
+	}
+	args = u.where.appendTo(&b, " WHERE ", args)
+	if u.limit != "" {
+		b.WriteString(" LIMIT ")
+		b.WriteString(u.limit)
+	}
+	return In(b.String(), args...)
+}
+
+// DeleteBuilder builds a DELETE statement.
+type DeleteBuilder struct {
+	table string
+	where where
+	limit string
+}
+
+// Delete starts a DELETE statement of table.
+func Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+// Where adds a condition joined by AND, pred is a string with placeholders or a Cond.
+func (d *DeleteBuilder) Where(pred interface{}, args ...interface{}) *DeleteBuilder {
+	d.where.add(pred, args)
+	return d
+}
+
+// Limit sets the LIMIT.
+func (d *DeleteBuilder) Limit(n uint64) *DeleteBuilder {
+	d.limit = strconv.FormatUint(n, 10)
+	return d
+}
+
+// ToSQL returns the statement and args, the slice args are expanded as In.
+// Deleting without any condition is refused.
+func (d *DeleteBuilder) ToSQL() (string, []interface{}, error) {
+	if d.where.err != nil {
+		return "", nil, d.where.err
+	}
+	if len(d.where.conds) == 0 {
+		return "", nil, errors.Errorf("sql: delete from %s without where", d.table)
+	}
+	var (
+		b    strings.Builder
+		args []interface{}
+	)
+	b.WriteString("DELETE FROM ")
+	b.WriteString(d.table)
+	args = d.where.appendTo(&b, " WHERE ", args)
+	if d.limit != "" {
+		b.WriteString(" LIMIT ")
+		b.WriteString(d.limit)
+	}
+	return In(b.String(), args...)
+}
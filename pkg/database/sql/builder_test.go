@@ -0,0 +1,118 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIn(t *testing.T) {
+	q, args, err := In("SELECT * FROM user WHERE id IN (?) AND name = '?' AND state = ?", []int64{1, 2, 3}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM user WHERE id IN (?,?,?) AND name = '?' AND state = ?", q)
+	assert.Equal(t, []interface{}{int64(1), int64(2), int64(3), 1}, args)
+
+	q, args, err = In("UPDATE user SET data = ? WHERE note = 'it\\'s ?' AND id = ?", []byte("x"), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE user SET data = ? WHERE note = 'it\\'s ?' AND id = ?", q)
+	assert.Equal(t, []interface{}{[]byte("x"), 1}, args)
+
+	// ?? is the postgres ? operator, it takes no arg.
+	q, args, err = In("SELECT * FROM doc WHERE tags ?? 'x' AND id IN (?)", []int{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM doc WHERE tags ?? 'x' AND id IN (?,?)", q)
+	assert.Equal(t, []interface{}{1, 2}, args)
+	assert.Equal(t, "SELECT * FROM doc WHERE tags ? 'x' AND id IN ($1,$2)", rebindDollar(q))
+
+	_, _, err = In("SELECT * FROM user WHERE id IN (?)", []int64{})
+	assert.Error(t, err)
+	_, _, err = In("SELECT * FROM user WHERE id = ?")
+	assert.Error(t, err)
+	_, _, err = In("SELECT * FROM user", 1)
+	assert.Error(t, err)
+}
+
+func TestNamed(t *testing.T) {
+	q, args, err := Named("SELECT * FROM user WHERE id IN (:ids) AND name = :name AND ctime > ':x' AND data::text = :name",
+		map[string]interface{}{"ids": []int{1, 2}, "name": "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM user WHERE id IN (?,?) AND name = ? AND ctime > ':x' AND data::text = ?", q)
+	assert.Equal(t, []interface{}{1, 2, "a", "a"}, args)
+
+	q, args, err = Named("INSERT INTO user (id, user_name, mail) VALUES (:id, :user_name, :mail)", &User{Base: Base{ID: 1}, UserName: "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO user (id, user_name, mail) VALUES (?, ?, ?)", q)
+	assert.Len(t, args, 3)
+	assert.Equal(t, int64(1), args[0])
+	assert.Equal(t, "a", args[1])
+
+	q, args, err = Named("SELECT * FROM doc WHERE tags ?? :tag", map[string]interface{}{"tag": "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM doc WHERE tags ?? ?", q)
+	assert.Equal(t, []interface{}{"x"}, args)
+
+	_, _, err = Named("SELECT :nope", map[string]interface{}{})
+	assert.Error(t, err)
+	_, _, err = Named("SELECT :id", 1)
+	assert.Error(t, err)
+}
+
+func TestSelectBuilder(t *testing.T) {
+	q, args, err := Select("id", "name").From("user u").
+		Join("LEFT JOIN role r ON r.mid = u.mid AND r.type = ?", 2).
+		Where("u.state = ? OR u.admin = 1", 1).
+		Where(Or(Eq("u.id", []int64{1, 2}), Eq("u.deleted", nil))).
+		GroupBy("u.id").Having("COUNT(*) > ?", 1).
+		OrderBy("u.id DESC").Limit(10).Offset(20).Suffix("FOR UPDATE").ToSQL()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT id,name FROM user u LEFT JOIN role r ON r.mid = u.mid AND r.type = ?"+
+		" WHERE (u.state = ? OR u.admin = 1) AND (u.id IN (?,?) OR u.deleted IS NULL)"+
+		" GROUP BY u.id HAVING COUNT(*) > ? ORDER BY u.id DESC LIMIT 10 OFFSET 20 FOR UPDATE", q)
+	assert.Equal(t, []interface{}{2, 1, int64(1), int64(2), 1}, args)
+
+	q, args, err = Select().From("user").Where(Eq("id", 1)).ToSQL()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM user WHERE id = ?", q)
+	assert.Equal(t, []interface{}{1}, args)
+
+	_, _, err = Select().From("user").Where(Eq("id", 1), 2).ToSQL()
+	assert.Error(t, err)
+	_, _, err = Select("id").ToSQL()
+	assert.Error(t, err)
+}
+
+func TestInsertBuilder(t *testing.T) {
+	q, args, err := Insert("user").Columns("id", "name").Values(1, "a").Values(2, "b").
+		Suffix("ON DUPLICATE KEY UPDATE name=VALUES(name)").ToSQL()
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO user (id,name) VALUES (?,?),(?,?) ON DUPLICATE KEY UPDATE name=VALUES(name)", q)
+	assert.Equal(t, []interface{}{1, "a", 2, "b"}, args)
+
+	_, _, err = Insert("user").Columns("id", "name").Values(1).ToSQL()
+	assert.Error(t, err)
+	_, _, err = Insert("user").ToSQL()
+	assert.Error(t, err)
+}
+
+func TestUpdateDeleteBuilder(t *testing.T) {
+	q, args, err := Update("user").Set("name", "a").SetExpr("count", "count+?", 1).
+		Where(Eq("id", []int{1, 2})).Limit(2).ToSQL()
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE user SET name=?,count=count+? WHERE id IN (?,?) LIMIT 2", q)
+	assert.Equal(t, []interface{}{"a", 1, 1, 2}, args)
+
+	q, args, err = Delete("user").Where("id = ?", 1).Limit(1).ToSQL()
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE FROM user WHERE id = ? LIMIT 1", q)
+	assert.Equal(t, []interface{}{1}, args)
+
+	q, args, err = Delete("user").Where(And(Expr("a=? OR b=?", 1, 2), Eq("c", 3))).ToSQL()
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE FROM user WHERE ((a=? OR b=?) AND c = ?)", q)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+
+	_, _, err = Delete("user").ToSQL()
+	assert.Error(t, err)
+	_, _, err = Update("user").ToSQL()
+	assert.Error(t, err)
+}
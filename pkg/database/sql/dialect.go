@@ -0,0 +1,93 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/net/trace"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+// drivers of Config.Driver.
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+)
+
+// dialect the differences between databases.
+type dialect struct {
+	// driver the driver name of database/sql.
+	driver string
+	// parseAddr returns the host:port of dsn.
+	parseAddr func(dsn string) string
+	// traceTags returns the span tags of connection of dsn.
+	traceTags func(dsn, addr string) []trace.Tag
+	// rebind converts the ? placeholders to the ones of database, nil means ?.
+	rebind func(query string) string
+	// mapErr maps the database errors to ecodes, nil means unchanged.
+	mapErr func(err error) error
+	// lag returns the replication lag of a replica.
+	lag func(c context.Context, db *sql.DB) (time.Duration, error)
+	// heartbeat returns the statement of lag in microseconds by the heartbeat table.
+	heartbeat func(table string) string
+	// tryLock tries to acquire the session lock of name on conn without waiting.
+	tryLock func(c context.Context, conn *sql.Conn, name string) (bool, error)
+	// unlock releases the session lock of name on conn.
+	unlock func(c context.Context, conn *sql.Conn, name string) error
+	// noTable reports whether err is caused by a missing table.
+	noTable func(err error) bool
+}
+
+var _mysqlDialect = &dialect{
+	driver:    DriverMySQL,
+	parseAddr: parseDSNAddr,
+	traceTags: traceTags,
+	lag:       slaveLag,
+	heartbeat: func(table string) string {
+		return "SELECT TIMESTAMPDIFF(MICROSECOND, MAX(ts), UTC_TIMESTAMP(6)) FROM " + table
+	},
+	tryLock: func(c context.Context, conn *sql.Conn, name string) (bool, error) {
+		var ok sql.NullInt64
+		if err := conn.QueryRowContext(c, "SELECT GET_LOCK(?, 0)", name).Scan(&ok); err != nil {
+			return false, errors.WithStack(err)
+		}
+		return ok.Int64 == 1, nil
+	},
+	unlock: func(c context.Context, conn *sql.Conn, name string) error {
+		_, err := conn.ExecContext(c, "DO RELEASE_LOCK(?)", name)
+		return errors.WithStack(err)
+	},
+	noTable: func(err error) bool {
+		var me *mysql.MySQLError
+		return errors.As(err, &me) && me.Number == _errNoSuchTable
+	},
+}
+
+func dialectOf(driver string) (*dialect, error) {
+	switch driver {
+	case "", DriverMySQL:
+		return _mysqlDialect, nil
+	case DriverPostgres:
+		return _postgresDialect, nil
+	}
+	return nil, errors.Errorf("sql: unsupported driver %q", driver)
+}
+
+// rebind converts the placeholders of query for the database of conn.
+func (db *conn) rebind(query string) string {
+	if db.dialect.rebind == nil {
+		return query
+	}
+	return db.dialect.rebind(query)
+}
+
+// mapErr maps the database error to ecode for the database of conn.
+func (db *conn) mapErr(err error) error {
+	if err == nil || db.dialect.mapErr == nil {
+		return err
+	}
+	return db.dialect.mapErr(err)
+}
@@ -0,0 +1,152 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/net/netutil/breaker"
+	xtime "github.com/go-kratos/kratos/pkg/time"
+)
+
+// fakeResult the result of a statement executed by fakeDriver.
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error
+}
+
+// fakeDriver answers the statements by handle and records them.
+type fakeDriver struct {
+	mu      sync.Mutex
+	handle  func(query string, args []driver.NamedValue) *fakeResult
+	queries []string
+}
+
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+func (d *fakeDriver) Driver() driver.Driver                        { return nil }
+
+func (d *fakeDriver) do(query string, args []driver.NamedValue) *fakeResult {
+	d.mu.Lock()
+	d.queries = append(d.queries, query)
+	handle := d.handle
+	d.mu.Unlock()
+	if handle == nil {
+		return &fakeResult{}
+	}
+	if r := handle(query, args); r != nil {
+		return r
+	}
+	return &fakeResult{}
+}
+
+func (d *fakeDriver) history() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.queries...)
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c: c, query: query}, nil
+}
+func (c *fakeConn) Close() error { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.d.do("BEGIN", nil)
+	return c, nil
+}
+func (c *fakeConn) Commit() error   { return c.d.do("COMMIT", nil).err }
+func (c *fakeConn) Rollback() error { return c.d.do("ROLLBACK", nil).err }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	r := c.d.do(query, args)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &fakeRows{columns: r.columns, rows: r.rows}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	r := c.d.do(query, args)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return driver.RowsAffected(len(r.rows)), nil
+}
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.c.ExecContext(context.Background(), s.query, namedValues(args))
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.c.QueryContext(context.Background(), s.query, namedValues(args))
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	nv := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		nv[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return nv
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	i       int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.i])
+	r.i++
+	return nil
+}
+
+func newFakeConfig() *Config {
+	return &Config{
+		QueryTimeout: xtime.Duration(time.Second),
+		ExecTimeout:  xtime.Duration(time.Second),
+		TranTimeout:  xtime.Duration(time.Second),
+	}
+}
+
+func newFakeConn(d *fakeDriver, c *Config, addr string) *conn {
+	db := sql.OpenDB(d)
+	return &conn{
+		DB:      db,
+		breaker: breaker.NewGroup(nil).Get(addr),
+		conf:    c,
+		addr:    addr,
+		name:    addr,
+		dialect: _mysqlDialect,
+		stat:    newRecorder(db, c, addr, addr, nil, _mysqlDialect),
+	}
+}
+
+// newFakeDB returns a db whose master and replicas are all answered by d.
+func newFakeDB(d *fakeDriver, replicas int) *DB {
+	c := newFakeConfig()
+	db := &DB{write: newFakeConn(d, c, "master")}
+	for i := 0; i < replicas; i++ {
+		db.read = append(db.read, newReplica(newFakeConn(d, c, "replica"), 1))
+	}
+	db.master = &DB{write: db.write}
+	return db
+}
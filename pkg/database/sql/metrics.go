@@ -34,4 +34,26 @@ var (
 		Help:      "mysql client connections current.",
 		Labels:    []string{"name", "addr", "state"},
 	})
+	_metricQueryDur = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: namespace,
+		Subsystem: "queries",
+		Name:      "duration_ms",
+		Help:      "mysql client statements duration(ms) by fingerprint.",
+		Labels:    []string{"name", "addr", "fingerprint"},
+		Buckets:   []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500},
+	})
+	_metricQuerySlow = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: "queries",
+		Name:      "slow_total",
+		Help:      "mysql client slow statements count by fingerprint.",
+		Labels:    []string{"name", "addr", "fingerprint"},
+	})
+	_metricReplicaLag = metric.NewGaugeVec(&metric.GaugeVecOpts{
+		Namespace: namespace,
+		Subsystem: "replica",
+		Name:      "lag_seconds",
+		Help:      "mysql client replica replication lag(s).",
+		Labels:    []string{"name", "addr"},
+	})
 )
@@ -0,0 +1,450 @@
+package sql
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/log"
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	_errNoSuchTable = 1146
+
+	_defaultMigrateDir   = "migrations"
+	_defaultMigrateTable = "schema_migrations"
+	_defaultLockTimeout  = time.Minute
+)
+
+// ErrLocked is returned when the migration lock is held by others until the
+// lock timeout.
+var ErrLocked = errors.New("sql: migration is locked by others")
+
+var _migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// MigrateConfig migration config.
+type MigrateConfig struct {
+	// Dir the directory of migration files, default migrations.
+	Dir string
+	// Table the version table, default schema_migrations.
+	Table string
+	// LockTimeout the max time waiting for the lock of others, default 1m.
+	LockTimeout xtime.Duration
+	// DryRun makes Up and Down return the migrations to be run without
+	// running them.
+	DryRun bool
+}
+
+// Migration a versioned migration, which is the files
+// {version}_{name}.up.sql and {version}_{name}.down.sql in the directory.
+type Migration struct {
+	Version int64
+	Name    string
+	// Up the statements to migrate.
+	Up string
+	// Down the statements to revert, empty if the down file is absent.
+	Down string
+	// Checksum the sha256 of Up.
+	Checksum string
+	// AppliedAt the time of applying, zero if it's not applied.
+	AppliedAt time.Time
+}
+
+// Migrator runs the migrations of a database, the runs of all the processes
+// on the same database are serialized by a database lock, so it's safe to
+// run it at the startup of every instance.
+type Migrator struct {
+	db         *DB
+	c          *MigrateConfig
+	migrations []*Migration
+}
+
+// NewMigrator loads the migrations of c.Dir for db.
+func NewMigrator(db *DB, c *MigrateConfig) (*Migrator, error) {
+	if c == nil {
+		c = &MigrateConfig{}
+	}
+	if c.Dir == "" {
+		c.Dir = _defaultMigrateDir
+	}
+	if c.Table == "" {
+		c.Table = _defaultMigrateTable
+	}
+	if c.LockTimeout <= 0 {
+		c.LockTimeout = xtime.Duration(_defaultLockTimeout)
+	}
+	ms, err := LoadMigrations(c.Dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, c: c, migrations: ms}, nil
+}
+
+// LoadMigrations loads the migrations of dir ordered by version.
+func LoadMigrations(dir string) ([]*Migration, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, fi := range fis {
+		sm := _migrationFile.FindStringSubmatch(fi.Name())
+		if fi.IsDir() || sm == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(sm[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "migration %s", fi.Name())
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: sm[2]}
+			byVersion[version] = m
+		} else if m.Name != sm[2] {
+			return nil, errors.Errorf("sql: duplicate migration version %d: %s and %s", version, m.Name, sm[2])
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if sm[3] == "up" {
+			m.Up = string(b)
+			m.Checksum = checksum(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+	ms := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Checksum == "" {
+			return nil, errors.Errorf("sql: migration %d_%s has no up file", m.Version, m.Name)
+		}
+		ms = append(ms, m)
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Version < ms[j].Version })
+	return ms, nil
+}
+
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Status returns all the migrations and the applied time of them, including
+// the applied ones whose files are absent.
+func (m *Migrator) Status(c context.Context) (ms []*Migration, err error) {
+	applied, err := m.applied(c, m.db.write.DB)
+	if err != nil {
+		return
+	}
+	for _, mi := range m.migrations {
+		cp := *mi
+		if a, ok := applied[mi.Version]; ok {
+			cp.AppliedAt = a.AppliedAt
+			delete(applied, mi.Version)
+		}
+		ms = append(ms, &cp)
+	}
+	for _, a := range applied {
+		ms = append(ms, a)
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Version < ms[j].Version })
+	return
+}
+
+// Up runs all the pending migrations in order, including the ones older than
+// the applied, and returns the run ones. It stops at the first error, and
+// fails before running if an applied migration file is changed.
+func (m *Migrator) Up(c context.Context) ([]*Migration, error) {
+	return m.run(c, func(applied map[int64]*Migration) (ms []*Migration, err error) {
+		for _, mi := range m.migrations {
+			a, ok := applied[mi.Version]
+			if !ok {
+				ms = append(ms, mi)
+				continue
+			}
+			if a.Checksum != mi.Checksum {
+				return nil, errors.Errorf("sql: applied migration %d_%s is changed, checksum %s != %s", mi.Version, mi.Name, mi.Checksum, a.Checksum)
+			}
+		}
+		return
+	}, true)
+}
+
+// Down reverts the last n applied migrations in reverse order and returns
+// the reverted ones, n must be positive.
+func (m *Migrator) Down(c context.Context, n int) ([]*Migration, error) {
+	if n <= 0 {
+		return nil, errors.Errorf("sql: migrate down count %d must be positive", n)
+	}
+	return m.run(c, func(applied map[int64]*Migration) (ms []*Migration, err error) {
+		versions := make([]int64, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+		if n < len(versions) {
+			versions = versions[:n]
+		}
+		for _, v := range versions {
+			mi := m.find(v)
+			if mi == nil {
+				return nil, errors.Errorf("sql: applied migration %d has no file", v)
+			}
+			if strings.TrimSpace(mi.Down) == "" {
+				return nil, errors.Errorf("sql: migration %d_%s has no down file", mi.Version, mi.Name)
+			}
+			ms = append(ms, mi)
+		}
+		return
+	}, false)
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	i := sort.Search(len(m.migrations), func(i int) bool { return m.migrations[i].Version >= version })
+	if i < len(m.migrations) && m.migrations[i].Version == version {
+		return m.migrations[i]
+	}
+	return nil
+}
+
+// run runs the migrations planned by plan under the lock.
+func (m *Migrator) run(c context.Context, plan func(applied map[int64]*Migration) ([]*Migration, error), up bool) (ms []*Migration, err error) {
+	if m.c.DryRun {
+		var applied map[int64]*Migration
+		if applied, err = m.applied(c, m.db.write.DB); err != nil {
+			return
+		}
+		return plan(applied)
+	}
+	w := m.db.write
+	conn, err := w.DB.Conn(c)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer conn.Close()
+	if err = m.lock(c, conn); err != nil {
+		return
+	}
+	defer func() {
+		if e := w.dialect.unlock(context.Background(), conn, m.lockName()); e != nil {
+			log.Error("%s migration unlock error(%v)", _family, e)
+		}
+	}()
+	if _, err = conn.ExecContext(c, "CREATE TABLE IF NOT EXISTS "+m.c.Table+
+		" (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, checksum CHAR(64) NOT NULL, applied_at BIGINT NOT NULL)"); err != nil {
+		return nil, errors.Wrapf(w.mapErr(err), "create table %s", m.c.Table)
+	}
+	applied, err := m.applied(c, conn)
+	if err != nil {
+		return
+	}
+	planned, err := plan(applied)
+	if err != nil {
+		return
+	}
+	action := "up"
+	if !up {
+		action = "down"
+	}
+	for _, mi := range planned {
+		now := time.Now()
+		if err = m.apply(c, conn, mi, up); err != nil {
+			return
+		}
+		log.Info("%s migration %d_%s %s in %v", _family, mi.Version, mi.Name, action, time.Since(now))
+		ms = append(ms, mi)
+	}
+	return
+}
+
+// lock acquires the migration lock, waiting for others until LockTimeout.
+func (m *Migrator) lock(c context.Context, conn *sql.Conn) error {
+	dl := m.db.write.dialect
+	deadline := time.Now().Add(time.Duration(m.c.LockTimeout))
+	for {
+		ok, err := dl.tryLock(c, conn, m.lockName())
+		if err != nil || ok {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return ErrLocked
+		}
+		log.Info("%s migration is locked by others, waiting", _family)
+		select {
+		case <-time.After(time.Second):
+		case <-c.Done():
+			return errors.WithStack(c.Err())
+		}
+	}
+}
+
+func (m *Migrator) lockName() string {
+	return "kratos_migrate_" + m.c.Table
+}
+
+// apply runs a migration and records it in a transaction, the DDL of mysql
+// commits implicitly so a failed mysql migration may be applied partially.
+func (m *Migrator) apply(c context.Context, conn *sql.Conn, mi *Migration, up bool) (err error) {
+	w := m.db.write
+	tx, err := conn.BeginTx(c, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	stmts := mi.Up
+	if !up {
+		stmts = mi.Down
+	}
+	for _, stmt := range splitStatements(stmts) {
+		if _, err = tx.ExecContext(c, stmt); err != nil {
+			return errors.Wrapf(w.mapErr(err), "migration %d_%s: %s", mi.Version, mi.Name, stmt)
+		}
+	}
+	if up {
+		_, err = tx.ExecContext(c, w.rebind("INSERT INTO "+m.c.Table+"(version,name,checksum,applied_at) VALUES(?,?,?,?)"),
+			mi.Version, mi.Name, mi.Checksum, time.Now().Unix())
+	} else {
+		_, err = tx.ExecContext(c, w.rebind("DELETE FROM "+m.c.Table+" WHERE version=?"), mi.Version)
+	}
+	if err != nil {
+		return errors.Wrapf(w.mapErr(err), "migration %d_%s record version", mi.Version, mi.Name)
+	}
+	return errors.WithStack(tx.Commit())
+}
+
+type querier interface {
+	QueryContext(c context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// applied returns the applied migrations by version, it's empty if the
+// version table does not exist.
+func (m *Migrator) applied(c context.Context, q querier) (map[int64]*Migration, error) {
+	rows, err := q.QueryContext(c, "SELECT version,name,checksum,applied_at FROM "+m.c.Table)
+	if err != nil {
+		if m.db.write.dialect.noTable(err) {
+			return map[int64]*Migration{}, nil
+		}
+		return nil, errors.Wrapf(err, "query %s", m.c.Table)
+	}
+	defer rows.Close()
+	applied := make(map[int64]*Migration)
+	for rows.Next() {
+		var (
+			a  = new(Migration)
+			at int64
+		)
+		if err = rows.Scan(&a.Version, &a.Name, &a.Checksum, &at); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		a.AppliedAt = time.Unix(at, 0)
+		applied[a.Version] = a
+	}
+	return applied, errors.WithStack(rows.Err())
+}
+
+// splitStatements splits sql by the semicolons which are not in quotes,
+// comments or the dollar quotes of postgresql.
+func splitStatements(s string) (stmts []string) {
+	var start int
+	add := func(end int) {
+		if stmt := strings.TrimSpace(s[start:end]); stmt != "" && !onlyComments(stmt) {
+			stmts = append(stmts, stmt)
+		}
+		start = end + 1
+	}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			for i++; i < len(s) && s[i] != c; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+		case c == '-' && strings.HasPrefix(s[i:], "--"):
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(s[i:], "/*"):
+			if end := strings.Index(s[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(s)
+			}
+		case c == '$':
+			// $$ or $tag$ of postgresql function bodies.
+			end := strings.IndexByte(s[i+1:], '$')
+			if end < 0 || !isDollarTag(s[i+1:i+1+end]) {
+				continue
+			}
+			tag := s[i : i+end+2]
+			if n := strings.Index(s[i+len(tag):], tag); n >= 0 {
+				i += len(tag) + n + len(tag) - 1
+			} else {
+				i = len(s)
+			}
+		case c == ';':
+			add(i)
+		}
+	}
+	if start < len(s) {
+		add(len(s))
+	}
+	return
+}
+
+func isDollarTag(tag string) bool {
+	for i := 0; i < len(tag); i++ {
+		c := tag[i]
+		if c != '_' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !(i > 0 && '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func onlyComments(stmt string) bool {
+	for _, line := range strings.Split(stmt, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateMigration creates the up and down files of a new migration named
+// name in dir, the version is the current time such as 20200102150405.
+func CreateMigration(dir, name string) (up, down string, err error) {
+	if !regexp.MustCompile(`^\w+$`).MatchString(name) {
+		return "", "", errors.Errorf("sql: invalid migration name %q, only letters, digits and _ are allowed", name)
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return "", "", errors.WithStack(err)
+	}
+	prefix := filepath.Join(dir, time.Now().Format("20060102150405")+"_"+name)
+	up, down = prefix+".up.sql", prefix+".down.sql"
+	if err = ioutil.WriteFile(up, []byte("-- "+name+"\n"), 0644); err != nil {
+		return "", "", errors.WithStack(err)
+	}
+	if err = ioutil.WriteFile(down, []byte("-- revert "+name+"\n"), 0644); err != nil {
+		return "", "", errors.WithStack(err)
+	}
+	return
+}
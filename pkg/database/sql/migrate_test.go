@@ -0,0 +1,192 @@
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeVersions handles the statements of the version table and the lock.
+type fakeVersions struct {
+	mu     sync.Mutex
+	rows   [][]driver.Value
+	noLock bool
+}
+
+func (f *fakeVersions) handle(query string, args []driver.NamedValue) *fakeResult {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case strings.HasPrefix(query, "SELECT GET_LOCK"):
+		if f.noLock {
+			return &fakeResult{columns: []string{"l"}, rows: [][]driver.Value{{int64(0)}}}
+		}
+		return &fakeResult{columns: []string{"l"}, rows: [][]driver.Value{{int64(1)}}}
+	case strings.HasPrefix(query, "SELECT version"):
+		if f.rows == nil {
+			return &fakeResult{err: &mysql.MySQLError{Number: _errNoSuchTable}}
+		}
+		return &fakeResult{columns: []string{"version", "name", "checksum", "applied_at"}, rows: f.rows}
+	case strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS schema_migrations"):
+		if f.rows == nil {
+			f.rows = [][]driver.Value{}
+		}
+	case strings.HasPrefix(query, "INSERT INTO schema_migrations"):
+		f.rows = append(f.rows, []driver.Value{args[0].Value, args[1].Value, args[2].Value, args[3].Value})
+	case strings.HasPrefix(query, "DELETE FROM schema_migrations"):
+		for i, r := range f.rows {
+			if r[0] == args[0].Value {
+				f.rows = append(f.rows[:i], f.rows[i+1:]...)
+				break
+			}
+		}
+	case strings.Contains(query, "fail"):
+		return &fakeResult{err: mysql.ErrInvalidConn}
+	}
+	return nil
+}
+
+func writeMigrations(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "migrations")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for name, content := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"1_create_user.up.sql":     "CREATE TABLE user(id INT);\nCREATE INDEX ix ON user(id);",
+		"1_create_user.down.sql":   "DROP TABLE user;",
+		"2_add_name.up.sql":        "ALTER TABLE user ADD name VARCHAR(8) DEFAULT 'a;b';",
+		"2_add_name.down.sql":      "ALTER TABLE user DROP name;",
+		"README.md":                "migrations",
+		"3_without_down.up.sql.gz": "",
+	})
+	defer os.RemoveAll(dir)
+	f := &fakeVersions{}
+	d := &fakeDriver{handle: f.handle}
+	db := newFakeDB(d, 0)
+
+	m, err := NewMigrator(db, &MigrateConfig{Dir: dir, DryRun: true})
+	assert.NoError(t, err)
+	ms, err := m.Up(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, ms, 2)
+	assert.Equal(t, []string{"SELECT version,name,checksum,applied_at FROM schema_migrations"}, d.history())
+
+	m.c.DryRun = false
+	ms, err = m.Up(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, ms, 2)
+	assert.Equal(t, []string{
+		"BEGIN",
+		"CREATE TABLE user(id INT)",
+		"CREATE INDEX ix ON user(id)",
+		"INSERT INTO schema_migrations(version,name,checksum,applied_at) VALUES(?,?,?,?)",
+		"COMMIT",
+		"BEGIN",
+		"ALTER TABLE user ADD name VARCHAR(8) DEFAULT 'a;b'",
+		"INSERT INTO schema_migrations(version,name,checksum,applied_at) VALUES(?,?,?,?)",
+		"COMMIT",
+		"DO RELEASE_LOCK(?)",
+	}, d.history()[4:])
+
+	ms, err = m.Up(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, ms)
+	status, err := m.Status(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, status, 2)
+	assert.False(t, status[1].AppliedAt.IsZero())
+
+	ms, err = m.Down(context.Background(), 1)
+	assert.NoError(t, err)
+	if assert.Len(t, ms, 1) {
+		assert.Equal(t, int64(2), ms[0].Version)
+	}
+	assert.Len(t, f.rows, 1)
+
+	for _, n := range []int{0, -1} {
+		ms, err = m.Down(context.Background(), n)
+		assert.EqualError(t, err, fmt.Sprintf("sql: migrate down count %d must be positive", n))
+		assert.Empty(t, ms)
+	}
+	assert.Len(t, f.rows, 1)
+}
+
+func TestMigrateChanged(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{"1_a.up.sql": "CREATE TABLE a(id INT);"})
+	defer os.RemoveAll(dir)
+	f := &fakeVersions{}
+	db := newFakeDB(&fakeDriver{handle: f.handle}, 0)
+	m, err := NewMigrator(db, &MigrateConfig{Dir: dir})
+	assert.NoError(t, err)
+	_, err = m.Up(context.Background())
+	assert.NoError(t, err)
+	_, err = m.Down(context.Background(), 1)
+	assert.Error(t, err, "no down file")
+
+	m.migrations[0].Checksum = checksum([]byte("CREATE TABLE a(id BIGINT);"))
+	_, err = m.Up(context.Background())
+	assert.Error(t, err)
+}
+
+func TestMigrateLockedAndFail(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"1_a.up.sql": "CREATE TABLE a(id INT);",
+		"2_b.up.sql": "fail;",
+	})
+	defer os.RemoveAll(dir)
+	f := &fakeVersions{noLock: true}
+	db := newFakeDB(&fakeDriver{handle: f.handle}, 0)
+	m, err := NewMigrator(db, &MigrateConfig{Dir: dir, LockTimeout: 1})
+	assert.NoError(t, err)
+	_, err = m.Up(context.Background())
+	assert.Equal(t, ErrLocked, err)
+
+	f.noLock = false
+	ms, err := m.Up(context.Background())
+	assert.Error(t, err)
+	assert.Len(t, ms, 1)
+	assert.Len(t, f.rows, 1)
+}
+
+func TestLoadMigrationsError(t *testing.T) {
+	for _, files := range []map[string]string{
+		{"1_a.down.sql": ""},
+		{"1_a.up.sql": "", "1_b.up.sql": ""},
+	} {
+		dir := writeMigrations(t, files)
+		_, err := LoadMigrations(dir)
+		assert.Error(t, err, "%v", files)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	assert.Equal(t, []string{
+		"-- create\nCREATE TABLE a(name VARCHAR(8) DEFAULT 'x;y')",
+		"INSERT INTO a VALUES(\"a;\") /* ; */",
+		"CREATE FUNCTION f() RETURNS trigger AS $body$ BEGIN RETURN NEW; END; $body$ LANGUAGE plpgsql",
+		"SELECT $$;$$",
+	}, splitStatements(`-- create
+CREATE TABLE a(name VARCHAR(8) DEFAULT 'x;y');
+INSERT INTO a VALUES("a;") /* ; */;
+CREATE FUNCTION f() RETURNS trigger AS $body$ BEGIN RETURN NEW; END; $body$ LANGUAGE plpgsql;
+SELECT $$;$$;
+-- the end
+`))
+}
@@ -11,6 +11,8 @@ import (
 
 // Config mysql config.
 type Config struct {
+	Driver       string          // mysql or postgres, default mysql.
+	Name         string          // name of metrics and breaker, default the addr of dsn.
 	DSN          string          // write data source name.
 	ReadDSN      []string        // read data source name.
 	Active       int             // pool
@@ -20,6 +22,24 @@ type Config struct {
 	ExecTimeout  time.Duration   // execute sql timeout
 	TranTimeout  time.Duration   // transaction sql timeout
 	Breaker      *breaker.Config // breaker
+	TranRetry    int             // retries of Transact on deadlock or lock wait timeout, default 3, negative means no retry.
+	TranBackoff  time.Duration   // base backoff of Transact retries, default 20ms.
+	// ReadWeight the weight of each ReadDSN, default 1.
+	ReadWeight []int
+	// MaxLag the replicas lagging behind master more than MaxLag are not read
+	// until they catch up, 0 means no lag check.
+	MaxLag time.Duration
+	// CheckInterval the interval of replicas health check, default 5s.
+	CheckInterval time.Duration
+	// Heartbeat the heartbeat table written by master, such as the
+	// "heartbeat.heartbeat" of pt-heartbeat --utc, the lag is measured by the
+	// ts column of it instead of SHOW SLAVE STATUS if set.
+	Heartbeat string
+	// SlowThreshold the statements slower than it are logged, default 250ms.
+	SlowThreshold time.Duration
+	// ExplainRate the sampling rate of the slow SELECT statements whose
+	// EXPLAIN is captured, 0 means never.
+	ExplainRate float64
 }
 
 // NewMySQL new db and retry connection when has error.
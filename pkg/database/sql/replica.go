@@ -0,0 +1,212 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/log"
+
+	"github.com/pkg/errors"
+)
+
+const _defaultCheckInterval = 5 * time.Second
+
+// replica a read connection with weight and health.
+type replica struct {
+	*conn
+	weight  int
+	current int
+	// unhealthy is 1 if the replica is down or lagging too much.
+	unhealthy int32
+}
+
+func newReplica(c *conn, weight int) *replica {
+	return &replica{conn: c, weight: weight}
+}
+
+func (r *replica) healthy() bool {
+	return atomic.LoadInt32(&r.unhealthy) == 0
+}
+
+type masterKey struct{}
+
+// masterFlag pins the reads of a context to master.
+type masterFlag struct {
+	// always is set by WithMaster.
+	always bool
+	// written is set after a write with the context.
+	written int32
+}
+
+// WithMaster returns a context whose reads always go to master.
+func WithMaster(c context.Context) context.Context {
+	return context.WithValue(c, masterKey{}, &masterFlag{always: true})
+}
+
+// WithReadYourWrites returns a context whose reads go to master once a
+// write is done with it or its children, so that the writes are visible
+// to the following reads, such as the context of a request.
+func WithReadYourWrites(c context.Context) context.Context {
+	if _, ok := c.Value(masterKey{}).(*masterFlag); ok {
+		return c
+	}
+	return context.WithValue(c, masterKey{}, &masterFlag{})
+}
+
+// markWritten pins the reads of c to master if c is from WithReadYourWrites.
+func markWritten(c context.Context) {
+	if f, ok := c.Value(masterKey{}).(*masterFlag); ok {
+		atomic.StoreInt32(&f.written, 1)
+	}
+}
+
+func readMaster(c context.Context) bool {
+	f, ok := c.Value(masterKey{}).(*masterFlag)
+	return ok && (f.always || atomic.LoadInt32(&f.written) == 1)
+}
+
+// replicas returns the healthy replicas to read, the first one is picked
+// by smooth weighted round robin, the others are the fallbacks. It's empty
+// if the reads of c are pinned to master.
+func (db *DB) replicas(c context.Context) []*conn {
+	if len(db.read) == 0 || readMaster(c) {
+		return nil
+	}
+	db.pickMu.Lock()
+	var (
+		best  *replica
+		total int
+	)
+	for _, r := range db.read {
+		if !r.healthy() {
+			continue
+		}
+		r.current += r.weight
+		total += r.weight
+		if best == nil || r.current > best.current {
+			best = r
+		}
+	}
+	if best == nil {
+		db.pickMu.Unlock()
+		return nil
+	}
+	best.current -= total
+	db.pickMu.Unlock()
+	cs := make([]*conn, 0, len(db.read))
+	cs = append(cs, best.conn)
+	for _, r := range db.read {
+		if r != best && r.healthy() {
+			cs = append(cs, r.conn)
+		}
+	}
+	return cs
+}
+
+func (db *DB) checkproc() {
+	interval := time.Duration(db.write.conf.CheckInterval)
+	if interval <= 0 {
+		interval = _defaultCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-db.closed:
+			return
+		case <-ticker.C:
+			db.checkReplicas()
+		}
+	}
+}
+
+// checkReplicas removes the replicas which are down or lagging more than
+// MaxLag from reading, and restores them when they recover.
+func (db *DB) checkReplicas() {
+	conf := db.write.conf
+	for _, r := range db.read {
+		c, cancel := context.WithTimeout(context.Background(), time.Duration(conf.QueryTimeout)+time.Second)
+		lag, err := r.lag(c, conf)
+		cancel()
+		healthy := err == nil && (conf.MaxLag <= 0 || lag <= time.Duration(conf.MaxLag))
+		if err == nil {
+			_metricReplicaLag.Set(lag.Seconds(), r.name, r.addr)
+		}
+		var state int32
+		if !healthy {
+			state = 1
+		}
+		if old := atomic.SwapInt32(&r.unhealthy, state); old != state {
+			if healthy {
+				log.Info("%s replica(%s) is back, lag(%v)", _family, r.addr, lag)
+			} else {
+				log.Warn("%s replica(%s) is removed from reading, lag(%v) error(%v)", _family, r.addr, lag, err)
+			}
+		}
+	}
+}
+
+// lag returns the replication lag of replica, it's 0 if the lag is not checked.
+func (r *replica) lag(c context.Context, conf *Config) (time.Duration, error) {
+	if err := r.DB.PingContext(c); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if conf.MaxLag <= 0 {
+		return 0, nil
+	}
+	if conf.Heartbeat != "" {
+		var us sql.NullInt64
+		if err := r.DB.QueryRowContext(c, r.dialect.heartbeat(conf.Heartbeat)).Scan(&us); err != nil {
+			return 0, errors.WithStack(err)
+		}
+		if !us.Valid {
+			return 0, errors.Errorf("sql: empty heartbeat table %s", conf.Heartbeat)
+		}
+		return time.Duration(us.Int64) * time.Microsecond, nil
+	}
+	return r.dialect.lag(c, r.DB)
+}
+
+// slaveLag returns Seconds_Behind_Master of SHOW SLAVE STATUS, a server which
+// is not a replica has no lag.
+func slaveLag(c context.Context, db *sql.DB) (time.Duration, error) {
+	rows, err := db.QueryContext(c, "SHOW SLAVE STATUS")
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return 0, errors.WithStack(rows.Err())
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err = rows.Scan(dest...); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	for i, col := range columns {
+		if !strings.EqualFold(col, "Seconds_Behind_Master") && !strings.EqualFold(col, "Seconds_Behind_Source") {
+			continue
+		}
+		// NULL means the replication is broken.
+		if values[i] == nil {
+			return 0, errors.New("sql: replication is not running")
+		}
+		sec, err := strconv.ParseInt(string(values[i]), 10, 64)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		return time.Duration(sec) * time.Second, nil
+	}
+	return 0, errors.New("sql: no Seconds_Behind_Master in SHOW SLAVE STATUS")
+}
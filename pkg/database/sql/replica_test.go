@@ -0,0 +1,149 @@
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lagDriver reports lag by SHOW SLAVE STATUS or heartbeat, and counts the reads.
+type lagDriver struct {
+	fakeDriver
+	mu    sync.Mutex
+	lag   driver.Value
+	reads int
+}
+
+func newLagDriver() *lagDriver {
+	d := &lagDriver{lag: int64(0)}
+	d.handle = func(query string, args []driver.NamedValue) *fakeResult {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		switch {
+		case query == "SHOW SLAVE STATUS":
+			return &fakeResult{
+				columns: []string{"Slave_IO_State", "Seconds_Behind_Master"},
+				rows:    [][]driver.Value{{"Waiting for master to send event", d.lag}},
+			}
+		case strings.HasPrefix(query, "SELECT TIMESTAMPDIFF"):
+			var us driver.Value
+			if d.lag != nil {
+				us = d.lag.(int64) * 1e6
+			}
+			return &fakeResult{columns: []string{"lag"}, rows: [][]driver.Value{{us}}}
+		case query == "SELECT":
+			d.reads++
+		}
+		return &fakeResult{columns: []string{"id"}}
+	}
+	return d
+}
+
+func (d *lagDriver) setLag(lag driver.Value) {
+	d.mu.Lock()
+	d.lag = lag
+	d.mu.Unlock()
+}
+
+func (d *lagDriver) readCount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := d.reads
+	d.reads = 0
+	return n
+}
+
+func newReplicaDB(weights ...int) (*DB, *lagDriver, []*lagDriver) {
+	c := newFakeConfig()
+	c.MaxLag = xtime.Duration(5 * time.Second)
+	master := newLagDriver()
+	db := &DB{write: newFakeConn(&master.fakeDriver, c, "master")}
+	var ds []*lagDriver
+	for i, w := range weights {
+		d := newLagDriver()
+		ds = append(ds, d)
+		db.read = append(db.read, newReplica(newFakeConn(&d.fakeDriver, c, "replica"+string(rune('0'+i))), w))
+	}
+	db.master = &DB{write: db.write}
+	return db, master, ds
+}
+
+func query(t *testing.T, c context.Context, db *DB, n int) {
+	for i := 0; i < n; i++ {
+		rows, err := db.Query(c, "SELECT")
+		assert.NoError(t, err)
+		rows.Close()
+	}
+}
+
+func TestReplicaWeight(t *testing.T) {
+	db, master, ds := newReplicaDB(1, 3)
+	query(t, context.Background(), db, 8)
+	assert.Equal(t, 0, master.readCount())
+	assert.Equal(t, 2, ds[0].readCount())
+	assert.Equal(t, 6, ds[1].readCount())
+}
+
+func TestReplicaLag(t *testing.T) {
+	db, master, ds := newReplicaDB(1, 1)
+	ds[0].setLag(int64(10))
+	db.checkReplicas()
+	query(t, context.Background(), db, 4)
+	assert.Equal(t, 0, ds[0].readCount())
+	assert.Equal(t, 4, ds[1].readCount())
+
+	// broken replication.
+	ds[1].setLag(nil)
+	db.checkReplicas()
+	query(t, context.Background(), db, 2)
+	assert.Equal(t, 2, master.readCount())
+
+	ds[0].setLag(int64(1))
+	ds[1].setLag(int64(0))
+	db.checkReplicas()
+	query(t, context.Background(), db, 4)
+	assert.Equal(t, 2, ds[0].readCount())
+	assert.Equal(t, 2, ds[1].readCount())
+}
+
+func TestReplicaHeartbeat(t *testing.T) {
+	db, _, ds := newReplicaDB(1)
+	db.write.conf.Heartbeat = "heartbeat.heartbeat"
+	ds[0].setLag(int64(6))
+	db.checkReplicas()
+	assert.False(t, db.read[0].healthy())
+	assert.Contains(t, ds[0].history(), "SELECT TIMESTAMPDIFF(MICROSECOND, MAX(ts), UTC_TIMESTAMP(6)) FROM heartbeat.heartbeat")
+	ds[0].setLag(int64(2))
+	db.checkReplicas()
+	assert.True(t, db.read[0].healthy())
+}
+
+func TestReadYourWrites(t *testing.T) {
+	db, master, ds := newReplicaDB(1)
+	c := WithReadYourWrites(context.Background())
+	query(t, c, db, 1)
+	assert.Equal(t, 1, ds[0].readCount())
+	_, err := db.Exec(c, "UPDATE")
+	assert.NoError(t, err)
+	query(t, c, db, 2)
+	assert.Equal(t, 2, master.readCount())
+	assert.Equal(t, 0, ds[0].readCount())
+
+	query(t, WithMaster(context.Background()), db, 1)
+	assert.Equal(t, 1, master.readCount())
+}
+
+func TestReplicaCloseTwice(t *testing.T) {
+	db, _, _ := newReplicaDB(1)
+	db.closed = make(chan struct{})
+	go db.checkproc()
+	assert.NoError(t, db.Close())
+	assert.NotPanics(t, func() { db.Close() })
+}
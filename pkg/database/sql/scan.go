@@ -0,0 +1,282 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	_scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	_timeType    = reflect.TypeOf(time.Time{})
+
+	// _structFields cache of reflect.Type to the fields by column name.
+	_structFields sync.Map
+)
+
+// structFields returns the index of fields by column name of struct t, the
+// column name is the db tag or the snake case of field name, fields tagged
+// with db:"-" are ignored, the fields of embedded structs are promoted
+// unless the embedded struct is tagged.
+func structFields(t reflect.Type) map[string][]int {
+	if fs, ok := _structFields.Load(t); ok {
+		return fs.(map[string][]int)
+	}
+	fs := make(map[string][]int)
+	appendFields(t, nil, fs)
+	_structFields.Store(t, fs)
+	return fs
+}
+
+func appendFields(t reflect.Type, index []int, fs map[string][]int) {
+	var embedded []int
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && tag == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !isScalar(ft) {
+				embedded = append(embedded, i)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		name := tag
+		if idx := strings.IndexByte(name, ','); idx >= 0 {
+			name = name[:idx]
+		}
+		if name == "" {
+			name = snakeCase(f.Name)
+		}
+		// the outer fields shadow the embedded ones.
+		if _, ok := fs[name]; !ok {
+			fs[name] = append(append([]int(nil), index...), i)
+		}
+	}
+	for _, i := range embedded {
+		f := t.Field(i)
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			// the fields of an unexported embedded pointer can't be allocated.
+			if f.PkgPath != "" {
+				continue
+			}
+			ft = ft.Elem()
+		}
+		appendFields(ft, append(append([]int(nil), index...), i), fs)
+	}
+}
+
+// snakeCase converts UserID to user_id.
+func snakeCase(name string) string {
+	rs := []rune(name)
+	var b strings.Builder
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1]) ||
+				(i+1 < len(rs) && unicode.IsLower(rs[i+1]) && unicode.IsUpper(rs[i-1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isScalar reports whether t is scanned as a single column.
+func isScalar(t reflect.Type) bool {
+	return t.Kind() != reflect.Struct || t == _timeType || reflect.PtrTo(t).Implements(_scannerType)
+}
+
+// fieldByIndex returns the field of v by index, the nil embedded pointers are allocated.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// columnFields returns the field index of each column in struct t.
+func columnFields(t reflect.Type, columns []string) ([][]int, error) {
+	fs := structFields(t)
+	idx := make([][]int, len(columns))
+	for i, col := range columns {
+		f, ok := fs[col]
+		if !ok {
+			f, ok = fs[strings.ToLower(col)]
+		}
+		if !ok {
+			return nil, errors.Errorf("sql: missing destination of column %s in %s", col, t)
+		}
+		idx[i] = f
+	}
+	return idx, nil
+}
+
+// ScanStruct copies the columns in the current row into the fields of the
+// struct pointed at by dest, the columns are matched by the db tag of fields.
+func (rs *Rows) ScanStruct(dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("sql: ScanStruct dest must be a non-nil struct pointer, got %T", dest)
+	}
+	v = v.Elem()
+	if rs.scanType != v.Type() {
+		columns, err := rs.Columns()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		idx, err := columnFields(v.Type(), columns)
+		if err != nil {
+			return err
+		}
+		rs.scanType, rs.scanFields = v.Type(), idx
+	}
+	values := make([]interface{}, len(rs.scanFields))
+	for i, idx := range rs.scanFields {
+		values[i] = fieldByIndex(v, idx).Addr().Interface()
+	}
+	return errors.WithStack(rs.Rows.Scan(values...))
+}
+
+// scanAll scans all the rows into dest, which is a pointer to a slice of
+// structs, struct pointers or scalars, and closes rows.
+func scanAll(rows *Rows, dest interface{}) (err error) {
+	defer func() {
+		if cerr := rows.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return errors.Errorf("sql: Select dest must be a non-nil slice pointer, got %T", dest)
+	}
+	slice := v.Elem()
+	et := slice.Type().Elem()
+	base := et
+	if et.Kind() == reflect.Ptr {
+		base = et.Elem()
+	}
+	scalar := isScalar(base)
+	for rows.Next() {
+		ev := reflect.New(base)
+		if scalar {
+			err = errors.WithStack(rows.Scan(ev.Interface()))
+		} else {
+			err = rows.ScanStruct(ev.Interface())
+		}
+		if err != nil {
+			return
+		}
+		if et.Kind() != reflect.Ptr {
+			ev = ev.Elem()
+		}
+		slice = reflect.Append(slice, ev)
+	}
+	if err = rows.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+	v.Elem().Set(slice)
+	return
+}
+
+// scanOne scans the first row into dest, which is a pointer to a struct or
+// scalar, and closes rows, it returns ErrNoRows if there is no row.
+func scanOne(rows *Rows, dest interface{}) (err error) {
+	defer func() {
+		if cerr := rows.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.Errorf("sql: Get dest must be a non-nil pointer, got %T", dest)
+	}
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return errors.WithStack(err)
+		}
+		return ErrNoRows
+	}
+	if isScalar(v.Elem().Type()) {
+		return errors.WithStack(rows.Scan(dest))
+	}
+	return rows.ScanStruct(dest)
+}
+
+// Select executes a query and scans all the rows into dest, which is a
+// pointer to a slice of structs, struct pointers or scalars.
+func (db *DB) Select(c context.Context, dest interface{}, query string, args ...interface{}) error {
+	rows, err := db.Query(c, query, args...)
+	if err != nil {
+		return err
+	}
+	return scanAll(rows, dest)
+}
+
+// Get executes a query and scans the first row into dest, which is a
+// pointer to a struct or scalar, it returns ErrNoRows if there is no row.
+func (db *DB) Get(c context.Context, dest interface{}, query string, args ...interface{}) error {
+	rows, err := db.Query(c, query, args...)
+	if err != nil {
+		return err
+	}
+	return scanOne(rows, dest)
+}
+
+// Select executes a query in the transaction and scans all the rows into dest.
+func (tx *Tx) Select(dest interface{}, query string, args ...interface{}) error {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	return scanAll(rows, dest)
+}
+
+// Get executes a query in the transaction and scans the first row into dest.
+func (tx *Tx) Get(dest interface{}, query string, args ...interface{}) error {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	return scanOne(rows, dest)
+}
+
+// Select executes a prepared query statement and scans all the rows into dest.
+func (s *Stmt) Select(c context.Context, dest interface{}, args ...interface{}) error {
+	rows, err := s.Query(c, args...)
+	if err != nil {
+		return err
+	}
+	return scanAll(rows, dest)
+}
+
+// Get executes a prepared query statement and scans the first row into dest.
+func (s *Stmt) Get(c context.Context, dest interface{}, args ...interface{}) error {
+	rows, err := s.Query(c, args...)
+	if err != nil {
+		return err
+	}
+	return scanOne(rows, dest)
+}
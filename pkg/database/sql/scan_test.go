@@ -0,0 +1,137 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Base struct {
+	ID    int64  `db:"id"`
+	Ctime string `db:"ctime"`
+}
+
+type Profile struct {
+	Sign string
+}
+
+type User struct {
+	Base
+	*Profile
+	UserName string
+	Email    sql.NullString `db:"mail"`
+	Ignored  string         `db:"-"`
+	unexport string
+}
+
+func TestStructFields(t *testing.T) {
+	fs := structFields(reflect.TypeOf(User{}))
+	assert.Equal(t, map[string][]int{
+		"id":        {0, 0},
+		"ctime":     {0, 1},
+		"sign":      {1, 0},
+		"user_name": {2},
+		"mail":      {3},
+	}, fs)
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"ID":        "id",
+		"UserID":    "user_id",
+		"HTTPCode":  "http_code",
+		"CreatedAt": "created_at",
+		"Level2Up":  "level2_up",
+		"name":      "name",
+	} {
+		assert.Equal(t, want, snakeCase(name), name)
+	}
+}
+
+func userDriver() *fakeDriver {
+	return &fakeDriver{handle: func(query string, args []driver.NamedValue) *fakeResult {
+		switch query {
+		case "SELECT users":
+			return &fakeResult{
+				columns: []string{"id", "user_name", "mail", "sign"},
+				rows: [][]driver.Value{
+					{int64(1), "a", "a@b.c", "hi"},
+					{int64(2), "b", nil, "yo"},
+				},
+			}
+		case "SELECT ids":
+			return &fakeResult{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}, {int64(2)}}}
+		case "SELECT unknown":
+			return &fakeResult{columns: []string{"id", "nope"}, rows: [][]driver.Value{{int64(1), "x"}}}
+		}
+		return &fakeResult{columns: []string{"id"}}
+	}}
+}
+
+func TestSelect(t *testing.T) {
+	db := newFakeDB(userDriver(), 1)
+	var users []*User
+	assert.NoError(t, db.Select(context.Background(), &users, "SELECT users"))
+	assert.Len(t, users, 2)
+	assert.Equal(t, int64(1), users[0].ID)
+	assert.Equal(t, "a", users[0].UserName)
+	assert.Equal(t, sql.NullString{String: "a@b.c", Valid: true}, users[0].Email)
+	assert.Equal(t, "hi", users[0].Sign)
+	assert.False(t, users[1].Email.Valid)
+
+	var values []User
+	assert.NoError(t, db.Select(context.Background(), &values, "SELECT users"))
+	assert.Equal(t, "yo", values[1].Sign)
+
+	var ids []int64
+	assert.NoError(t, db.Select(context.Background(), &ids, "SELECT ids"))
+	assert.Equal(t, []int64{1, 2}, ids)
+
+	assert.Error(t, db.Select(context.Background(), &users, "SELECT unknown"))
+	assert.Error(t, db.Select(context.Background(), users, "SELECT users"))
+}
+
+func TestGet(t *testing.T) {
+	db := newFakeDB(userDriver(), 0)
+	u := new(User)
+	assert.NoError(t, db.Get(context.Background(), u, "SELECT users"))
+	assert.Equal(t, int64(1), u.ID)
+	assert.Equal(t, "hi", u.Sign)
+
+	var id int64
+	assert.NoError(t, db.Get(context.Background(), &id, "SELECT ids"))
+	assert.Equal(t, int64(1), id)
+
+	assert.Equal(t, ErrNoRows, db.Get(context.Background(), u, "SELECT none"))
+}
+
+func TestTxSelect(t *testing.T) {
+	db := newFakeDB(userDriver(), 0)
+	tx, err := db.Begin(context.Background())
+	assert.NoError(t, err)
+	var users []User
+	assert.NoError(t, tx.Select(&users, "SELECT users"))
+	assert.Len(t, users, 2)
+	var id int64
+	assert.NoError(t, tx.Get(&id, "SELECT ids"))
+	assert.NoError(t, tx.Commit())
+}
+
+func TestRowsScanStruct(t *testing.T) {
+	db := newFakeDB(userDriver(), 0)
+	rows, err := db.Query(context.Background(), "SELECT users")
+	assert.NoError(t, err)
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var u User
+		assert.NoError(t, rows.ScanStruct(&u))
+		names = append(names, u.UserName)
+	}
+	assert.Equal(t, []string{"a", "b"}, names)
+	assert.Error(t, rows.ScanStruct(User{}))
+}
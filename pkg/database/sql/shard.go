@@ -0,0 +1,254 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-kratos/kratos/pkg/sync/errgroup"
+
+	"github.com/pkg/errors"
+)
+
+// shard rules.
+const (
+	ShardRuleMod    = "mod"
+	ShardRuleRange  = "range"
+	ShardRuleLookup = "lookup"
+)
+
+// _suffixPlaceholder the placeholder of table suffix in the query of SelectAll.
+const _suffixPlaceholder = "{suffix}"
+
+// ErrNoShard is returned by Shard when no shard matches the key.
+var ErrNoShard = errors.New("sql: no shard of key")
+
+// ShardConfig sharded database config.
+//
+// The tables are numbered from 0 to Tables-1 and spread evenly over DB in
+// order, table t is in DB[t*len(DB)/Tables], such as 4 DB and 16 Tables,
+// table 0-3 are in DB[0], 4-7 in DB[1].
+// A rule maps the shard key to a table number:
+//
+//	mod: table = key % Tables.
+//	range: table = the Table of the range Start <= key < End.
+//	lookup: table = Lookup[key % len(Lookup)], Lookup is a table of virtual buckets.
+type ShardConfig struct {
+	// Name prefix of the metrics and breaker name of each database, default shard.
+	Name string
+	// DB the database of each shard.
+	DB []*Config
+	// Rule mod, range or lookup, default mod.
+	Rule string
+	// Tables the number of tables, default len(DB) which means the tables
+	// are not split in database and the suffix is empty.
+	Tables int
+	// TableFormat the format of table suffix by table number, default _%d.
+	TableFormat string
+	// Ranges of range rule.
+	Ranges []*ShardRange
+	// Lookup of lookup rule.
+	Lookup []int
+	// Concurrency of Scatter, default no limit.
+	Concurrency int
+}
+
+// ShardRange the keys in [Start, End) are in table Table.
+type ShardRange struct {
+	Start int64
+	End   int64
+	Table int
+}
+
+// ShardedDB databases sharded by key.
+type ShardedDB struct {
+	c       *ShardConfig
+	dbs     []*DB
+	suffix  []string
+	shardFn func(key int64) (int, bool)
+}
+
+// NewShardedDB opens the databases of shards.
+func NewShardedDB(c *ShardConfig) (*ShardedDB, error) {
+	return newShardedDB(c, Open)
+}
+
+func newShardedDB(c *ShardConfig, open func(*Config) (*DB, error)) (s *ShardedDB, err error) {
+	if len(c.DB) == 0 {
+		return nil, errors.New("sql: sharded db without database")
+	}
+	s = &ShardedDB{c: c}
+	if err = s.init(); err != nil {
+		return nil, err
+	}
+	name := c.Name
+	if name == "" {
+		name = "shard"
+	}
+	for i, dc := range c.DB {
+		if dc.Name == "" {
+			dc.Name = fmt.Sprintf("%s_%d", name, i)
+		}
+		db, err := open(dc)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.dbs = append(s.dbs, db)
+	}
+	return s, nil
+}
+
+func (s *ShardedDB) init() error {
+	c := s.c
+	split := c.Tables > 0
+	if c.Tables <= 0 {
+		c.Tables = len(c.DB)
+	}
+	if c.Tables < len(c.DB) {
+		return errors.Errorf("sql: %d tables less than %d databases", c.Tables, len(c.DB))
+	}
+	s.suffix = make([]string, c.Tables)
+	if split {
+		format := c.TableFormat
+		if format == "" {
+			format = "_%d"
+		}
+		for t := range s.suffix {
+			s.suffix[t] = fmt.Sprintf(format, t)
+		}
+	}
+	switch c.Rule {
+	case "", ShardRuleMod:
+		tables := uint64(c.Tables)
+		s.shardFn = func(key int64) (int, bool) {
+			return int(uint64(key) % tables), true
+		}
+	case ShardRuleRange:
+		if len(c.Ranges) == 0 {
+			return errors.New("sql: range shard without ranges")
+		}
+		for _, r := range c.Ranges {
+			if r.Start >= r.End || r.Table < 0 || r.Table >= c.Tables {
+				return errors.Errorf("sql: invalid shard range %+v", r)
+			}
+		}
+		ranges := c.Ranges
+		s.shardFn = func(key int64) (int, bool) {
+			for _, r := range ranges {
+				if r.Start <= key && key < r.End {
+					return r.Table, true
+				}
+			}
+			return 0, false
+		}
+	case ShardRuleLookup:
+		if len(c.Lookup) == 0 {
+			return errors.New("sql: lookup shard without lookup table")
+		}
+		for _, t := range c.Lookup {
+			if t < 0 || t >= c.Tables {
+				return errors.Errorf("sql: invalid shard lookup table %d", t)
+			}
+		}
+		lookup := c.Lookup
+		buckets := uint64(len(lookup))
+		s.shardFn = func(key int64) (int, bool) {
+			return lookup[uint64(key)%buckets], true
+		}
+	default:
+		return errors.Errorf("sql: unknown shard rule %q", c.Rule)
+	}
+	return nil
+}
+
+// Shard returns the database and table suffix of key, such as
+//
+//	db, suffix, err := s.Shard(mid)
+//	row := db.QueryRow(c, "SELECT name FROM user"+suffix+" WHERE mid=?", mid)
+func (s *ShardedDB) Shard(key int64) (*DB, string, error) {
+	t, ok := s.shardFn(key)
+	if !ok {
+		return nil, "", errors.Wrapf(ErrNoShard, "key %d", key)
+	}
+	return s.dbOf(t), s.suffix[t], nil
+}
+
+// dbOf returns the database of table t.
+func (s *ShardedDB) dbOf(t int) *DB {
+	return s.dbs[t*len(s.dbs)/len(s.suffix)]
+}
+
+// DB returns all the databases.
+func (s *ShardedDB) DB() []*DB {
+	return s.dbs
+}
+
+// Scatter calls fn with the database and table suffix of every table
+// concurrently, the context of fn is canceled at the first error.
+func (s *ShardedDB) Scatter(c context.Context, fn func(c context.Context, db *DB, suffix string) error) error {
+	g := errgroup.WithCancel(c)
+	if s.c.Concurrency > 0 {
+		g.GOMAXPROCS(s.c.Concurrency)
+	}
+	for t := range s.suffix {
+		db, suffix := s.dbOf(t), s.suffix[t]
+		g.Go(func(c context.Context) error {
+			return fn(c, db, suffix)
+		})
+	}
+	return g.Wait()
+}
+
+// SelectAll executes query on every table and gathers the rows into dest
+// like DB.Select, the {suffix} in query is replaced with the table suffix,
+// such as "SELECT * FROM user{suffix} WHERE state=?", the order of rows is
+// undefined.
+func (s *ShardedDB) SelectAll(c context.Context, dest interface{}, query string, args ...interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return errors.Errorf("sql: SelectAll dest must be a non-nil slice pointer, got %T", dest)
+	}
+	var (
+		mu  sync.Mutex
+		all = v.Elem()
+		typ = all.Type()
+	)
+	err := s.Scatter(c, func(c context.Context, db *DB, suffix string) error {
+		part := reflect.New(typ)
+		if err := db.Select(c, part.Interface(), strings.Replace(query, _suffixPlaceholder, suffix, -1), args...); err != nil {
+			return err
+		}
+		mu.Lock()
+		all = reflect.AppendSlice(all, part.Elem())
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	v.Elem().Set(all)
+	return nil
+}
+
+// Ping pings all the databases.
+func (s *ShardedDB) Ping(c context.Context) (err error) {
+	for _, db := range s.dbs {
+		if err = db.Ping(c); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Close closes all the databases.
+func (s *ShardedDB) Close() (err error) {
+	for _, db := range s.dbs {
+		if e := db.Close(); e != nil {
+			err = e
+		}
+	}
+	return
+}
@@ -0,0 +1,121 @@
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFakeShardedDB(t *testing.T, c *ShardConfig) (*ShardedDB, []*fakeDriver) {
+	var ds []*fakeDriver
+	s, err := newShardedDB(c, func(dc *Config) (*DB, error) {
+		d := &fakeDriver{handle: func(query string, args []driver.NamedValue) *fakeResult {
+			if strings.Contains(query, "fail") {
+				return &fakeResult{err: errors.New("fail")}
+			}
+			table := query[strings.Index(query, "user"):]
+			return &fakeResult{columns: []string{"name"}, rows: [][]driver.Value{{dc.Name + "." + table}}}
+		}}
+		ds = append(ds, d)
+		db := newFakeDB(d, 0)
+		db.write.name = dc.Name
+		return db, nil
+	})
+	assert.NoError(t, err)
+	return s, ds
+}
+
+func shardOf(t *testing.T, s *ShardedDB, key int64) (string, string) {
+	db, suffix, err := s.Shard(key)
+	if !assert.NoError(t, err) {
+		return "", ""
+	}
+	return db.write.name, suffix
+}
+
+func TestShardMod(t *testing.T) {
+	s, _ := newFakeShardedDB(t, &ShardConfig{DB: make4DB(), Tables: 16, TableFormat: "_%02d"})
+	db, suffix := shardOf(t, s, 21)
+	assert.Equal(t, "shard_1", db)
+	assert.Equal(t, "_05", suffix)
+	db, suffix = shardOf(t, s, 15)
+	assert.Equal(t, "shard_3", db)
+	assert.Equal(t, "_15", suffix)
+
+	// table t is in DB[t*4/6], none of the databases is unused.
+	s, _ = newFakeShardedDB(t, &ShardConfig{DB: make4DB(), Tables: 6})
+	var dbs []string
+	for key := int64(0); key < 6; key++ {
+		db, _ = shardOf(t, s, key)
+		dbs = append(dbs, db)
+	}
+	assert.Equal(t, []string{"shard_0", "shard_0", "shard_1", "shard_2", "shard_2", "shard_3"}, dbs)
+
+	// the tables are not split.
+	s, _ = newFakeShardedDB(t, &ShardConfig{Name: "user", DB: make4DB()})
+	db, suffix = shardOf(t, s, 6)
+	assert.Equal(t, "user_2", db)
+	assert.Equal(t, "", suffix)
+}
+
+func TestShardRangeLookup(t *testing.T) {
+	s, _ := newFakeShardedDB(t, &ShardConfig{DB: make4DB(), Rule: ShardRuleRange, Ranges: []*ShardRange{
+		{Start: 0, End: 100, Table: 0},
+		{Start: 100, End: 200, Table: 3},
+	}})
+	db, _ := shardOf(t, s, 150)
+	assert.Equal(t, "shard_3", db)
+	_, _, err := s.Shard(200)
+	assert.True(t, errors.Is(err, ErrNoShard))
+
+	s, _ = newFakeShardedDB(t, &ShardConfig{DB: make4DB(), Rule: ShardRuleLookup, Tables: 8, Lookup: []int{7, 0, 3}})
+	db, suffix := shardOf(t, s, 3)
+	assert.Equal(t, "shard_3", db)
+	assert.Equal(t, "_7", suffix)
+	db, suffix = shardOf(t, s, 5)
+	assert.Equal(t, "shard_1", db)
+	assert.Equal(t, "_3", suffix)
+}
+
+func TestShardConfigError(t *testing.T) {
+	for _, c := range []*ShardConfig{
+		{},
+		{DB: make4DB(), Tables: 2},
+		{DB: make4DB(), Rule: "hash"},
+		{DB: make4DB(), Rule: ShardRuleRange},
+		{DB: make4DB(), Rule: ShardRuleRange, Ranges: []*ShardRange{{Start: 1, End: 0}}},
+		{DB: make4DB(), Rule: ShardRuleLookup, Lookup: []int{4}},
+	} {
+		_, err := newShardedDB(c, func(*Config) (*DB, error) { return newFakeDB(&fakeDriver{}, 0), nil })
+		assert.Error(t, err, "%+v", c)
+	}
+}
+
+func TestShardSelectAll(t *testing.T) {
+	s, _ := newFakeShardedDB(t, &ShardConfig{DB: make4DB()[:2], Tables: 4, Concurrency: 2})
+	var names []string
+	assert.NoError(t, s.SelectAll(context.Background(), &names, "SELECT name FROM user{suffix}"))
+	sort.Strings(names)
+	assert.Equal(t, []string{"shard_0.user_0", "shard_0.user_1", "shard_1.user_2", "shard_1.user_3"}, names)
+
+	// % in query is not a format verb.
+	names = nil
+	assert.NoError(t, s.SelectAll(context.Background(), &names, "SELECT name FROM user{suffix} WHERE name LIKE 'a%'"))
+	assert.Len(t, names, 4)
+
+	assert.Error(t, s.SelectAll(context.Background(), &names, "SELECT fail FROM user{suffix}"))
+	assert.NoError(t, s.Close())
+}
+
+func make4DB() []*Config {
+	cs := make([]*Config, 4)
+	for i := range cs {
+		cs[i] = newFakeConfig()
+	}
+	return cs
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/go-kratos/kratos/pkg/database/sqlstat"
 	"github.com/go-kratos/kratos/pkg/ecode"
 	"github.com/go-kratos/kratos/pkg/log"
 	"github.com/go-kratos/kratos/pkg/net/netutil/breaker"
@@ -37,9 +41,13 @@ var (
 // DB database.
 type DB struct {
 	write  *conn
-	read   []*conn
-	idx    int64
+	read   []*replica
 	master *DB
+
+	// pickMu protects the current weights of replicas.
+	pickMu    sync.Mutex
+	closed    chan struct{}
+	closeOnce sync.Once
 }
 
 // conn database connection
@@ -48,6 +56,10 @@ type conn struct {
 	breaker breaker.Breaker
 	conf    *Config
 	addr    string
+	// name the name label of metrics and the key of breaker, Config.Name or addr.
+	name    string
+	dialect *dialect
+	stat    *sqlstat.Recorder
 }
 
 // Tx transaction.
@@ -57,6 +69,8 @@ type Tx struct {
 	t      trace.Trace
 	c      context.Context
 	cancel func()
+	// savepoints the sequence of savepoints of nested Transact.
+	savepoints int
 }
 
 // Row row.
@@ -90,7 +104,7 @@ func (r *Row) Scan(dest ...interface{}) (err error) {
 	}
 	r.db.onBreaker(&err)
 	if err != ErrNoRows {
-		err = errors.Wrapf(err, "query %s args %+v", r.query, r.args)
+		err = errors.Wrapf(r.db.mapErr(err), "query %s args %+v", r.query, r.args)
 	}
 	return
 }
@@ -99,6 +113,10 @@ func (r *Row) Scan(dest ...interface{}) (err error) {
 type Rows struct {
 	*sql.Rows
 	cancel func()
+
+	// the struct type and fields of the columns of last ScanStruct.
+	scanType   reflect.Type
+	scanFields [][]int
 }
 
 // Close closes the Rows, preventing further enumeration. If Next is called
@@ -126,34 +144,56 @@ type Stmt struct {
 // driver-specific data source name, usually consisting of at least a database
 // name and connection information.
 func Open(c *Config) (*DB, error) {
+	dl, err := dialectOf(c.Driver)
+	if err != nil {
+		return nil, err
+	}
 	db := new(DB)
-	d, err := connect(c, c.DSN)
+	d, err := connect(c, dl.driver, c.DSN)
 	if err != nil {
 		return nil, err
 	}
-	addr := parseDSNAddr(c.DSN)
+	addr := dl.parseAddr(c.DSN)
 	brkGroup := breaker.NewGroup(c.Breaker)
-	brk := brkGroup.Get(addr)
-	w := &conn{DB: d, breaker: brk, conf: c, addr: addr}
-	rs := make([]*conn, 0, len(c.ReadDSN))
-	for _, rd := range c.ReadDSN {
-		d, err := connect(c, rd)
+	name := connName(c.Name, addr)
+	w := &conn{DB: d, breaker: brkGroup.Get(name), conf: c, addr: addr, name: name, dialect: dl,
+		stat: newRecorder(d, c, name, addr, dl.traceTags(c.DSN, addr), dl)}
+	rs := make([]*replica, 0, len(c.ReadDSN))
+	for i, rd := range c.ReadDSN {
+		d, err := connect(c, dl.driver, rd)
 		if err != nil {
 			return nil, err
 		}
-		addr = parseDSNAddr(rd)
-		brk := brkGroup.Get(addr)
-		r := &conn{DB: d, breaker: brk, conf: c, addr: addr}
-		rs = append(rs, r)
+		addr = dl.parseAddr(rd)
+		name := connName(c.Name, addr)
+		r := &conn{DB: d, breaker: brkGroup.Get(name), conf: c, addr: addr, name: name, dialect: dl,
+			stat: newRecorder(d, c, name, addr, dl.traceTags(rd, addr), dl)}
+		weight := 1
+		if i < len(c.ReadWeight) && c.ReadWeight[i] > 0 {
+			weight = c.ReadWeight[i]
+		}
+		rs = append(rs, newReplica(r, weight))
 	}
 	db.write = w
 	db.read = rs
 	db.master = &DB{write: db.write}
+	if len(rs) > 0 {
+		db.closed = make(chan struct{})
+		go db.checkproc()
+	}
 	return db, nil
 }
 
-func connect(c *Config, dataSourceName string) (*sql.DB, error) {
-	d, err := sql.Open("mysql", dataSourceName)
+// connName returns the name of connection to addr, it's name@addr if name is set.
+func connName(name, addr string) string {
+	if name == "" {
+		return addr
+	}
+	return name + "@" + addr
+}
+
+func connect(c *Config, driver, dataSourceName string) (*sql.DB, error) {
+	d, err := sql.Open(driver, dataSourceName)
 	if err != nil {
 		err = errors.WithStack(err)
 		return nil, err
@@ -166,12 +206,14 @@ func connect(c *Config, dataSourceName string) (*sql.DB, error) {
 
 // Begin starts a transaction. The isolation level is dependent on the driver.
 func (db *DB) Begin(c context.Context) (tx *Tx, err error) {
+	markWritten(c)
 	return db.write.begin(c)
 }
 
 // Exec executes a query without returning any rows.
 // The args are for any placeholder parameters in the query.
 func (db *DB) Exec(c context.Context, query string, args ...interface{}) (res sql.Result, err error) {
+	markWritten(c)
 	return db.write.exec(c, query, args...)
 }
 
@@ -194,9 +236,8 @@ func (db *DB) Prepared(query string) (stmt *Stmt) {
 // Query executes a query that returns rows, typically a SELECT. The args are
 // for any placeholder parameters in the query.
 func (db *DB) Query(c context.Context, query string, args ...interface{}) (rows *Rows, err error) {
-	idx := db.readIndex()
-	for i := range db.read {
-		if rows, err = db.read[(idx+i)%len(db.read)].query(c, query, args...); !ecode.EqualError(ecode.ServiceUnavailable, err) {
+	for _, r := range db.replicas(c) {
+		if rows, err = r.query(c, query, args...); !ecode.EqualError(ecode.ServiceUnavailable, err) {
 			return
 		}
 	}
@@ -207,25 +248,19 @@ func (db *DB) Query(c context.Context, query string, args ...interface{}) (rows
 // QueryRow always returns a non-nil value. Errors are deferred until Row's
 // Scan method is called.
 func (db *DB) QueryRow(c context.Context, query string, args ...interface{}) *Row {
-	idx := db.readIndex()
-	for i := range db.read {
-		if row := db.read[(idx+i)%len(db.read)].queryRow(c, query, args...); !ecode.EqualError(ecode.ServiceUnavailable, row.err) {
+	for _, r := range db.replicas(c) {
+		if row := r.queryRow(c, query, args...); !ecode.EqualError(ecode.ServiceUnavailable, row.err) {
 			return row
 		}
 	}
 	return db.write.queryRow(c, query, args...)
 }
 
-func (db *DB) readIndex() int {
-	if len(db.read) == 0 {
-		return 0
-	}
-	v := atomic.AddInt64(&db.idx, 1)
-	return int(v) % len(db.read)
-}
-
 // Close closes the write and read database, releasing any open resources.
 func (db *DB) Close() (err error) {
+	if db.closed != nil {
+		db.closeOnce.Do(func() { close(db.closed) })
+	}
 	if e := db.write.Close(); e != nil {
 		err = errors.WithStack(e)
 	}
@@ -271,10 +306,8 @@ func (db *conn) onBreaker(err *error) {
 func (db *conn) begin(c context.Context) (tx *Tx, err error) {
 	now := time.Now()
 	defer slowLog("Begin", now)
-	t, ok := trace.FromContext(c)
+	t, ok := db.stat.Fork(c, "begin", "")
 	if ok {
-		t = t.Fork(_family, "begin")
-		t.SetTag(trace.String(trace.TagAddress, db.addr), trace.String(trace.TagComment, ""))
 		defer func() {
 			if err != nil {
 				t.Finish(&err)
@@ -282,40 +315,45 @@ func (db *conn) begin(c context.Context) (tx *Tx, err error) {
 		}()
 	}
 	if err = db.breaker.Allow(); err != nil {
-		_metricReqErr.Inc(db.addr, db.addr, "begin", "breaker")
+		_metricReqErr.Inc(db.name, db.addr, "begin", "breaker")
 		return
 	}
 	_, c, cancel := db.conf.TranTimeout.Shrink(c)
 	rtx, err := db.BeginTx(c, nil)
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), db.addr, db.addr, "begin")
+	_metricReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), db.name, db.addr, "begin")
 	if err != nil {
 		err = errors.WithStack(err)
 		cancel()
 		return
 	}
-	tx = &Tx{tx: rtx, t: t, db: db, c: c, cancel: cancel}
+	tx = &Tx{tx: rtx, t: t, db: db, cancel: cancel}
+	if t != nil {
+		c = trace.NewContext(c, t)
+	}
+	tx.c = context.WithValue(c, txKey{}, tx)
 	return
 }
 
 func (db *conn) exec(c context.Context, query string, args ...interface{}) (res sql.Result, err error) {
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("Exec query(%s) args(%+v)", query, args), now)
-	if t, ok := trace.FromContext(c); ok {
-		t = t.Fork(_family, "exec")
-		t.SetTag(trace.String(trace.TagAddress, db.addr), trace.String(trace.TagComment, query))
-		defer t.Finish(&err)
+	defer db.stat.Record(c, query, args, now, &err)
+	if t, ok := db.stat.Fork(c, "exec", query); ok {
+		defer func() {
+			sqlstat.TraceRowsAffected(t, res)
+			t.Finish(&err)
+		}()
 	}
 	if err = db.breaker.Allow(); err != nil {
-		_metricReqErr.Inc(db.addr, db.addr, "exec", "breaker")
+		_metricReqErr.Inc(db.name, db.addr, "exec", "breaker")
 		return
 	}
 	_, c, cancel := db.conf.ExecTimeout.Shrink(c)
-	res, err = db.ExecContext(c, query, args...)
+	res, err = db.ExecContext(c, db.rebind(query), args...)
 	cancel()
 	db.onBreaker(&err)
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), db.addr, db.addr, "exec")
+	_metricReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), db.name, db.addr, "exec")
 	if err != nil {
-		err = errors.Wrapf(err, "exec:%s, args:%+v", query, args)
+		err = errors.Wrapf(db.mapErr(err), "exec:%s, args:%+v", query, args)
 	}
 	return
 }
@@ -323,20 +361,18 @@ func (db *conn) exec(c context.Context, query string, args ...interface{}) (res
 func (db *conn) ping(c context.Context) (err error) {
 	now := time.Now()
 	defer slowLog("Ping", now)
-	if t, ok := trace.FromContext(c); ok {
-		t = t.Fork(_family, "ping")
-		t.SetTag(trace.String(trace.TagAddress, db.addr), trace.String(trace.TagComment, ""))
+	if t, ok := db.stat.Fork(c, "ping", ""); ok {
 		defer t.Finish(&err)
 	}
 	if err = db.breaker.Allow(); err != nil {
-		_metricReqErr.Inc(db.addr, db.addr, "ping", "breaker")
+		_metricReqErr.Inc(db.name, db.addr, "ping", "breaker")
 		return
 	}
 	_, c, cancel := db.conf.ExecTimeout.Shrink(c)
 	err = db.PingContext(c)
 	cancel()
 	db.onBreaker(&err)
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), db.addr, db.addr, "ping")
+	_metricReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), db.name, db.addr, "ping")
 	if err != nil {
 		err = errors.WithStack(err)
 	}
@@ -345,9 +381,9 @@ func (db *conn) ping(c context.Context) (err error) {
 
 func (db *conn) prepare(query string) (*Stmt, error) {
 	defer slowLog(fmt.Sprintf("Prepare query(%s)", query), time.Now())
-	stmt, err := db.Prepare(query)
+	stmt, err := db.Prepare(db.rebind(query))
 	if err != nil {
-		err = errors.Wrapf(err, "prepare %s", query)
+		err = errors.Wrapf(db.mapErr(err), "prepare %s", query)
 		return nil, err
 	}
 	st := &Stmt{query: query, db: db}
@@ -358,14 +394,14 @@ func (db *conn) prepare(query string) (*Stmt, error) {
 func (db *conn) prepared(query string) (stmt *Stmt) {
 	defer slowLog(fmt.Sprintf("Prepared query(%s)", query), time.Now())
 	stmt = &Stmt{query: query, db: db}
-	s, err := db.Prepare(query)
+	s, err := db.Prepare(db.rebind(query))
 	if err == nil {
 		stmt.stmt.Store(s)
 		return
 	}
 	go func() {
 		for {
-			s, err := db.Prepare(query)
+			s, err := db.Prepare(db.rebind(query))
 			if err != nil {
 				time.Sleep(time.Second)
 				continue
@@ -379,22 +415,20 @@ func (db *conn) prepared(query string) (stmt *Stmt) {
 
 func (db *conn) query(c context.Context, query string, args ...interface{}) (rows *Rows, err error) {
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("Query query(%s) args(%+v)", query, args), now)
-	if t, ok := trace.FromContext(c); ok {
-		t = t.Fork(_family, "query")
-		t.SetTag(trace.String(trace.TagAddress, db.addr), trace.String(trace.TagComment, query))
+	defer db.stat.Record(c, query, args, now, &err)
+	if t, ok := db.stat.Fork(c, "query", query); ok {
 		defer t.Finish(&err)
 	}
 	if err = db.breaker.Allow(); err != nil {
-		_metricReqErr.Inc(db.addr, db.addr, "query", "breaker")
+		_metricReqErr.Inc(db.name, db.addr, "query", "breaker")
 		return
 	}
 	_, c, cancel := db.conf.QueryTimeout.Shrink(c)
-	rs, err := db.DB.QueryContext(c, query, args...)
+	rs, err := db.DB.QueryContext(c, db.rebind(query), args...)
 	db.onBreaker(&err)
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), db.addr, db.addr, "query")
+	_metricReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), db.name, db.addr, "query")
 	if err != nil {
-		err = errors.Wrapf(err, "query:%s, args:%+v", query, args)
+		err = errors.Wrapf(db.mapErr(err), "query:%s, args:%+v", query, args)
 		cancel()
 		return
 	}
@@ -404,19 +438,15 @@ func (db *conn) query(c context.Context, query string, args ...interface{}) (row
 
 func (db *conn) queryRow(c context.Context, query string, args ...interface{}) *Row {
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("QueryRow query(%s) args(%+v)", query, args), now)
-	t, ok := trace.FromContext(c)
-	if ok {
-		t = t.Fork(_family, "queryrow")
-		t.SetTag(trace.String(trace.TagAddress, db.addr), trace.String(trace.TagComment, query))
-	}
+	defer db.stat.Record(c, query, args, now, nil)
+	t, _ := db.stat.Fork(c, "queryrow", query)
 	if err := db.breaker.Allow(); err != nil {
-		_metricReqErr.Inc(db.addr, db.addr, "queryRow", "breaker")
+		_metricReqErr.Inc(db.name, db.addr, "queryRow", "breaker")
 		return &Row{db: db, t: t, err: err}
 	}
 	_, c, cancel := db.conf.QueryTimeout.Shrink(c)
-	r := db.DB.QueryRowContext(c, query, args...)
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), db.addr, db.addr, "queryrow")
+	r := db.DB.QueryRowContext(c, db.rebind(query), args...)
+	_metricReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), db.name, db.addr, "queryrow")
 	return &Row{db: db, Row: r, query: query, args: args, t: t, cancel: cancel}
 }
 
@@ -441,18 +471,22 @@ func (s *Stmt) Exec(c context.Context, args ...interface{}) (res sql.Result, err
 		return
 	}
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("Exec query(%s) args(%+v)", s.query, args), now)
+	defer s.db.stat.Record(c, s.query, args, now, &err)
+	if !s.tx {
+		markWritten(c)
+	}
 	if s.tx {
 		if s.t != nil {
-			s.t.SetTag(trace.String(trace.TagAnnotation, s.query))
+			sqlstat.TraceTxLog(s.t, "stmt:exec", s.query)
 		}
-	} else if t, ok := trace.FromContext(c); ok {
-		t = t.Fork(_family, "exec")
-		t.SetTag(trace.String(trace.TagAddress, s.db.addr), trace.String(trace.TagComment, s.query))
-		defer t.Finish(&err)
+	} else if t, ok := s.db.stat.Fork(c, "exec", s.query); ok {
+		defer func() {
+			sqlstat.TraceRowsAffected(t, res)
+			t.Finish(&err)
+		}()
 	}
 	if err = s.db.breaker.Allow(); err != nil {
-		_metricReqErr.Inc(s.db.addr, s.db.addr, "stmt:exec", "breaker")
+		_metricReqErr.Inc(s.db.name, s.db.addr, "stmt:exec", "breaker")
 		return
 	}
 	stmt, ok := s.stmt.Load().(*sql.Stmt)
@@ -464,9 +498,9 @@ func (s *Stmt) Exec(c context.Context, args ...interface{}) (res sql.Result, err
 	res, err = stmt.ExecContext(c, args...)
 	cancel()
 	s.db.onBreaker(&err)
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), s.db.addr, s.db.addr, "stmt:exec")
+	_metricReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), s.db.name, s.db.addr, "stmt:exec")
 	if err != nil {
-		err = errors.Wrapf(err, "exec:%s, args:%+v", s.query, args)
+		err = errors.Wrapf(s.db.mapErr(err), "exec:%s, args:%+v", s.query, args)
 	}
 	return
 }
@@ -479,18 +513,16 @@ func (s *Stmt) Query(c context.Context, args ...interface{}) (rows *Rows, err er
 		return
 	}
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("Query query(%s) args(%+v)", s.query, args), now)
+	defer s.db.stat.Record(c, s.query, args, now, &err)
 	if s.tx {
 		if s.t != nil {
-			s.t.SetTag(trace.String(trace.TagAnnotation, s.query))
+			sqlstat.TraceTxLog(s.t, "stmt:query", s.query)
 		}
-	} else if t, ok := trace.FromContext(c); ok {
-		t = t.Fork(_family, "query")
-		t.SetTag(trace.String(trace.TagAddress, s.db.addr), trace.String(trace.TagComment, s.query))
+	} else if t, ok := s.db.stat.Fork(c, "query", s.query); ok {
 		defer t.Finish(&err)
 	}
 	if err = s.db.breaker.Allow(); err != nil {
-		_metricReqErr.Inc(s.db.addr, s.db.addr, "stmt:query", "breaker")
+		_metricReqErr.Inc(s.db.name, s.db.addr, "stmt:query", "breaker")
 		return
 	}
 	stmt, ok := s.stmt.Load().(*sql.Stmt)
@@ -501,9 +533,9 @@ func (s *Stmt) Query(c context.Context, args ...interface{}) (rows *Rows, err er
 	_, c, cancel := s.db.conf.QueryTimeout.Shrink(c)
 	rs, err := stmt.QueryContext(c, args...)
 	s.db.onBreaker(&err)
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), s.db.addr, s.db.addr, "stmt:query")
+	_metricReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), s.db.name, s.db.addr, "stmt:query")
 	if err != nil {
-		err = errors.Wrapf(err, "query:%s, args:%+v", s.query, args)
+		err = errors.Wrapf(s.db.mapErr(err), "query:%s, args:%+v", s.query, args)
 		cancel()
 		return
 	}
@@ -518,7 +550,7 @@ func (s *Stmt) Query(c context.Context, args ...interface{}) (rows *Rows, err er
 // Otherwise, the *Row's Scan scans the first selected row and discards the rest.
 func (s *Stmt) QueryRow(c context.Context, args ...interface{}) (row *Row) {
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("QueryRow query(%s) args(%+v)", s.query, args), now)
+	defer s.db.stat.Record(c, s.query, args, now, nil)
 	row = &Row{db: s.db, query: s.query, args: args}
 	if s == nil {
 		row.err = ErrStmtNil
@@ -526,15 +558,13 @@ func (s *Stmt) QueryRow(c context.Context, args ...interface{}) (row *Row) {
 	}
 	if s.tx {
 		if s.t != nil {
-			s.t.SetTag(trace.String(trace.TagAnnotation, s.query))
+			sqlstat.TraceTxLog(s.t, "stmt:queryrow", s.query)
 		}
-	} else if t, ok := trace.FromContext(c); ok {
-		t = t.Fork(_family, "queryrow")
-		t.SetTag(trace.String(trace.TagAddress, s.db.addr), trace.String(trace.TagComment, s.query))
+	} else if t, ok := s.db.stat.Fork(c, "queryrow", s.query); ok {
 		row.t = t
 	}
 	if row.err = s.db.breaker.Allow(); row.err != nil {
-		_metricReqErr.Inc(s.db.addr, s.db.addr, "stmt:queryrow", "breaker")
+		_metricReqErr.Inc(s.db.name, s.db.addr, "stmt:queryrow", "breaker")
 		return
 	}
 	stmt, ok := s.stmt.Load().(*sql.Stmt)
@@ -544,7 +574,7 @@ func (s *Stmt) QueryRow(c context.Context, args ...interface{}) (row *Row) {
 	_, c, cancel := s.db.conf.QueryTimeout.Shrink(c)
 	row.Row = stmt.QueryRowContext(c, args...)
 	row.cancel = cancel
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), s.db.addr, s.db.addr, "stmt:queryrow")
+	_metricReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), s.db.name, s.db.addr, "stmt:queryrow")
 	return
 }
 
@@ -580,14 +610,19 @@ func (tx *Tx) Rollback() (err error) {
 // UPDATE.
 func (tx *Tx) Exec(query string, args ...interface{}) (res sql.Result, err error) {
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("Exec query(%s) args(%+v)", query, args), now)
+	defer tx.db.stat.Record(tx.c, query, args, now, &err)
 	if tx.t != nil {
-		tx.t.SetTag(trace.String(trace.TagAnnotation, fmt.Sprintf("exec %s", query)))
+		sqlstat.TraceTxLog(tx.t, "exec", query)
+	}
+	res, err = tx.tx.ExecContext(tx.c, tx.db.rebind(query), args...)
+	if tx.t != nil && err == nil {
+		if n, rerr := res.RowsAffected(); rerr == nil {
+			tx.t.SetLog(trace.Log(trace.TagDBRowsAffected, strconv.FormatInt(n, 10)))
+		}
 	}
-	res, err = tx.tx.ExecContext(tx.c, query, args...)
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), tx.db.addr, tx.db.addr, "tx:exec")
+	_metricReqDur.ObserveContext(tx.c, int64(time.Since(now)/time.Millisecond), tx.db.name, tx.db.addr, "tx:exec")
 	if err != nil {
-		err = errors.Wrapf(err, "exec:%s, args:%+v", query, args)
+		err = errors.Wrapf(tx.db.mapErr(err), "exec:%s, args:%+v", query, args)
 	}
 	return
 }
@@ -595,18 +630,18 @@ func (tx *Tx) Exec(query string, args ...interface{}) (res sql.Result, err error
 // Query executes a query that returns rows, typically a SELECT.
 func (tx *Tx) Query(query string, args ...interface{}) (rows *Rows, err error) {
 	if tx.t != nil {
-		tx.t.SetTag(trace.String(trace.TagAnnotation, fmt.Sprintf("query %s", query)))
+		sqlstat.TraceTxLog(tx.t, "query", query)
 	}
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("Query query(%s) args(%+v)", query, args), now)
+	defer tx.db.stat.Record(tx.c, query, args, now, &err)
 	defer func() {
-		_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), tx.db.addr, tx.db.addr, "tx:query")
+		_metricReqDur.ObserveContext(tx.c, int64(time.Since(now)/time.Millisecond), tx.db.name, tx.db.addr, "tx:query")
 	}()
-	rs, err := tx.tx.QueryContext(tx.c, query, args...)
+	rs, err := tx.tx.QueryContext(tx.c, tx.db.rebind(query), args...)
 	if err == nil {
 		rows = &Rows{Rows: rs}
 	} else {
-		err = errors.Wrapf(err, "query:%s, args:%+v", query, args)
+		err = errors.Wrapf(tx.db.mapErr(err), "query:%s, args:%+v", query, args)
 	}
 	return
 }
@@ -616,14 +651,14 @@ func (tx *Tx) Query(query string, args ...interface{}) (rows *Rows, err error) {
 // Scan method is called.
 func (tx *Tx) QueryRow(query string, args ...interface{}) *Row {
 	if tx.t != nil {
-		tx.t.SetTag(trace.String(trace.TagAnnotation, fmt.Sprintf("queryrow %s", query)))
+		sqlstat.TraceTxLog(tx.t, "queryrow", query)
 	}
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("QueryRow query(%s) args(%+v)", query, args), now)
+	defer tx.db.stat.Record(tx.c, query, args, now, nil)
 	defer func() {
-		_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), tx.db.addr, tx.db.addr, "tx:queryrow")
+		_metricReqDur.ObserveContext(tx.c, int64(time.Since(now)/time.Millisecond), tx.db.name, tx.db.addr, "tx:queryrow")
 	}()
-	r := tx.tx.QueryRowContext(tx.c, query, args...)
+	r := tx.tx.QueryRowContext(tx.c, tx.db.rebind(query), args...)
 	return &Row{Row: r, db: tx.db, query: query, args: args}
 }
 
@@ -645,12 +680,12 @@ func (tx *Tx) Stmt(stmt *Stmt) *Stmt {
 // To use an existing prepared statement on this transaction, see Tx.Stmt.
 func (tx *Tx) Prepare(query string) (*Stmt, error) {
 	if tx.t != nil {
-		tx.t.SetTag(trace.String(trace.TagAnnotation, fmt.Sprintf("prepare %s", query)))
+		sqlstat.TraceTxLog(tx.t, "prepare", query)
 	}
 	defer slowLog(fmt.Sprintf("Prepare query(%s)", query), time.Now())
-	stmt, err := tx.tx.Prepare(query)
+	stmt, err := tx.tx.Prepare(tx.db.rebind(query))
 	if err != nil {
-		err = errors.Wrapf(err, "prepare %s", query)
+		err = errors.Wrapf(tx.db.mapErr(err), "prepare %s", query)
 		return nil, err
 	}
 	st := &Stmt{query: query, tx: true, t: tx.t, db: tx.db}
@@ -3,6 +3,8 @@ package sql
 import (
 	"testing"
 
+	"github.com/go-kratos/kratos/pkg/net/trace"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,3 +18,11 @@ func TestParseAddrDSN(t *testing.T) {
 		assert.Equal(t, "1.2.3.4:3306", addr)
 	})
 }
+
+func TestTraceTags(t *testing.T) {
+	tags := traceTags("user:pass@tcp(1.2.3.4:3306)/account?timeout=1s", "1.2.3.4:3306")
+	assert.Contains(t, tags, trace.TagString(trace.TagPeerAddress, "1.2.3.4:3306"))
+	assert.Contains(t, tags, trace.TagString(trace.TagDBInstance, "account"))
+	assert.Contains(t, tags, trace.TagString(trace.TagDBUser, "user"))
+	assert.Contains(t, tags, trace.TagString(trace.TagDBType, "sql"))
+}
@@ -0,0 +1,26 @@
+package sql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/database/sqlstat"
+	"github.com/go-kratos/kratos/pkg/net/trace"
+)
+
+// newRecorder returns the recorder of the statements on connection d.
+func newRecorder(d *sql.DB, c *Config, name, addr string, tags []trace.Tag, dl *dialect) *sqlstat.Recorder {
+	return &sqlstat.Recorder{
+		Family:         _family,
+		DB:             name,
+		Addr:           addr,
+		SlowThreshold:  time.Duration(c.SlowThreshold),
+		ExplainRate:    c.ExplainRate,
+		ExplainTimeout: time.Duration(c.QueryTimeout),
+		Queryer:        d,
+		Rebind:         dl.rebind,
+		QueryDur:       _metricQueryDur,
+		QuerySlow:      _metricQuerySlow,
+		Tags:           tags,
+	}
+}
@@ -0,0 +1,54 @@
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/database/sqlstat"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordSlowExplain(t *testing.T) {
+	sqlstat.Reset()
+	defer sqlstat.Reset()
+	d := &fakeDriver{handle: func(query string, args []driver.NamedValue) *fakeResult {
+		if strings.HasPrefix(query, "EXPLAIN ") {
+			return &fakeResult{columns: []string{"table", "type", "key"}, rows: [][]driver.Value{{"user", "ALL", nil}}}
+		}
+		return &fakeResult{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}}}
+	}}
+	db := newFakeDB(d, 0)
+	db.write.stat.SlowThreshold = time.Nanosecond
+	db.write.stat.ExplainRate = 1
+
+	var id int64
+	assert.NoError(t, db.QueryRow(context.Background(), "SELECT id FROM user WHERE name=?", "a").Scan(&id))
+	_, err := db.Exec(context.Background(), "UPDATE user SET name='b' WHERE id=1")
+	assert.NoError(t, err)
+
+	var ss []*sqlstat.Stat
+	for i := 0; i < 100; i++ {
+		if ss = sqlstat.Top(0, sqlstat.ByCount); len(ss) == 2 && ss[0].Explain+ss[1].Explain != "" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	fps := make(map[string]*sqlstat.Stat)
+	for _, s := range ss {
+		assert.Equal(t, "master", s.DB)
+		assert.Equal(t, int64(1), s.Slow)
+		fps[s.Fingerprint] = s
+	}
+	if s := fps["select id from user where name=?"]; assert.NotNil(t, s) {
+		assert.Equal(t, "table\ttype\tkey\nuser\tALL\tNULL", s.Explain)
+	}
+	if s := fps["update user set name=? where id=?"]; assert.NotNil(t, s) {
+		assert.Empty(t, s.Explain)
+		assert.Equal(t, "UPDATE user SET name=? WHERE id=?", s.SlowQuery)
+	}
+	assert.Contains(t, d.history(), "EXPLAIN SELECT id FROM user WHERE name=?")
+}
@@ -0,0 +1,16 @@
+package sql
+
+import (
+	"github.com/go-kratos/kratos/pkg/database/sqlstat"
+	"github.com/go-kratos/kratos/pkg/net/trace"
+)
+
+const (
+	_traceComponent   = "database/sql"
+	_tracePeerService = "mysql"
+)
+
+// traceTags returns the tags of the spans on connection of dsn.
+func traceTags(dsn, addr string) []trace.Tag {
+	return sqlstat.TraceTags(_traceComponent, _tracePeerService, dsn, addr)
+}
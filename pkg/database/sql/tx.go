@@ -0,0 +1,128 @@
+package sql
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/log"
+	"github.com/go-kratos/kratos/pkg/net/netutil"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+const (
+	_defaultTranRetry   = 3
+	_defaultTranBackoff = 20 * time.Millisecond
+
+	// mysql error numbers of deadlock and lock wait timeout.
+	_errDeadlock        = 1213
+	_errLockWaitTimeout = 1205
+)
+
+type txKey struct{}
+
+// TxFromContext returns the transaction started by Transact in context.
+func TxFromContext(c context.Context) (tx *Tx, ok bool) {
+	tx, ok = c.Value(txKey{}).(*Tx)
+	return
+}
+
+// Context returns the context of the transaction, it carries the span of
+// the transaction and the transaction itself, and is done when the
+// transaction times out.
+func (tx *Tx) Context() context.Context {
+	return tx.c
+}
+
+// Transact runs fn in a transaction, the transaction is committed if fn
+// returns nil, or rolled back if fn returns an error or panics.
+//
+// If c carries a transaction of db, which means Transact is called in the
+// fn of another Transact, fn runs in a savepoint of that transaction and
+// only the savepoint is rolled back on error.
+//
+// The whole transaction is retried with backoff on deadlock or lock wait
+// timeout, up to Config.TranRetry times, so fn must be safe to rerun.
+func (db *DB) Transact(c context.Context, fn func(c context.Context, tx *Tx) error) (err error) {
+	if tx, ok := TxFromContext(c); ok && tx.db == db.write {
+		return tx.savepoint(c, fn)
+	}
+	retry := db.write.conf.TranRetry
+	if retry == 0 {
+		retry = _defaultTranRetry
+	}
+	bc := netutil.DefaultBackoffConfig
+	bc.BaseDelay = time.Duration(db.write.conf.TranBackoff)
+	if bc.BaseDelay <= 0 {
+		bc.BaseDelay = _defaultTranBackoff
+	}
+	bc.MaxDelay = bc.BaseDelay * 50
+	for i := 0; ; i++ {
+		if err = db.transact(c, fn); err == nil || i >= retry || !IsRetryable(err) {
+			return
+		}
+		_metricReqErr.Inc(db.write.name, db.write.addr, "transact", "retry")
+		log.Warn("%s transact retry(%d) error(%v)", _family, i+1, err)
+		select {
+		case <-time.After(bc.Backoff(i)):
+		case <-c.Done():
+			return
+		}
+	}
+}
+
+func (db *DB) transact(c context.Context, fn func(c context.Context, tx *Tx) error) (err error) {
+	tx, err := db.Begin(c)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+	return fn(tx.c, tx)
+}
+
+// savepoint runs fn in a savepoint of tx.
+func (tx *Tx) savepoint(c context.Context, fn func(c context.Context, tx *Tx) error) (err error) {
+	tx.savepoints++
+	name := "kratos_sp_" + strconv.Itoa(tx.savepoints)
+	if _, err = tx.Exec("SAVEPOINT " + name); err != nil {
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Exec("ROLLBACK TO SAVEPOINT " + name)
+			panic(p)
+		}
+		if err != nil {
+			// the whole transaction is rolled back on deadlock.
+			if !IsRetryable(err) {
+				tx.Exec("ROLLBACK TO SAVEPOINT " + name)
+			}
+			return
+		}
+		_, err = tx.Exec("RELEASE SAVEPOINT " + name)
+	}()
+	return fn(c, tx)
+}
+
+// IsRetryable reports whether err is a deadlock or lock wait timeout of
+// mysql, or a serialization failure or deadlock of postgresql. Transact
+// retries them, the callers can retry the error returned after the retries.
+func IsRetryable(err error) bool {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		return me.Number == _errDeadlock || me.Number == _errLockWaitTimeout
+	}
+	return isPostgresRetryable(err)
+}
@@ -0,0 +1,104 @@
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransact(t *testing.T) {
+	d := &fakeDriver{}
+	db := newFakeDB(d, 0)
+	err := db.Transact(context.Background(), func(c context.Context, tx *Tx) error {
+		ctx, ok := TxFromContext(c)
+		assert.True(t, ok)
+		assert.Equal(t, tx, ctx)
+		_, err := tx.Exec("UPDATE a")
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"BEGIN", "UPDATE a", "COMMIT"}, d.history())
+
+	d = &fakeDriver{}
+	db = newFakeDB(d, 0)
+	err = db.Transact(context.Background(), func(c context.Context, tx *Tx) error {
+		return errors.New("fail")
+	})
+	assert.EqualError(t, err, "fail")
+	assert.Equal(t, []string{"BEGIN", "ROLLBACK"}, d.history())
+}
+
+func TestTransactPanic(t *testing.T) {
+	d := &fakeDriver{}
+	db := newFakeDB(d, 0)
+	assert.PanicsWithValue(t, "boom", func() {
+		db.Transact(context.Background(), func(c context.Context, tx *Tx) error {
+			panic("boom")
+		})
+	})
+	assert.Equal(t, []string{"BEGIN", "ROLLBACK"}, d.history())
+}
+
+func TestTransactNested(t *testing.T) {
+	d := &fakeDriver{}
+	db := newFakeDB(d, 0)
+	err := db.Transact(context.Background(), func(c context.Context, tx *Tx) error {
+		err := db.Transact(c, func(c context.Context, tx *Tx) error {
+			tx.Exec("UPDATE a")
+			return errors.New("fail")
+		})
+		assert.Error(t, err)
+		return db.Transact(c, func(c context.Context, tx *Tx) error {
+			_, err := tx.Exec("UPDATE b")
+			return err
+		})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"BEGIN",
+		"SAVEPOINT kratos_sp_1", "UPDATE a", "ROLLBACK TO SAVEPOINT kratos_sp_1",
+		"SAVEPOINT kratos_sp_2", "UPDATE b", "RELEASE SAVEPOINT kratos_sp_2",
+		"COMMIT",
+	}, d.history())
+}
+
+func TestTransactRetry(t *testing.T) {
+	var deadlocks int
+	d := &fakeDriver{handle: func(query string, args []driver.NamedValue) *fakeResult {
+		if query == "UPDATE a" && deadlocks < 2 {
+			deadlocks++
+			return &fakeResult{err: &mysql.MySQLError{Number: _errDeadlock, Message: "Deadlock found"}}
+		}
+		return nil
+	}}
+	db := newFakeDB(d, 0)
+	db.write.conf.TranBackoff = xtime.Duration(time.Millisecond)
+	var runs int
+	err := db.Transact(context.Background(), func(c context.Context, tx *Tx) error {
+		runs++
+		_, err := tx.Exec("UPDATE a")
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, runs)
+	assert.Equal(t, []string{
+		"BEGIN", "UPDATE a", "ROLLBACK",
+		"BEGIN", "UPDATE a", "ROLLBACK",
+		"BEGIN", "UPDATE a", "COMMIT",
+	}, d.history())
+
+	deadlocks = 0
+	db.write.conf.TranRetry = -1
+	err = db.Transact(context.Background(), func(c context.Context, tx *Tx) error {
+		_, err := tx.Exec("UPDATE a")
+		return err
+	})
+	assert.True(t, IsRetryable(err))
+}
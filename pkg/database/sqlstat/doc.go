@@ -0,0 +1,6 @@
+// Package sqlstat normalizes the statements to fingerprints and collects the
+// statistics and slow statements of them for the sql and tidb clients, the
+// top fingerprints are served by ServeTop on /debug/sql of the perf listener.
+// Recorder and the trace helpers are shared by the clients to record the
+// statements into the stats, metrics, slow log and spans.
+package sqlstat
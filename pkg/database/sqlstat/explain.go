@@ -0,0 +1,66 @@
+package sqlstat
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// Queryer runs EXPLAIN, such as *sql.DB.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Explain captures the EXPLAIN of a slow statement of the fingerprint of db
+// and records it, the plan is tab separated lines with a header line.
+func Explain(c context.Context, q Queryer, db, fingerprint, query string, args ...interface{}) (string, error) {
+	rows, err := q.QueryContext(c, "EXPLAIN "+query, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	plan, err := formatRows(rows)
+	if err != nil {
+		return "", err
+	}
+	RecordExplain(db, fingerprint, plan)
+	return plan, nil
+}
+
+// formatRows formats the rows as tab separated lines with a header line.
+func formatRows(rows *sql.Rows) (string, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(strings.Join(columns, "\t"))
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return "", err
+		}
+		b.WriteByte('\n')
+		for i, v := range values {
+			if i > 0 {
+				b.WriteByte('\t')
+			}
+			if v.Valid {
+				b.WriteString(v.String)
+			} else {
+				b.WriteString("NULL")
+			}
+		}
+	}
+	return b.String(), rows.Err()
+}
+
+// IsSelect reports whether query is a SELECT, which is safe to EXPLAIN.
+func IsSelect(query string) bool {
+	query = strings.TrimLeft(query, " \t\r\n(")
+	return len(query) >= 6 && strings.EqualFold(query[:6], "select")
+}
@@ -0,0 +1,146 @@
+package sqlstat
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// _maxFingerprintLen the max length of fingerprint, the longer is truncated.
+	_maxFingerprintLen = 256
+	// _maxCached the max number of cached fingerprints by query.
+	_maxCached = 10000
+)
+
+var (
+	_inList     = regexp.MustCompile(`\bin ?\(\?(, \?)*\)`)
+	_valuesList = regexp.MustCompile(`(\(\?(?:, \?)*\))(?:, \(\?(?:, \?)*\))+`)
+
+	_cache  sync.Map
+	_cached int64
+)
+
+// Fingerprint returns the shape of query, the literals and placeholders are
+// replaced with ?, the comments are removed, the whitespaces are collapsed,
+// the value lists of IN and VALUES are collapsed and it's in lower case, such as
+//
+//	SELECT * FROM user WHERE id IN (1, 2, 3) AND name = 'a'
+//
+// is select * from user where id in(?+) and name = ?.
+func Fingerprint(query string) string {
+	if fp, ok := _cache.Load(query); ok {
+		return fp.(string)
+	}
+	fp := fingerprint(query)
+	if atomic.LoadInt64(&_cached) < _maxCached {
+		if _, loaded := _cache.LoadOrStore(query, fp); !loaded {
+			atomic.AddInt64(&_cached, 1)
+		}
+	}
+	return fp
+}
+
+func fingerprint(query string) string {
+	var (
+		b     strings.Builder
+		space bool
+		last  byte
+	)
+	b.Grow(len(query))
+	// write writes c with a space before it if there are whitespaces, the
+	// spaces after ( and before , and ) are removed, and a space is always
+	// added after , so that the lists are in the same shape.
+	write := func(c byte) {
+		if (space || last == ',') && last != 0 && last != '(' && c != ',' && c != ')' {
+			b.WriteByte(' ')
+		}
+		space = false
+		last = c
+		b.WriteByte(c)
+	}
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+		case c == '\'' || c == '"':
+			for i++; i < len(query) && query[i] != c; i++ {
+				if query[i] == '\\' {
+					i++
+				}
+			}
+			write('?')
+		case c == '`':
+			start := i
+			for i++; i < len(query) && query[i] != '`'; i++ {
+			}
+			for _, ic := range []byte(strings.ToLower(query[start:min(i+1, len(query))])) {
+				write(ic)
+			}
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+			space = true
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(query)
+			}
+			space = true
+		case c == '?' || (c == '$' && i+1 < len(query) && isDigit(query[i+1])):
+			for i+1 < len(query) && isDigit(query[i+1]) {
+				i++
+			}
+			write('?')
+		case isDigit(c) && (i == 0 || !isIdent(query[i-1])):
+			if c == '0' && i+1 < len(query) && (query[i+1] == 'x' || query[i+1] == 'X') {
+				i++
+			}
+			for i+1 < len(query) && (isIdent(query[i+1]) || query[i+1] == '.') {
+				i++
+			}
+			write('?')
+		case isIdent(c):
+			// keep the digits in identifiers such as user_01.
+			for ; i < len(query) && isIdent(query[i]); i++ {
+				write(lower(query[i]))
+			}
+			i--
+		default:
+			write(c)
+		}
+	}
+	fp := strings.TrimRight(b.String(), "; ")
+	fp = _inList.ReplaceAllString(fp, "in(?+)")
+	fp = _valuesList.ReplaceAllString(fp, "$1")
+	if len(fp) > _maxFingerprintLen {
+		fp = fp[:_maxFingerprintLen]
+	}
+	return fp
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || isDigit(c) || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func lower(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
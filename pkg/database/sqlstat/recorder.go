@@ -0,0 +1,97 @@
+package sqlstat
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/log"
+	"github.com/go-kratos/kratos/pkg/net/trace"
+	"github.com/go-kratos/kratos/pkg/stat/metric"
+)
+
+const (
+	_slowThreshold  = time.Millisecond * 250
+	_explainTimeout = time.Second
+)
+
+// Recorder records the statements of a connection into the fingerprint stats,
+// metrics, slow log and spans, it's shared by the sql and tidb clients.
+type Recorder struct {
+	// Family the family of the logs and spans.
+	Family string
+	// DB the database in stats and the name label of metrics.
+	DB string
+	// Addr the addr label of metrics.
+	Addr string
+	// SlowThreshold the statements slower than it are slow, default 250ms.
+	SlowThreshold time.Duration
+	// ExplainRate the sampling rate of the slow SELECT statements to EXPLAIN,
+	// 0 disables it.
+	ExplainRate float64
+	// ExplainTimeout the timeout of EXPLAIN, default 1s.
+	ExplainTimeout time.Duration
+	// Queryer runs the EXPLAIN.
+	Queryer Queryer
+	// Rebind converts the placeholders of the EXPLAIN, nil means keeping them.
+	Rebind func(query string) string
+	// QueryDur and QuerySlow the metrics labeled by name, addr and fingerprint.
+	QueryDur  metric.HistogramVec
+	QuerySlow metric.CounterVec
+	// Tags the tags of the spans.
+	Tags []trace.Tag
+
+	// explaining is 1 if an EXPLAIN of slow statement is running.
+	explaining int32
+}
+
+// Record records the statement into the fingerprint stats and metrics, and
+// logs it if it's slow. err may be nil.
+func (r *Recorder) Record(c context.Context, query string, args []interface{}, now time.Time, err *error) {
+	du := time.Since(now)
+	var e error
+	if err != nil {
+		e = *err
+	}
+	fp := Record(r.DB, query, du, e)
+	r.QueryDur.ObserveContext(c, int64(du/time.Millisecond), r.DB, r.Addr, fp)
+	threshold := r.SlowThreshold
+	if threshold <= 0 {
+		threshold = _slowThreshold
+	}
+	if du <= threshold {
+		return
+	}
+	r.QuerySlow.Inc(r.DB, r.Addr, fp)
+	RecordSlow(r.DB, fp, query)
+	log.Warn("%s slow log statement: addr: %s query(%s) args(%+v) fingerprint(%s) time: %v", r.Family, r.Addr, query, args, fp, du)
+	if r.ExplainRate > 0 && rand.Float64() < r.ExplainRate && IsSelect(query) &&
+		atomic.CompareAndSwapInt32(&r.explaining, 0, 1) {
+		go func() {
+			r.explain(fp, query, args)
+			atomic.StoreInt32(&r.explaining, 0)
+		}()
+	}
+}
+
+// explain captures the EXPLAIN of a slow statement, only one EXPLAIN runs on
+// a connection at the same time.
+func (r *Recorder) explain(fp, query string, args []interface{}) {
+	timeout := r.ExplainTimeout
+	if timeout <= 0 {
+		timeout = _explainTimeout
+	}
+	c, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	stmt := query
+	if r.Rebind != nil {
+		stmt = r.Rebind(query)
+	}
+	plan, err := Explain(c, r.Queryer, r.DB, fp, stmt, args...)
+	if err != nil {
+		log.Error("%s explain statement: %s error(%v)", r.Family, query, err)
+		return
+	}
+	log.Warn("%s slow log statement: addr: %s query(%s) explain:\n%s", r.Family, r.Addr, query, plan)
+}
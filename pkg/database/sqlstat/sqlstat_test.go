@@ -0,0 +1,118 @@
+package sqlstat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprint(t *testing.T) {
+	for query, want := range map[string]string{
+		"SELECT * FROM user WHERE id IN (1, 2, 3) AND name = 'a'":           "select * from user where id in(?+) and name = ?",
+		"select *  from user\n where id in(?,?) and name=\"b\\\"c\" ;":      "select * from user where id in(?+) and name=?",
+		"SELECT id FROM user_01 WHERE id=? /* hint */ -- comment\nLIMIT 10": "select id from user_01 where id=? limit ?",
+		"INSERT INTO t(a,b) VALUES (1,'x'),(2,'y'), (3, 'z')":               "insert into t(a, b) values (?, ?)",
+		"UPDATE `User` SET score=score+1.5, hex=0xFF WHERE id=$1":           "update `user` set score=score+?, hex=? where id=?",
+		"SELECT MIN(a) FROM t WHERE b IN (SELECT c FROM d)":                 "select min(a) from t where b in (select c from d)",
+	} {
+		assert.Equal(t, want, Fingerprint(query), query)
+	}
+	assert.Equal(t, Fingerprint("SELECT 1"), Fingerprint("select   2"))
+}
+
+func TestRecordTop(t *testing.T) {
+	Reset()
+	defer Reset()
+	Record("db", "SELECT * FROM a WHERE id=1", time.Millisecond, nil)
+	Record("db", "SELECT * FROM a WHERE id=2", 3*time.Millisecond, errors.New("fail"))
+	fp := Record("db", "SELECT * FROM b", 10*time.Millisecond, nil)
+	RecordSlow("db", fp, "SELECT * FROM b WHERE name='secret'")
+	RecordExplain("db", fp, "ALL")
+
+	ss := Top(0, ByTotal)
+	if assert.Len(t, ss, 2) {
+		assert.Equal(t, "select * from b", ss[0].Fingerprint)
+		assert.Equal(t, int64(1), ss[0].Slow)
+		assert.Equal(t, "ALL", ss[0].Explain)
+		assert.Equal(t, "SELECT * FROM b WHERE name=?", ss[0].SlowQuery)
+	}
+	ss = Top(1, ByCount)
+	if assert.Len(t, ss, 1) {
+		assert.Equal(t, int64(2), ss[0].Count)
+		assert.Equal(t, int64(1), ss[0].Errors)
+		assert.Equal(t, 2*time.Millisecond, ss[0].Avg())
+		assert.Equal(t, 3*time.Millisecond, ss[0].Max)
+	}
+}
+
+func TestRecordOther(t *testing.T) {
+	Reset()
+	defer Reset()
+	for i := 0; i < _maxStats; i++ {
+		Record("db", fmt.Sprintf("SELECT * FROM t%d", i), time.Millisecond, nil)
+	}
+	assert.Equal(t, Other, Record("db", "SELECT * FROM x", time.Millisecond, nil))
+	assert.Equal(t, "select * from t1", Record("db", "SELECT * FROM t1", time.Millisecond, nil))
+	assert.Len(t, Top(0, ""), _maxStats+1)
+}
+
+func TestServeTop(t *testing.T) {
+	Reset()
+	defer Reset()
+	Record("a", "SELECT 1", time.Millisecond, nil)
+	Record("b", "SELECT 1", 2*time.Millisecond, nil)
+
+	w := httptest.NewRecorder()
+	ServeTop(w, httptest.NewRequest(http.MethodGet, "/debug/sql?by=max&db=a", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var res []*statJSON
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	if assert.Len(t, res, 1) {
+		assert.Equal(t, "a", res[0].DB)
+		assert.Equal(t, 1.0, res[0].MaxMs)
+	}
+
+	w = httptest.NewRecorder()
+	ServeTop(w, httptest.NewRequest(http.MethodGet, "/debug/sql?by=foo", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	ServeTop(w, httptest.NewRequest(http.MethodDelete, "/debug/sql", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, Top(0, ""))
+}
+
+func TestRecordConcurrent(t *testing.T) {
+	Reset()
+	defer Reset()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				Record("db", "SELECT 1", time.Duration(i*100+j), nil)
+			}
+		}(i)
+	}
+	wg.Wait()
+	ss := Top(0, "")
+	if assert.Len(t, ss, 1) {
+		assert.Equal(t, int64(800), ss[0].Count)
+		assert.Equal(t, time.Duration(799), ss[0].Max)
+	}
+}
+
+func TestIsSelect(t *testing.T) {
+	assert.True(t, IsSelect(" select 1"))
+	assert.True(t, IsSelect("(SELECT 1) UNION (SELECT 2)"))
+	assert.False(t, IsSelect("UPDATE a SET b=1"))
+	assert.False(t, IsSelect("SEL"))
+}
@@ -0,0 +1,291 @@
+package sqlstat
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/net/trace"
+)
+
+const (
+	// _maxStats the max number of fingerprints of all the databases, the
+	// statements of the fingerprints beyond are recorded as Other.
+	_maxStats = 1000
+	// _maxSample the max length of sample query.
+	_maxSample = 1024
+)
+
+// Other the fingerprint of the statements beyond the max number of fingerprints.
+const Other = "other"
+
+// Stat the statistics of a fingerprint of a database.
+type Stat struct {
+	DB          string
+	Fingerprint string
+	Count       int64
+	Errors      int64
+	Total       time.Duration
+	Max         time.Duration
+	// Slow the number of slow statements.
+	Slow int64
+	// SlowQuery the last slow statement whose literal values are redacted.
+	SlowQuery string
+	// SlowAt the time of the last slow statement.
+	SlowAt time.Time
+	// Explain the last captured EXPLAIN of the slow statements.
+	Explain string
+}
+
+// Avg returns the average duration.
+func (s *Stat) Avg() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+type key struct {
+	db, fingerprint string
+}
+
+// entry the stat of a fingerprint, the counters are updated atomically so
+// that the statements don't contend on a lock once the entry exists.
+type entry struct {
+	count  int64
+	errors int64
+	total  int64
+	max    int64
+	slow   int64
+
+	db, fingerprint string
+
+	mu        sync.Mutex
+	slowQuery string
+	slowAt    time.Time
+	explain   string
+}
+
+func (e *entry) snapshot() *Stat {
+	s := &Stat{
+		DB:          e.db,
+		Fingerprint: e.fingerprint,
+		Count:       atomic.LoadInt64(&e.count),
+		Errors:      atomic.LoadInt64(&e.errors),
+		Total:       time.Duration(atomic.LoadInt64(&e.total)),
+		Max:         time.Duration(atomic.LoadInt64(&e.max)),
+		Slow:        atomic.LoadInt64(&e.slow),
+	}
+	e.mu.Lock()
+	s.SlowQuery, s.SlowAt, s.Explain = e.slowQuery, e.slowAt, e.explain
+	e.mu.Unlock()
+	return s
+}
+
+var (
+	_mu    sync.RWMutex
+	_stats = make(map[key]*entry)
+)
+
+// Record records a statement of db executed in d and returns the fingerprint
+// of query, which is Other if there are too many fingerprints.
+func Record(db, query string, d time.Duration, err error) string {
+	fp := Fingerprint(query)
+	e := stat(db, &fp)
+	atomic.AddInt64(&e.count, 1)
+	atomic.AddInt64(&e.total, int64(d))
+	for {
+		max := atomic.LoadInt64(&e.max)
+		if int64(d) <= max || atomic.CompareAndSwapInt64(&e.max, max, int64(d)) {
+			break
+		}
+	}
+	if err != nil {
+		atomic.AddInt64(&e.errors, 1)
+	}
+	return fp
+}
+
+// RecordSlow records a slow statement of the fingerprint of db, the literal
+// values of the sample query are redacted.
+func RecordSlow(db, fingerprint, query string) {
+	query = trace.RedactSQL(query)
+	if len(query) > _maxSample {
+		query = query[:_maxSample]
+	}
+	e := stat(db, &fingerprint)
+	atomic.AddInt64(&e.slow, 1)
+	e.mu.Lock()
+	e.slowQuery = query
+	e.slowAt = time.Now()
+	e.mu.Unlock()
+}
+
+// RecordExplain records the EXPLAIN of a slow statement of the fingerprint of db.
+func RecordExplain(db, fingerprint, explain string) {
+	e := stat(db, &fingerprint)
+	e.mu.Lock()
+	e.explain = explain
+	e.mu.Unlock()
+}
+
+// stat returns the entry of fingerprint, fingerprint is changed to Other if
+// there are too many.
+func stat(db string, fingerprint *string) *entry {
+	k := key{db: db, fingerprint: *fingerprint}
+	_mu.RLock()
+	e, ok := _stats[k]
+	_mu.RUnlock()
+	if ok {
+		return e
+	}
+	_mu.Lock()
+	defer _mu.Unlock()
+	if e, ok = _stats[k]; ok {
+		return e
+	}
+	if len(_stats) >= _maxStats {
+		*fingerprint = Other
+		k.fingerprint = Other
+		if e, ok = _stats[k]; ok {
+			return e
+		}
+	}
+	e = &entry{db: db, fingerprint: *fingerprint}
+	_stats[k] = e
+	return e
+}
+
+// sort keys of Top.
+const (
+	ByTotal  = "total"
+	ByCount  = "count"
+	ByAvg    = "avg"
+	ByMax    = "max"
+	BySlow   = "slow"
+	ByErrors = "errors"
+)
+
+var _less = map[string]func(a, b *Stat) bool{
+	ByTotal:  func(a, b *Stat) bool { return a.Total > b.Total },
+	ByCount:  func(a, b *Stat) bool { return a.Count > b.Count },
+	ByAvg:    func(a, b *Stat) bool { return a.Avg() > b.Avg() },
+	ByMax:    func(a, b *Stat) bool { return a.Max > b.Max },
+	BySlow:   func(a, b *Stat) bool { return a.Slow > b.Slow },
+	ByErrors: func(a, b *Stat) bool { return a.Errors > b.Errors },
+}
+
+// Top returns the copies of the top n stats sorted by by in descending
+// order, n <= 0 means all, by is total by default.
+func Top(n int, by string) []*Stat {
+	less, ok := _less[by]
+	if !ok {
+		less = _less[ByTotal]
+	}
+	_mu.RLock()
+	es := make([]*entry, 0, len(_stats))
+	for _, e := range _stats {
+		es = append(es, e)
+	}
+	_mu.RUnlock()
+	ss := make([]*Stat, 0, len(es))
+	for _, e := range es {
+		ss = append(ss, e.snapshot())
+	}
+	sort.Slice(ss, func(i, j int) bool { return less(ss[i], ss[j]) })
+	if n > 0 && n < len(ss) {
+		ss = ss[:n]
+	}
+	return ss
+}
+
+// Reset clears all the stats.
+func Reset() {
+	_mu.Lock()
+	_stats = make(map[key]*entry)
+	_mu.Unlock()
+}
+
+type statJSON struct {
+	DB          string  `json:"db"`
+	Fingerprint string  `json:"fingerprint"`
+	Count       int64   `json:"count"`
+	Errors      int64   `json:"errors"`
+	TotalMs     float64 `json:"total_ms"`
+	AvgMs       float64 `json:"avg_ms"`
+	MaxMs       float64 `json:"max_ms"`
+	Slow        int64   `json:"slow"`
+	SlowQuery   string  `json:"slow_query,omitempty"`
+	SlowAt      string  `json:"slow_at,omitempty"`
+	Explain     string  `json:"explain,omitempty"`
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// ServeTop serve the top fingerprints over http as json, the query values:
+//
+//	n: the number of fingerprints, default 20, 0 means all.
+//	by: total, count, avg, max, slow or errors, default total.
+//	db: only the fingerprints of the database.
+//
+// DELETE resets the stats.
+func ServeTop(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodDelete:
+		Reset()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	n := 20
+	if v := r.FormValue("n"); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "sqlstat: invalid n "+strconv.Quote(v), http.StatusBadRequest)
+			return
+		}
+	}
+	by := r.FormValue("by")
+	if _, ok := _less[by]; by != "" && !ok {
+		http.Error(w, "sqlstat: invalid by "+strconv.Quote(by), http.StatusBadRequest)
+		return
+	}
+	db := r.FormValue("db")
+	ss := Top(0, by)
+	res := make([]*statJSON, 0, len(ss))
+	for _, s := range ss {
+		if db != "" && s.DB != db {
+			continue
+		}
+		if n > 0 && len(res) >= n {
+			break
+		}
+		sj := &statJSON{
+			DB:          s.DB,
+			Fingerprint: s.Fingerprint,
+			Count:       s.Count,
+			Errors:      s.Errors,
+			TotalMs:     ms(s.Total),
+			AvgMs:       ms(s.Avg()),
+			MaxMs:       ms(s.Max),
+			Slow:        s.Slow,
+			SlowQuery:   s.SlowQuery,
+			Explain:     s.Explain,
+		}
+		if !s.SlowAt.IsZero() {
+			sj.SlowAt = s.SlowAt.Format(time.RFC3339)
+		}
+		res = append(res, sj)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(res)
+}
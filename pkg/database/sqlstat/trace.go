@@ -0,0 +1,53 @@
+package sqlstat
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kratos/kratos/pkg/net/trace"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// TraceTags returns the tags of the spans on connection of the mysql dsn.
+func TraceTags(component, peerService, dsn, addr string) []trace.Tag {
+	tags := []trace.Tag{
+		trace.TagString(trace.TagSpanKind, "client"),
+		trace.TagString(trace.TagComponent, component),
+		trace.TagString(trace.TagPeerService, peerService),
+		trace.TagString(trace.TagDBType, "sql"),
+		trace.TagString(trace.TagPeerAddress, addr),
+	}
+	if cfg, err := mysql.ParseDSN(dsn); err == nil {
+		tags = append(tags, trace.TagString(trace.TagDBInstance, cfg.DBName), trace.TagString(trace.TagDBUser, cfg.User))
+	}
+	return tags
+}
+
+// Fork forks a client span of the statement from the trace in context.
+func (r *Recorder) Fork(c context.Context, operation, query string) (t trace.Trace, ok bool) {
+	if t, ok = trace.FromContext(c); !ok {
+		return nil, false
+	}
+	t = t.Fork(r.Family, operation)
+	t.SetTag(r.Tags...)
+	if query != "" {
+		t.SetTag(trace.TagString(trace.TagDBStatement, trace.SQLStatement(query)))
+	}
+	return t, true
+}
+
+// TraceRowsAffected sets the rows affected by exec to span.
+func TraceRowsAffected(t trace.Trace, res sql.Result) {
+	if res == nil {
+		return
+	}
+	if n, err := res.RowsAffected(); err == nil {
+		t.SetTag(trace.TagInt64(trace.TagDBRowsAffected, n))
+	}
+}
+
+// TraceTxLog logs the statement executed in transaction to the span of transaction.
+func TraceTxLog(t trace.Trace, event, query string) {
+	t.SetLog(trace.Log(trace.LogEvent, event), trace.Log(trace.TagDBStatement, trace.SQLStatement(query)))
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/go-kratos/kratos/pkg/database/sqlstat"
 	"github.com/go-kratos/kratos/pkg/log"
 	"github.com/go-kratos/kratos/pkg/naming"
 	"github.com/go-kratos/kratos/pkg/net/netutil/breaker"
@@ -50,6 +52,7 @@ type conn struct {
 	breaker breaker.Breaker
 	conf    *Config
 	addr    string
+	stat    *sqlstat.Recorder
 }
 
 // Tx transaction.
@@ -170,7 +173,7 @@ func (db *DB) connectDSN(dsn string) (c *conn, err error) {
 	}
 	addr := parseDSNAddr(dsn)
 	brk := db.breakerGroup.Get(addr)
-	c = &conn{DB: d, breaker: brk, conf: db.conf, addr: addr}
+	c = &conn{DB: d, breaker: brk, conf: db.conf, addr: addr, stat: newRecorder(d, db.conf, dsn, addr)}
 	return
 }
 
@@ -280,10 +283,8 @@ func (db *conn) onBreaker(err *error) {
 func (db *conn) begin(c context.Context) (tx *Tx, err error) {
 	now := time.Now()
 	defer slowLog(fmt.Sprintf("Begin addr: %s", db.addr), now)
-	t, ok := trace.FromContext(c)
+	t, ok := db.stat.Fork(c, "begin", "")
 	if ok {
-		t = t.Fork(_family, "begin")
-		t.SetTag(trace.String(trace.TagAddress, db.addr), trace.String(trace.TagComment, ""))
 		defer func() {
 			if err != nil {
 				t.Finish(&err)
@@ -296,7 +297,7 @@ func (db *conn) begin(c context.Context) (tx *Tx, err error) {
 	}
 	_, c, cancel := db.conf.TranTimeout.Shrink(c)
 	rtx, err := db.BeginTx(c, nil)
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), db.addr, db.addr, "begin")
+	_metricReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), db.addr, db.addr, "begin")
 	if err != nil {
 		err = errors.WithStack(err)
 		cancel()
@@ -308,11 +309,12 @@ func (db *conn) begin(c context.Context) (tx *Tx, err error) {
 
 func (db *conn) exec(c context.Context, query string, args ...interface{}) (res sql.Result, err error) {
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("Exec addr: %s query(%s) args(%+v)", db.addr, query, args), now)
-	if t, ok := trace.FromContext(c); ok {
-		t = t.Fork(_family, "exec")
-		t.SetTag(trace.String(trace.TagAddress, db.addr), trace.String(trace.TagComment, query))
-		defer t.Finish(&err)
+	defer db.stat.Record(c, query, args, now, &err)
+	if t, ok := db.stat.Fork(c, "exec", query); ok {
+		defer func() {
+			sqlstat.TraceRowsAffected(t, res)
+			t.Finish(&err)
+		}()
 	}
 	if err = db.breaker.Allow(); err != nil {
 		_metricReqErr.Inc(db.addr, db.addr, "exec", "breaker")
@@ -322,7 +324,7 @@ func (db *conn) exec(c context.Context, query string, args ...interface{}) (res
 	res, err = db.ExecContext(c, query, args...)
 	cancel()
 	db.onBreaker(&err)
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), db.addr, db.addr, "exec")
+	_metricReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), db.addr, db.addr, "exec")
 	if err != nil {
 		err = errors.Wrapf(err, "addr: %s exec:%s, args:%+v", db.addr, query, args)
 	}
@@ -332,9 +334,7 @@ func (db *conn) exec(c context.Context, query string, args ...interface{}) (res
 func (db *conn) ping(c context.Context) (err error) {
 	now := time.Now()
 	defer slowLog(fmt.Sprintf("Ping addr: %s", db.addr), now)
-	if t, ok := trace.FromContext(c); ok {
-		t = t.Fork(_family, "ping")
-		t.SetTag(trace.String(trace.TagAddress, db.addr), trace.String(trace.TagComment, ""))
+	if t, ok := db.stat.Fork(c, "ping", ""); ok {
 		defer t.Finish(&err)
 	}
 	if err = db.breaker.Allow(); err != nil {
@@ -345,7 +345,7 @@ func (db *conn) ping(c context.Context) (err error) {
 	err = db.PingContext(c)
 	cancel()
 	db.onBreaker(&err)
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), db.addr, db.addr, "ping")
+	_metricReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), db.addr, db.addr, "ping")
 	if err != nil {
 		err = errors.WithStack(err)
 	}
@@ -377,10 +377,8 @@ func (db *conn) prepared(query string) (stmt *Stmt) {
 
 func (db *conn) query(c context.Context, query string, args ...interface{}) (rows *Rows, err error) {
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("Query addr: %s query(%s) args(%+v)", db.addr, query, args), now)
-	if t, ok := trace.FromContext(c); ok {
-		t = t.Fork(_family, "query")
-		t.SetTag(trace.String(trace.TagAddress, db.addr), trace.String(trace.TagComment, query))
+	defer db.stat.Record(c, query, args, now, &err)
+	if t, ok := db.stat.Fork(c, "query", query); ok {
 		defer t.Finish(&err)
 	}
 	if err = db.breaker.Allow(); err != nil {
@@ -390,7 +388,7 @@ func (db *conn) query(c context.Context, query string, args ...interface{}) (row
 	_, c, cancel := db.conf.QueryTimeout.Shrink(c)
 	rs, err := db.DB.QueryContext(c, query, args...)
 	db.onBreaker(&err)
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), db.addr, db.addr, "query")
+	_metricReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), db.addr, db.addr, "query")
 	if err != nil {
 		err = errors.Wrapf(err, "addr: %s, query:%s, args:%+v", db.addr, query, args)
 		cancel()
@@ -402,19 +400,15 @@ func (db *conn) query(c context.Context, query string, args ...interface{}) (row
 
 func (db *conn) queryRow(c context.Context, query string, args ...interface{}) *Row {
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("QueryRow addr: %s query(%s) args(%+v)", db.addr, query, args), now)
-	t, ok := trace.FromContext(c)
-	if ok {
-		t = t.Fork(_family, "queryrow")
-		t.SetTag(trace.String(trace.TagAddress, db.addr), trace.String(trace.TagComment, query))
-	}
+	defer db.stat.Record(c, query, args, now, nil)
+	t, _ := db.stat.Fork(c, "queryrow", query)
 	if err := db.breaker.Allow(); err != nil {
 		_metricReqErr.Inc(db.addr, db.addr, "queryrow", "breaker")
 		return &Row{db: db, t: t, err: err}
 	}
 	_, c, cancel := db.conf.QueryTimeout.Shrink(c)
 	r := db.DB.QueryRowContext(c, query, args...)
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), db.addr, db.addr, "queryrow")
+	_metricReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), db.addr, db.addr, "queryrow")
 	return &Row{db: db, Row: r, query: query, args: args, t: t, cancel: cancel}
 }
 
@@ -443,15 +437,16 @@ func (s *Stmt) prepare() (st *sql.Stmt) {
 // Result summarizing the effect of the statement.
 func (s *Stmt) Exec(c context.Context, args ...interface{}) (res sql.Result, err error) {
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("Exec addr: %s query(%s) args(%+v)", s.db.addr, s.query, args), now)
+	defer s.db.stat.Record(c, s.query, args, now, &err)
 	if s.tx {
 		if s.t != nil {
-			s.t.SetTag(trace.String(trace.TagAnnotation, s.query))
+			sqlstat.TraceTxLog(s.t, "stmt:exec", s.query)
 		}
-	} else if t, ok := trace.FromContext(c); ok {
-		t = t.Fork(_family, "exec")
-		t.SetTag(trace.String(trace.TagAddress, s.db.addr), trace.String(trace.TagComment, s.query))
-		defer t.Finish(&err)
+	} else if t, ok := s.db.stat.Fork(c, "exec", s.query); ok {
+		defer func() {
+			sqlstat.TraceRowsAffected(t, res)
+			t.Finish(&err)
+		}()
 	}
 	if err = s.db.breaker.Allow(); err != nil {
 		_metricReqErr.Inc(s.db.addr, s.db.addr, "stmt:exec", "breaker")
@@ -466,7 +461,7 @@ func (s *Stmt) Exec(c context.Context, args ...interface{}) (res sql.Result, err
 	res, err = stmt.ExecContext(c, args...)
 	cancel()
 	s.db.onBreaker(&err)
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), s.db.addr, s.db.addr, "stmt:exec")
+	_metricReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), s.db.addr, s.db.addr, "stmt:exec")
 	if err != nil {
 		err = errors.Wrapf(err, "addr: %s exec:%s, args:%+v", s.db.addr, s.query, args)
 	}
@@ -477,14 +472,12 @@ func (s *Stmt) Exec(c context.Context, args ...interface{}) (res sql.Result, err
 // returns the query results as a *Rows.
 func (s *Stmt) Query(c context.Context, args ...interface{}) (rows *Rows, err error) {
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("Query addr: %s query(%s) args(%+v)", s.db.addr, s.query, args), now)
+	defer s.db.stat.Record(c, s.query, args, now, &err)
 	if s.tx {
 		if s.t != nil {
-			s.t.SetTag(trace.String(trace.TagAnnotation, s.query))
+			sqlstat.TraceTxLog(s.t, "stmt:query", s.query)
 		}
-	} else if t, ok := trace.FromContext(c); ok {
-		t = t.Fork(_family, "query")
-		t.SetTag(trace.String(trace.TagAddress, s.db.addr), trace.String(trace.TagComment, s.query))
+	} else if t, ok := s.db.stat.Fork(c, "query", s.query); ok {
 		defer t.Finish(&err)
 	}
 	if err = s.db.breaker.Allow(); err != nil {
@@ -499,7 +492,7 @@ func (s *Stmt) Query(c context.Context, args ...interface{}) (rows *Rows, err er
 	_, c, cancel := s.db.conf.QueryTimeout.Shrink(c)
 	rs, err := stmt.QueryContext(c, args...)
 	s.db.onBreaker(&err)
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), s.db.addr, s.db.addr, "stmt:query")
+	_metricReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), s.db.addr, s.db.addr, "stmt:query")
 	if err != nil {
 		err = errors.Wrapf(err, "addr: %s, query:%s, args:%+v", s.db.addr, s.query, args)
 		cancel()
@@ -516,15 +509,13 @@ func (s *Stmt) Query(c context.Context, args ...interface{}) (rows *Rows, err er
 // Otherwise, the *Row's Scan scans the first selected row and discards the rest.
 func (s *Stmt) QueryRow(c context.Context, args ...interface{}) (row *Row) {
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("QueryRow addr: %s query(%s) args(%+v)", s.db.addr, s.query, args), now)
+	defer s.db.stat.Record(c, s.query, args, now, nil)
 	row = &Row{db: s.db, query: s.query, args: args}
 	if s.tx {
 		if s.t != nil {
-			s.t.SetTag(trace.String(trace.TagAnnotation, s.query))
+			sqlstat.TraceTxLog(s.t, "stmt:queryrow", s.query)
 		}
-	} else if t, ok := trace.FromContext(c); ok {
-		t = t.Fork(_family, "queryrow")
-		t.SetTag(trace.String(trace.TagAddress, s.db.addr), trace.String(trace.TagComment, s.query))
+	} else if t, ok := s.db.stat.Fork(c, "queryrow", s.query); ok {
 		row.t = t
 	}
 	if row.err = s.db.breaker.Allow(); row.err != nil {
@@ -538,7 +529,7 @@ func (s *Stmt) QueryRow(c context.Context, args ...interface{}) (row *Row) {
 	_, c, cancel := s.db.conf.QueryTimeout.Shrink(c)
 	row.Row = stmt.QueryRowContext(c, args...)
 	row.cancel = cancel
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), s.db.addr, s.db.addr, "stmt:queryrow")
+	_metricReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), s.db.addr, s.db.addr, "stmt:queryrow")
 	return
 }
 
@@ -621,12 +612,17 @@ func (tx *Tx) Rollback() (err error) {
 // UPDATE.
 func (tx *Tx) Exec(query string, args ...interface{}) (res sql.Result, err error) {
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("Exec addr: %s query(%s) args(%+v)", tx.db.addr, query, args), now)
+	defer tx.db.stat.Record(tx.c, query, args, now, &err)
 	if tx.t != nil {
-		tx.t.SetTag(trace.String(trace.TagAnnotation, fmt.Sprintf("exec %s", query)))
+		sqlstat.TraceTxLog(tx.t, "exec", query)
 	}
 	res, err = tx.tx.ExecContext(tx.c, query, args...)
-	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), tx.db.addr, tx.db.addr, "tx:exec")
+	if tx.t != nil && err == nil {
+		if n, rerr := res.RowsAffected(); rerr == nil {
+			tx.t.SetLog(trace.Log(trace.TagDBRowsAffected, strconv.FormatInt(n, 10)))
+		}
+	}
+	_metricReqDur.ObserveContext(tx.c, int64(time.Since(now)/time.Millisecond), tx.db.addr, tx.db.addr, "tx:exec")
 	if err != nil {
 		err = errors.Wrapf(err, "addr: %s exec:%s, args:%+v", tx.db.addr, query, args)
 	}
@@ -636,12 +632,12 @@ func (tx *Tx) Exec(query string, args ...interface{}) (res sql.Result, err error
 // Query executes a query that returns rows, typically a SELECT.
 func (tx *Tx) Query(query string, args ...interface{}) (rows *Rows, err error) {
 	if tx.t != nil {
-		tx.t.SetTag(trace.String(trace.TagAnnotation, fmt.Sprintf("query %s", query)))
+		sqlstat.TraceTxLog(tx.t, "query", query)
 	}
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("Query addr: %s query(%s) args(%+v)", tx.db.addr, query, args), now)
+	defer tx.db.stat.Record(tx.c, query, args, now, &err)
 	defer func() {
-		_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), tx.db.addr, tx.db.addr, "tx:query")
+		_metricReqDur.ObserveContext(tx.c, int64(time.Since(now)/time.Millisecond), tx.db.addr, tx.db.addr, "tx:query")
 	}()
 	rs, err := tx.tx.QueryContext(tx.c, query, args...)
 	if err == nil {
@@ -657,12 +653,12 @@ func (tx *Tx) Query(query string, args ...interface{}) (rows *Rows, err error) {
 // Scan method is called.
 func (tx *Tx) QueryRow(query string, args ...interface{}) *Row {
 	if tx.t != nil {
-		tx.t.SetTag(trace.String(trace.TagAnnotation, fmt.Sprintf("queryrow %s", query)))
+		sqlstat.TraceTxLog(tx.t, "queryrow", query)
 	}
 	now := time.Now()
-	defer slowLog(fmt.Sprintf("QueryRow addr: %s query(%s) args(%+v)", tx.db.addr, query, args), now)
+	defer tx.db.stat.Record(tx.c, query, args, now, nil)
 	defer func() {
-		_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), tx.db.addr, tx.db.addr, "tx:queryrow")
+		_metricReqDur.ObserveContext(tx.c, int64(time.Since(now)/time.Millisecond), tx.db.addr, tx.db.addr, "tx:queryrow")
 	}()
 	r := tx.tx.QueryRowContext(tx.c, query, args...)
 	return &Row{Row: r, db: tx.db, query: query, args: args}
@@ -694,7 +690,7 @@ func (tx *Tx) Stmts(stmt *Stmts) *Stmt {
 // To use an existing prepared statement on this transaction, see Tx.Stmt.
 func (tx *Tx) Prepare(query string) (*Stmt, error) {
 	if tx.t != nil {
-		tx.t.SetTag(trace.String(trace.TagAnnotation, fmt.Sprintf("prepare %s", query)))
+		sqlstat.TraceTxLog(tx.t, "prepare", query)
 	}
 	defer slowLog(fmt.Sprintf("Prepare addr: %s query(%s)", tx.db.addr, query), time.Now())
 	stmt, err := tx.tx.Prepare(query)
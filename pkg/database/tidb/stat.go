@@ -0,0 +1,29 @@
+package tidb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/database/sqlstat"
+)
+
+const (
+	_traceComponent   = "database/tidb"
+	_tracePeerService = "tidb"
+)
+
+// newRecorder returns the recorder of the statements on connection d of dsn.
+func newRecorder(d *sql.DB, c *Config, dsn, addr string) *sqlstat.Recorder {
+	return &sqlstat.Recorder{
+		Family:         _family,
+		DB:             addr,
+		Addr:           addr,
+		SlowThreshold:  time.Duration(c.SlowThreshold),
+		ExplainRate:    c.ExplainRate,
+		ExplainTimeout: time.Duration(c.QueryTimeout),
+		Queryer:        d,
+		QueryDur:       _metricQueryDur,
+		QuerySlow:      _metricQuerySlow,
+		Tags:           sqlstat.TraceTags(_traceComponent, _tracePeerService, dsn, addr),
+	}
+}
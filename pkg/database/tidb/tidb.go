@@ -19,6 +19,11 @@ type Config struct {
 	ExecTimeout  time.Duration   // execute sql timeout
 	TranTimeout  time.Duration   // transaction sql timeout
 	Breaker      *breaker.Config // breaker
+	// SlowThreshold the statements slower than it are logged, default 250ms.
+	SlowThreshold time.Duration
+	// ExplainRate the sampling rate of the slow SELECT statements whose
+	// EXPLAIN is captured, 0 means never.
+	ExplainRate float64
 }
 
 // NewTiDB new db and retry connection when has error.
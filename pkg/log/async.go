@@ -0,0 +1,108 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+const _defaultAsyncSize = 8192
+
+// AsyncHandler writes logs to Handler in a background goroutine through a
+// ring buffer, the oldest log is dropped when the buffer is full so that
+// logging never blocks.
+type AsyncHandler struct {
+	Handler
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	ring    []entry
+	head    int
+	size    int
+	closed  bool
+	dropped uint64
+	done    chan struct{}
+}
+
+type entry struct {
+	ctx context.Context
+	lv  Level
+	d   []D
+}
+
+// NewAsync create a async log handler of h with a ring buffer of size logs.
+func NewAsync(h Handler, size int) *AsyncHandler {
+	if size <= 0 {
+		size = _defaultAsyncSize
+	}
+	a := &AsyncHandler{
+		Handler: h,
+		ring:    make([]entry, size),
+		done:    make(chan struct{}),
+	}
+	a.cond = sync.NewCond(&a.mu)
+	go a.daemon()
+	return a
+}
+
+// Log put the log into ring buffer.
+func (a *AsyncHandler) Log(ctx context.Context, lv Level, args ...D) {
+	e := entry{ctx: detachContext(ctx), lv: lv, d: append([]D(nil), args...)}
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return
+	}
+	if a.size == len(a.ring) {
+		// drop the oldest
+		old := a.ring[a.head]
+		a.ring[a.head] = entry{}
+		a.head = (a.head + 1) % len(a.ring)
+		a.size--
+		atomic.AddUint64(&a.dropped, 1)
+		metricDroppedCount.Inc(old.lv.String())
+	}
+	a.ring[(a.head+a.size)%len(a.ring)] = e
+	a.size++
+	a.mu.Unlock()
+	a.cond.Signal()
+}
+
+// Dropped returns the number of logs dropped because the buffer is full.
+func (a *AsyncHandler) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+func (a *AsyncHandler) daemon() {
+	defer close(a.done)
+	for {
+		a.mu.Lock()
+		for a.size == 0 && !a.closed {
+			a.cond.Wait()
+		}
+		if a.size == 0 && a.closed {
+			a.mu.Unlock()
+			return
+		}
+		e := a.ring[a.head]
+		a.ring[a.head] = entry{}
+		a.head = (a.head + 1) % len(a.ring)
+		a.size--
+		a.mu.Unlock()
+		a.Handler.Log(e.ctx, e.lv, e.d...)
+	}
+}
+
+// Close flush the buffered logs and close Handler.
+func (a *AsyncHandler) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	a.mu.Unlock()
+	a.cond.Broadcast()
+	<-a.done
+	return a.Handler.Close()
+}
@@ -0,0 +1,60 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/pkg/net/trace"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type blockHandler struct {
+	countHandler
+	block chan struct{}
+}
+
+func (h *blockHandler) Log(ctx context.Context, lv Level, args ...D) {
+	<-h.block
+	h.countHandler.Log(ctx, lv, args...)
+}
+
+func TestAsyncHandler(t *testing.T) {
+	bh := &blockHandler{block: make(chan struct{})}
+	h := NewAsync(bh, 4)
+	for i := 0; i < 10; i++ {
+		h.Log(context.Background(), _infoLevel, KVString(_log, "msg"))
+	}
+	// one log may be taken by daemon, the others are buffered or dropped.
+	assert.True(t, h.Dropped() >= 5)
+	close(bh.block)
+	assert.NoError(t, h.Close())
+	assert.Equal(t, uint64(10), h.Dropped()+uint64(bh.count()))
+	h.Log(context.Background(), _infoLevel, KVString(_log, "closed"))
+	assert.Equal(t, uint64(10), h.Dropped()+uint64(bh.count()))
+}
+
+type ctxHandler struct {
+	tid chan string
+}
+
+func (h *ctxHandler) Log(ctx context.Context, lv Level, args ...D) {
+	ti, _ := traceFromContext(ctx)
+	h.tid <- ti.legacy
+}
+func (h *ctxHandler) SetFormat(string) {}
+func (h *ctxHandler) Close() error     { return nil }
+
+func TestAsyncHandlerDetachTrace(t *testing.T) {
+	ch := &ctxHandler{tid: make(chan string, 1)}
+	h := NewAsync(ch, 4)
+	defer h.Close()
+	tracer := trace.NewTracer("log_test", nopReport{}, true)
+	sp := tracer.New("op")
+	tid := sp.TraceID()
+	ctx, cancel := context.WithCancel(trace.NewContext(context.Background(), sp))
+	h.Log(ctx, _infoLevel, KVString(_log, "msg"))
+	cancel()
+	sp.Finish(nil)
+	assert.Equal(t, tid, <-ch.tid)
+}
@@ -0,0 +1,134 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	_defaultBatchSize     = 1024
+	_defaultFlushInterval = time.Second
+	_defaultQueueSize     = 8192
+)
+
+// record a encoded log line waiting to be shipped.
+type record struct {
+	ts   time.Time
+	lv   Level
+	line []byte
+}
+
+// batcher collects records through a bounded queue and flushes them in
+// batches of size or every interval in a background goroutine.
+type batcher struct {
+	size     int
+	interval time.Duration
+	// block is the max time Log waits for a full queue, 0 means drop at once.
+	block time.Duration
+	flush func([]record)
+
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan record
+	dropped uint64
+	done    chan struct{}
+}
+
+func newBatcher(size, queue int, interval, block time.Duration, flush func([]record)) *batcher {
+	if size <= 0 {
+		size = _defaultBatchSize
+	}
+	if queue <= 0 {
+		queue = _defaultQueueSize
+	}
+	if interval <= 0 {
+		interval = _defaultFlushInterval
+	}
+	b := &batcher{
+		size:     size,
+		interval: interval,
+		block:    block,
+		flush:    flush,
+		queue:    make(chan record, queue),
+		done:     make(chan struct{}),
+	}
+	go b.daemon()
+	return b
+}
+
+// push put r into queue, wait at most block when the queue is full.
+func (b *batcher) push(r record) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+	select {
+	case b.queue <- r:
+		return
+	default:
+	}
+	if b.block > 0 {
+		timer := time.NewTimer(b.block)
+		defer timer.Stop()
+		select {
+		case b.queue <- r:
+			return
+		case <-timer.C:
+		}
+	}
+	b.drop(r)
+}
+
+func (b *batcher) drop(rs ...record) {
+	atomic.AddUint64(&b.dropped, uint64(len(rs)))
+	for _, r := range rs {
+		metricDroppedCount.Inc(r.lv.String())
+	}
+}
+
+// Dropped returns the number of logs dropped.
+func (b *batcher) Dropped() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
+
+func (b *batcher) daemon() {
+	defer close(b.done)
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	batch := make([]record, 0, b.size)
+	for {
+		select {
+		case r, ok := <-b.queue:
+			if !ok {
+				b.flush(batch)
+				return
+			}
+			if batch = append(batch, r); len(batch) >= b.size {
+				b.flush(batch)
+				batch = make([]record, 0, b.size)
+			}
+		case <-ticker.C:
+			// flush even if empty so that the handler can retry the spooled logs.
+			b.flush(batch)
+			batch = make([]record, 0, b.size)
+		}
+	}
+}
+
+// close stop accepting logs and wait at most timeout for the rest to be flushed.
+func (b *batcher) close(timeout time.Duration) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	close(b.queue)
+	b.mu.Unlock()
+	select {
+	case <-b.done:
+	case <-time.After(timeout):
+	}
+}
@@ -27,6 +27,34 @@ var _fileNames = map[int]string{
 	_fatalIdx: "fatal.log",
 }
 
+// FileOption option of file handler.
+type FileOption = filewriter.Option
+
+// FileRotateHourly rotate log files every hour instead of every day.
+func FileRotateHourly() FileOption {
+	return filewriter.RotateFormat(filewriter.RotateHourly)
+}
+
+// FileCompress compress rotated files with gzip or zstd in background.
+func FileCompress(algorithm string) FileOption {
+	return filewriter.Compress(algorithm)
+}
+
+// FileMaxAge remove rotated files older than d.
+func FileMaxAge(d time.Duration) FileOption {
+	return filewriter.MaxAge(d)
+}
+
+// FileMaxTotalSize remove the oldest rotated files when the files of a level exceed n bytes.
+func FileMaxTotalSize(n int64) FileOption {
+	return filewriter.MaxTotalSize(n)
+}
+
+// FileRotateHook invoke fn with the path of rotated file after each rotation, e.g. to upload it.
+func FileRotateHook(fn func(fpath string)) FileOption {
+	return filewriter.RotateHook(fn)
+}
+
 // FileHandler .
 type FileHandler struct {
 	render Render
@@ -34,7 +62,7 @@ type FileHandler struct {
 }
 
 // NewFile crete a file logger.
-func NewFile(dir string, bufferSize, rotateSize int64, maxLogFile int) *FileHandler {
+func NewFile(dir string, bufferSize, rotateSize int64, maxLogFile int, opts ...FileOption) *FileHandler {
 	// new info writer
 	newWriter := func(name string) *filewriter.FileWriter {
 		var options []filewriter.Option
@@ -44,6 +72,7 @@ func NewFile(dir string, bufferSize, rotateSize int64, maxLogFile int) *FileHand
 		if maxLogFile > 0 {
 			options = append(options, filewriter.MaxFile(maxLogFile))
 		}
+		options = append(options, opts...)
 		w, err := filewriter.New(filepath.Join(dir, name), options...)
 		if err != nil {
 			panic(err)
@@ -2,6 +2,7 @@ package log
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	pkgerr "github.com/pkg/errors"
@@ -28,6 +29,9 @@ const (
 	_instanceID = "instance_id"
 	// uniq ID from trace.
 	_tid = "traceid"
+	// trace id and span id of json log.
+	_traceID = "trace_id"
+	_spanID  = "span_id"
 	// request time.
 	// _ts = "ts"
 	// requester.
@@ -65,25 +69,32 @@ type Handler interface {
 }
 
 func newHandlers(filters []string, handlers ...Handler) *Handlers {
-	set := make(map[string]struct{})
-	for _, k := range filters {
-		set[k] = struct{}{}
-	}
-	return &Handlers{filters: set, handlers: handlers}
+	hs := &Handlers{handlers: handlers}
+	hs.setFilters(filters)
+	return hs
 }
 
 // Handlers a bundle for hander with filter function.
 type Handlers struct {
-	filters  map[string]struct{}
+	filters  atomic.Value // map[string]struct{}
 	handlers []Handler
 }
 
+func (hs *Handlers) setFilters(filters []string) {
+	set := make(map[string]struct{})
+	for _, k := range filters {
+		set[k] = struct{}{}
+	}
+	hs.filters.Store(set)
+}
+
 // Log handlers logging.
-func (hs Handlers) Log(ctx context.Context, lv Level, d ...D) {
+func (hs *Handlers) Log(ctx context.Context, lv Level, d ...D) {
+	filters := hs.filters.Load().(map[string]struct{})
 	hasSource := false
 	for i := range d {
-		if _, ok := hs.filters[d[i].Key]; ok {
-			d[i].Value = "***"
+		if _, ok := filters[d[i].Key]; ok {
+			d[i] = KVString(d[i].Key, "***")
 		}
 		if d[i].Key == _source {
 			hasSource = true
@@ -101,7 +112,7 @@ func (hs Handlers) Log(ctx context.Context, lv Level, d ...D) {
 }
 
 // Close close resource.
-func (hs Handlers) Close() (err error) {
+func (hs *Handlers) Close() (err error) {
 	for _, h := range hs.handlers {
 		if e := h.Close(); e != nil {
 			err = pkgerr.WithStack(e)
@@ -111,7 +122,7 @@ func (hs Handlers) Close() (err error) {
 }
 
 // SetFormat .
-func (hs Handlers) SetFormat(format string) {
+func (hs *Handlers) SetFormat(format string) {
 	for _, h := range hs.handlers {
 		h.SetFormat(format)
 	}
@@ -0,0 +1,76 @@
+package filewriter
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var compressExts = map[string]string{
+	CompressGzip: ".gz",
+	CompressZstd: ".zst",
+}
+
+// trimCompressExt remove the compressed suffix of name.
+func trimCompressExt(name string) string {
+	for _, ext := range compressExts {
+		if strings.HasSuffix(name, ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+func newCompressWriter(algorithm string, w io.Writer) (io.WriteCloser, error) {
+	switch algorithm {
+	case CompressGzip:
+		return gzip.NewWriter(w), nil
+	case CompressZstd:
+		return zstd.NewWriter(w)
+	}
+	return nil, fmt.Errorf("unknown compress algorithm: %s", algorithm)
+}
+
+// compressFile compress fpath to fpath with the compressed suffix and remove fpath.
+func compressFile(algorithm, fpath string) (dst string, err error) {
+	dst = fpath + compressExts[algorithm]
+	tmp := dst + ".tmp"
+	src, err := os.Open(fpath)
+	if err != nil {
+		return
+	}
+	defer src.Close()
+	fp, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			fp.Close()
+			os.Remove(tmp)
+		}
+	}()
+	w, err := newCompressWriter(algorithm, fp)
+	if err != nil {
+		return
+	}
+	if _, err = io.Copy(w, src); err != nil {
+		w.Close()
+		return
+	}
+	if err = w.Close(); err != nil {
+		return
+	}
+	if err = fp.Close(); err != nil {
+		return
+	}
+	if err = os.Rename(tmp, dst); err != nil {
+		return
+	}
+	err = os.Remove(fpath)
+	return
+}
@@ -31,10 +31,22 @@ type FileWriter struct {
 	current *wrapFile
 	files   *list.List
 
+	// rotated files to be compressed and hooked in background, the pending
+	// ones are kept from removing by retain.
+	rotatedCh   chan rotatedFile
+	rotatedDone chan struct{}
+	pendingMu   sync.Mutex
+	pending     map[string]struct{}
+
 	closed int32
 	wg     sync.WaitGroup
 }
 
+type rotatedFile struct {
+	fpath string
+	hook  bool
+}
+
 type rotateItem struct {
 	rotateTime int64
 	rotateNum  int
@@ -49,9 +61,9 @@ func parseRotateItem(dir, fname, rotateFormat string) (*list.List, error) {
 
 	// parse exists log file filename
 	parse := func(s string) (rt rotateItem, err error) {
-		// remove filename and left "." error.log.2018-09-12.001 -> 2018-09-12.001
+		// remove filename, compressed suffix and left "." error.log.2018-09-12.001.gz -> 2018-09-12.001
 		rt.fname = s
-		s = strings.TrimLeft(s[len(fname):], ".")
+		s = strings.TrimLeft(trimCompressExt(s)[len(fname):], ".")
 		seqs := strings.Split(s, ".")
 		var t time.Time
 		switch len(seqs) {
@@ -71,7 +83,8 @@ func parseRotateItem(dir, fname, rotateFormat string) (*list.List, error) {
 
 	var items []rotateItem
 	for _, fi := range fis {
-		if strings.HasPrefix(fi.Name(), fname) && fi.Name() != fname {
+		// skip the temporary file of compressing
+		if strings.HasPrefix(fi.Name(), fname) && fi.Name() != fname && !strings.HasSuffix(fi.Name(), ".tmp") {
 			rt, err := parse(fi.Name())
 			if err != nil {
 				// TODO deal with error
@@ -181,8 +194,23 @@ func New(fpath string, fns ...Option) (*FileWriter, error) {
 
 		files:   files,
 		current: current,
+
+		rotatedCh:   make(chan rotatedFile, 128),
+		rotatedDone: make(chan struct{}),
+		pending:     make(map[string]struct{}),
 	}
 
+	if opt.Compress != "" {
+		// compress the files rotated but not compressed before exit.
+		for e := files.Back(); e != nil; e = e.Prev() {
+			name := e.Value.(rotateItem).fname
+			if trimCompressExt(name) == name && !fw.sendRotated(rotatedFile{fpath: filepath.Join(dir, name)}) {
+				break
+			}
+		}
+	}
+
+	go fw.rotatedproc()
 	fw.wg.Add(1)
 	go fw.daemon()
 
@@ -269,9 +297,123 @@ func (f *FileWriter) Close() error {
 	atomic.StoreInt32(&f.closed, 1)
 	close(f.ch)
 	f.wg.Wait()
+	close(f.rotatedCh)
+	<-f.rotatedDone
 	return nil
 }
 
+// sendRotated queues the rotated file without blocking, it reports whether
+// the file is queued.
+func (f *FileWriter) sendRotated(rf rotatedFile) bool {
+	name := filepath.Base(rf.fpath)
+	f.pendingMu.Lock()
+	f.pending[name] = struct{}{}
+	f.pendingMu.Unlock()
+	select {
+	case f.rotatedCh <- rf:
+		return true
+	default:
+		f.donePending(name)
+		return false
+	}
+}
+
+func (f *FileWriter) donePending(name string) {
+	f.pendingMu.Lock()
+	delete(f.pending, name)
+	f.pendingMu.Unlock()
+}
+
+func (f *FileWriter) isPending(name string) bool {
+	f.pendingMu.Lock()
+	_, ok := f.pending[trimCompressExt(name)]
+	f.pendingMu.Unlock()
+	return ok
+}
+
+// rotatedproc compress the rotated files and invoke the rotate hook.
+func (f *FileWriter) rotatedproc() {
+	defer close(f.rotatedDone)
+	for rf := range f.rotatedCh {
+		fpath := rf.fpath
+		if f.opt.Compress != "" {
+			dst, err := compressFile(f.opt.Compress, fpath)
+			if err != nil {
+				f.stdlog.Printf("compress file %s error: %s", fpath, err)
+			} else {
+				fpath = dst
+			}
+		}
+		if rf.hook && f.opt.RotateHook != nil {
+			f.opt.RotateHook(fpath)
+		}
+		f.donePending(filepath.Base(rf.fpath))
+	}
+}
+
+// retain remove the rotated files expired or exceeding the total size.
+func (f *FileWriter) retain(now time.Time) {
+	if f.opt.MaxAge == 0 && f.opt.MaxTotalSize == 0 {
+		return
+	}
+	fis, err := ioutil.ReadDir(f.dir)
+	if err != nil {
+		f.stdlog.Printf("read dir %s error: %s", f.dir, err)
+		return
+	}
+	var (
+		total   int64
+		rotated []os.FileInfo
+	)
+	if f.current != nil {
+		total = f.current.size()
+	}
+	for _, fi := range fis {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasPrefix(name, f.fname+".") || strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		rotated = append(rotated, fi)
+		total += fi.Size()
+	}
+	sort.Slice(rotated, func(i, j int) bool { return rotated[i].ModTime().Before(rotated[j].ModTime()) })
+	for _, fi := range rotated {
+		expired := f.opt.MaxAge > 0 && now.Sub(fi.ModTime()) > f.opt.MaxAge
+		exceeded := f.opt.MaxTotalSize > 0 && total > f.opt.MaxTotalSize
+		if !expired && !exceeded {
+			break
+		}
+		// the file being compressed or hooked is removed by the next check.
+		if f.isPending(fi.Name()) {
+			continue
+		}
+		fpath := filepath.Join(f.dir, fi.Name())
+		if err := os.Remove(fpath); err != nil {
+			f.stdlog.Printf("remove file %s error: %s", fpath, err)
+			continue
+		}
+		total -= fi.Size()
+		name := trimCompressExt(fi.Name())
+		for e := f.files.Front(); e != nil; e = e.Next() {
+			if trimCompressExt(e.Value.(rotateItem).fname) == name {
+				f.files.Remove(e)
+				break
+			}
+		}
+	}
+}
+
+// removeRotated remove the rotated file name whether it's compressed or not.
+func (f *FileWriter) removeRotated(name string) {
+	name = trimCompressExt(name)
+	for _, fname := range []string{name, name + compressExts[CompressGzip], name + compressExts[CompressZstd]} {
+		fpath := filepath.Join(f.dir, fname)
+		if err := os.Remove(fpath); err != nil && !os.IsNotExist(err) {
+			f.stdlog.Printf("remove file %s error: %s", fpath, err)
+		}
+	}
+}
+
 func (f *FileWriter) checkRotate(t time.Time) {
 	formatFname := func(format string, num int) string {
 		if num == 0 {
@@ -282,14 +424,12 @@ func (f *FileWriter) checkRotate(t time.Time) {
 	format := t.Format(f.opt.RotateFormat)
 
 	if f.opt.MaxFile != 0 {
-		for f.files.Len() > f.opt.MaxFile {
+		for f.files.Len() > f.opt.MaxFile && !f.isPending(f.files.Front().Value.(rotateItem).fname) {
 			rt := f.files.Remove(f.files.Front()).(rotateItem)
-			fpath := filepath.Join(f.dir, rt.fname)
-			if err := os.Remove(fpath); err != nil {
-				f.stdlog.Printf("remove file %s error: %s", fpath, err)
-			}
+			f.removeRotated(rt.fname)
 		}
 	}
+	f.retain(t)
 
 	if format != f.lastRotateFormat || (f.opt.MaxSize != 0 && f.current.size() > f.opt.MaxSize) {
 		var err error
@@ -308,6 +448,9 @@ func (f *FileWriter) checkRotate(t time.Time) {
 		}
 
 		f.files.PushBack(rotateItem{fname: fname /*rotateNum: f.lastSplitNum, rotateTime: t.Unix() unnecessary*/})
+		if !f.sendRotated(rotatedFile{fpath: newpath, hook: true}) {
+			f.stdlog.Printf("rotated queue is full, file %s is not compressed or hooked", newpath)
+		}
 
 		if format != f.lastRotateFormat {
 			f.lastRotateFormat = format
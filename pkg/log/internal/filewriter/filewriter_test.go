@@ -1,13 +1,19 @@
 package filewriter
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -192,6 +198,124 @@ func TestMaxFile2(t *testing.T) {
 	assert.True(t, len(fis) == 4, fmt.Sprintf("expect 4 file get %d", len(fis)))
 }
 
+func writeRotate(t *testing.T, fw *FileWriter, n int) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 64)
+	for i := 0; i < n; i++ {
+		if _, err := fw.Write(data); err != nil {
+			t.Error(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestCompress(t *testing.T) {
+	for _, algorithm := range []string{CompressGzip, CompressZstd} {
+		t.Run(algorithm, func(t *testing.T) {
+			dir := filepath.Join(logdir, "test-compress-"+algorithm)
+			os.RemoveAll(dir)
+			var (
+				mu     sync.Mutex
+				hooked []string
+			)
+			fw, err := New(dir+"/info.log",
+				MaxSize(512),
+				Compress(algorithm),
+				RotateHook(func(fpath string) {
+					mu.Lock()
+					hooked = append(hooked, fpath)
+					mu.Unlock()
+				}),
+				func(opt *option) { opt.RotateInterval = 5 * time.Millisecond },
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeRotate(t, fw, 3)
+			fw.Close()
+
+			fis, err := ioutil.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var compressed []string
+			for _, fi := range fis {
+				if fi.Name() == "info.log" {
+					continue
+				}
+				assert.True(t, strings.HasSuffix(fi.Name(), compressExts[algorithm]), fi.Name())
+				compressed = append(compressed, filepath.Join(dir, fi.Name()))
+			}
+			assert.NotEmpty(t, compressed)
+			mu.Lock()
+			assert.ElementsMatch(t, compressed, hooked)
+			mu.Unlock()
+
+			fp, err := os.Open(compressed[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer fp.Close()
+			var r io.Reader
+			if algorithm == CompressGzip {
+				r, err = gzip.NewReader(fp)
+			} else {
+				r, err = zstd.NewReader(fp)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ioutil.ReadAll(r)
+			assert.NoError(t, err)
+			assert.True(t, bytes.HasPrefix(b, []byte("0123456789abcdef")))
+		})
+	}
+}
+
+func TestRetain(t *testing.T) {
+	dir := filepath.Join(logdir, "test-retain")
+	os.RemoveAll(dir)
+	old := time.Now().Add(-48 * time.Hour)
+	for _, name := range []string{"info.log.2018-12-01", "info.log.2018-12-02.gz"} {
+		touch(dir, name)
+		os.Chtimes(filepath.Join(dir, name), old, old)
+	}
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "info.log.2018-12-03"), make([]byte, 4096), 0644))
+	fw, err := New(dir+"/info.log",
+		MaxAge(24*time.Hour),
+		MaxTotalSize(1024),
+		func(opt *option) { opt.RotateInterval = 5 * time.Millisecond },
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	fw.Close()
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, fis, 1)
+	assert.Equal(t, "info.log", fis[0].Name())
+	assert.Equal(t, 0, fw.files.Len())
+}
+
+func TestParseRotateCompressed(t *testing.T) {
+	dir := filepath.Join(logdir, "test-parse-compressed")
+	os.RemoveAll(dir)
+	hour := time.Now().Format(RotateHourly)
+	for _, name := range []string{"info.log." + hour + ".gz", "info.log." + hour + ".001.zst", "info.log." + hour + ".002.zst.tmp"} {
+		touch(dir, name)
+	}
+	l, err := parseRotateItem(dir, "info.log", RotateHourly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, l.Len())
+	rt := l.Front().Value.(rotateItem)
+	assert.Equal(t, 1, rt.rotateNum)
+	assert.Equal(t, "info.log."+hour+".001.zst", rt.fname)
+}
+
 func TestFileWriter(t *testing.T) {
 	fw, err := New("testlog/info.log")
 	if err != nil {
@@ -219,3 +343,50 @@ func BenchmarkFileWriter(b *testing.B) {
 		}
 	}
 }
+
+func TestRetainPending(t *testing.T) {
+	dir := filepath.Join(logdir, "test-retain-pending")
+	os.RemoveAll(dir)
+	touch(dir, "info.log.2018-12-01")
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(filepath.Join(dir, "info.log.2018-12-01"), old, old)
+	fw, err := New(dir+"/info.log",
+		MaxAge(24*time.Hour),
+		func(opt *option) { opt.RotateInterval = time.Hour },
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fw.Close()
+
+	fw.pending["info.log.2018-12-01"] = struct{}{}
+	fw.retain(time.Now())
+	_, err = os.Stat(filepath.Join(dir, "info.log.2018-12-01"))
+	assert.NoError(t, err)
+
+	fw.donePending("info.log.2018-12-01")
+	fw.retain(time.Now())
+	_, err = os.Stat(filepath.Join(dir, "info.log.2018-12-01"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRotatedQueueFull(t *testing.T) {
+	dir := filepath.Join(logdir, "test-rotated-full")
+	os.RemoveAll(dir)
+	block := make(chan struct{})
+	fw, err := New(dir+"/info.log",
+		RotateHook(func(string) { <-block }),
+		func(opt *option) { opt.RotateInterval = time.Hour },
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dropped bool
+	for i := 0; i < cap(fw.rotatedCh)+2 && !dropped; i++ {
+		dropped = !fw.sendRotated(rotatedFile{fpath: filepath.Join(dir, fmt.Sprintf("info.log.%d", i)), hook: true})
+	}
+	assert.True(t, dropped)
+	close(block)
+	fw.Close()
+	assert.Empty(t, fw.pending)
+}
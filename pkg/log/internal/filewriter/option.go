@@ -8,7 +8,14 @@ import (
 
 // RotateFormat
 const (
-	RotateDaily = "2006-01-02"
+	RotateDaily  = "2006-01-02"
+	RotateHourly = "2006-01-02-15"
+)
+
+// Compress algorithm of rotated files.
+const (
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
 )
 
 var defaultOption = option{
@@ -27,6 +34,11 @@ type option struct {
 	// TODO export Option
 	RotateInterval time.Duration
 	WriteTimeout   time.Duration
+
+	Compress     string
+	MaxAge       time.Duration
+	MaxTotalSize int64
+	RotateHook   func(fpath string)
 }
 
 // Option filewriter option
@@ -67,3 +79,44 @@ func ChanSize(n int) Option {
 		opt.ChanSize = n
 	}
 }
+
+// IsCompressAlgorithm reports whether algorithm is supported by Compress.
+func IsCompressAlgorithm(algorithm string) bool {
+	_, ok := compressExts[algorithm]
+	return ok
+}
+
+// Compress compress rotated files with gzip or zstd in background,
+// the compressed file is named with suffix .gz or .zst.
+// NOTE: unknown algorithm will cause panic.
+func Compress(algorithm string) Option {
+	if !IsCompressAlgorithm(algorithm) {
+		panic(fmt.Sprintf("unknown compress algorithm: %s", algorithm))
+	}
+	return func(opt *option) {
+		opt.Compress = algorithm
+	}
+}
+
+// MaxAge remove rotated files modified before MaxAge, 0 meaning unlimit.
+func MaxAge(d time.Duration) Option {
+	return func(opt *option) {
+		opt.MaxAge = d
+	}
+}
+
+// MaxTotalSize remove the oldest rotated files when the total size of log files
+// exceeds n, 0 meaning unlimit.
+func MaxTotalSize(n int64) Option {
+	return func(opt *option) {
+		opt.MaxTotalSize = n
+	}
+}
+
+// RotateHook set fn invoked with the path of rotated file in background after
+// each rotation, the path is the compressed file if Compress is set.
+func RotateHook(fn func(fpath string)) Option {
+	return func(opt *option) {
+		opt.RotateHook = fn
+	}
+}
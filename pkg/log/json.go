@@ -0,0 +1,70 @@
+package log
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/go-kratos/kratos/pkg/log/internal/core"
+)
+
+// JSONHandler json lines log handler, every log is a json object in one line
+// and the fields of D are encoded by their types.
+type JSONHandler struct {
+	mu  sync.Mutex
+	out io.Writer
+	enc core.Encoder
+}
+
+// NewJSON create a json log handler writing to out.
+func NewJSON(out io.Writer) *JSONHandler {
+	return &JSONHandler{out: out, enc: newJSONEncoder()}
+}
+
+func newJSONEncoder() core.Encoder {
+	return core.NewJSONEncoder(core.EncoderConfig{
+		EncodeTime:     core.EpochTimeEncoder,
+		EncodeDuration: core.SecondsDurationEncoder,
+	}, core.GetPool())
+}
+
+// Log write a json line of args and trace_id, span_id and other fields from context.
+func (h *JSONHandler) Log(ctx context.Context, lv Level, args ...D) {
+	buf := core.GetPool()
+	defer buf.Free()
+	if err := encodeJSON(h.enc, buf, ctx, args); err != nil {
+		return
+	}
+	h.mu.Lock()
+	h.out.Write(buf.Bytes())
+	h.mu.Unlock()
+}
+
+// encodeJSON encode args and the fields from context as a json line into buf.
+func encodeJSON(enc core.Encoder, buf *core.Buffer, ctx context.Context, args []D) error {
+	d := make([]D, 0, len(args)+12)
+	d = append(d, args...)
+	if ti, ok := traceFromContext(ctx); ok {
+		d = append(d, KVString(_traceID, ti.traceID))
+		if ti.spanID != "" {
+			d = append(d, KVString(_spanID, ti.spanID))
+		}
+	}
+	d = commonFields(ctx, d)
+	return enc.Encode(buf, d...)
+}
+
+// Close json handler, the writer is closed if it's an io.Closer except stdout and stderr.
+func (h *JSONHandler) Close() error {
+	if h.out == os.Stdout || h.out == os.Stderr {
+		return nil
+	}
+	if c, ok := h.out.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// SetFormat json handler ignores the format.
+func (h *JSONHandler) SetFormat(string) {}
@@ -0,0 +1,70 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/net/metadata"
+	"github.com/go-kratos/kratos/pkg/net/trace"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type nopReport struct{}
+
+func (nopReport) WriteSpan(*trace.Span) error { return nil }
+func (nopReport) Close() error                { return nil }
+
+func TestJSONHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	hs := newHandlers([]string{"password"}, NewJSON(buf))
+
+	tracer := trace.NewTracer("log_test", nopReport{}, true)
+	sp := tracer.New("op").(*trace.Span)
+	ctx := trace.NewContext(context.Background(), sp)
+	ctx = metadata.NewContext(ctx, metadata.MD{metadata.Caller: "caller_app"})
+	hs.Log(ctx, _infoLevel,
+		KVString(_log, "hello \"world\""),
+		KVInt("int", 1),
+		KVFloat64("float", 1.5),
+		KVDuration("duration", 1500*time.Millisecond),
+		KV("bool", true),
+		KV("struct", struct{ A int }{A: 1}),
+		KVString("password", "123456"),
+	)
+	hs.Log(context.Background(), _warnLevel, KVString(_log, "second"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	m := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(lines[0]), &m); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "hello \"world\"", m[_log])
+	assert.Equal(t, float64(1), m["int"])
+	assert.Equal(t, 1.5, m["float"])
+	assert.Equal(t, 1.5, m["duration"])
+	assert.Equal(t, true, m["bool"])
+	assert.Equal(t, map[string]interface{}{"A": float64(1)}, m["struct"])
+	assert.Equal(t, "***", m["password"])
+	assert.Equal(t, "INFO", m[_level])
+	assert.Equal(t, float64(_infoLevel), m[_levelValue])
+	assert.Equal(t, "caller_app", m[_caller])
+	assert.NotEmpty(t, m[_source])
+	assert.NotEmpty(t, m[_time])
+	sc := sp.Context()
+	assert.Equal(t, sp.TraceID()[:strings.Index(sp.TraceID(), ":")], m[_traceID])
+	assert.NotEmpty(t, m[_spanID])
+	assert.NotZero(t, sc.SpanID)
+
+	m = make(map[string]interface{})
+	if err := json.Unmarshal([]byte(lines[1]), &m); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "WARN", m[_level])
+	assert.NotContains(t, m, _traceID)
+}
@@ -7,9 +7,12 @@ import (
 	"io"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/go-kratos/kratos/pkg/conf/env"
+	"github.com/go-kratos/kratos/pkg/log/internal/filewriter"
 	"github.com/go-kratos/kratos/pkg/stat/metric"
+	xtime "github.com/go-kratos/kratos/pkg/time"
 )
 
 // Config log config.
@@ -28,7 +31,19 @@ type Config struct {
 	MaxLogFile int
 	// RotateSize
 	RotateSize int64
-
+	// RotateHourly rotate log files every hour instead of every day.
+	RotateHourly bool
+	// Compress rotated files in background: gzip or zstd, empty means no compression.
+	Compress string
+	// MaxAge remove rotated files older than MaxAge, 0 means unlimited.
+	MaxAge xtime.Duration
+	// MaxTotalSize remove the oldest rotated files when the files of a level exceed MaxTotalSize, 0 means unlimited.
+	MaxTotalSize int64
+	// RotateHook invoked with the path of rotated file after each rotation.
+	RotateHook func(fpath string)
+
+	// Level the lowest level to log: debug, info, warn, error or fatal, empty means debug.
+	Level string
 	// V Enable V-leveled logging at the specified level.
 	V int32
 	// Module=""
@@ -42,11 +57,30 @@ type Config struct {
 	Module map[string]int32
 	// Filter tell log handler which field are sensitive message, use * instead.
 	Filter []string
+
+	// JSON output json lines on stdout instead of the pattern.
+	JSON bool
+	// SampleFirst and SampleThereafter sample logs of the same message per second,
+	// log the first SampleFirst and every SampleThereafter after that, 0 means no sampling.
+	SampleFirst      int
+	SampleThereafter int
+	// AsyncBuffer write logs in background with a ring buffer of AsyncBuffer logs,
+	// the oldest log is dropped if the buffer is full, 0 means writing synchronously.
+	AsyncBuffer int
+
+	// Syslog ship logs to a syslog server if not nil.
+	Syslog *SyslogConfig
+	// Loki push logs to loki if not nil.
+	Loki *LokiConfig
+	// Ship ship json lines to a tcp or http sink if not nil.
+	Ship *ShipConfig
 }
 
 // metricErrCount prometheus error counter.
 var (
-	metricErrCount = metric.NewBusinessMetricCount("log_error_total", "source")
+	metricErrCount     = metric.NewBusinessMetricCount("log_error_total", "source")
+	metricSampledCount = metric.NewBusinessMetricCount("log_sampled_total", "level")
+	metricDroppedCount = metric.NewBusinessMetricCount("log_dropped_total", "level")
 )
 
 // Render render log output
@@ -129,116 +163,171 @@ func Init(conf *Config) {
 	var hs []Handler
 	// when env is dev
 	if conf.Stdout || (isNil && (env.DeployEnv == "" || env.DeployEnv == env.DeployEnvDev)) || _noagent {
-		hs = append(hs, NewStdout())
+		if conf.JSON {
+			hs = append(hs, NewJSON(os.Stderr))
+		} else {
+			hs = append(hs, NewStdout())
+		}
 	}
 	if conf.Dir != "" {
-		hs = append(hs, NewFile(conf.Dir, conf.FileBufferSize, conf.RotateSize, conf.MaxLogFile))
+		var opts []FileOption
+		if conf.RotateHourly {
+			opts = append(opts, FileRotateHourly())
+		}
+		if conf.Compress != "" {
+			if filewriter.IsCompressAlgorithm(conf.Compress) {
+				opts = append(opts, FileCompress(conf.Compress))
+			} else {
+				fmt.Fprintf(os.Stderr, "log: unknown compress algorithm %q, rotated files are not compressed\n", conf.Compress)
+			}
+		}
+		if conf.MaxAge > 0 {
+			opts = append(opts, FileMaxAge(time.Duration(conf.MaxAge)))
+		}
+		if conf.MaxTotalSize > 0 {
+			opts = append(opts, FileMaxTotalSize(conf.MaxTotalSize))
+		}
+		if conf.RotateHook != nil {
+			opts = append(opts, FileRotateHook(conf.RotateHook))
+		}
+		hs = append(hs, NewFile(conf.Dir, conf.FileBufferSize, conf.RotateSize, conf.MaxLogFile, opts...))
+	}
+	if conf.Syslog != nil {
+		if sh, err := NewSyslog(conf.Syslog); err != nil {
+			fmt.Fprintf(os.Stderr, "log: new syslog handler error(%v)\n", err)
+		} else if conf.AsyncBuffer > 0 {
+			hs = append(hs, sh)
+		} else {
+			// never dial or write the server in the caller.
+			hs = append(hs, NewAsync(sh, 0))
+		}
+	}
+	if conf.Loki != nil {
+		hs = append(hs, NewLoki(conf.Loki))
+	}
+	if conf.Ship != nil {
+		if sh, err := NewShip(conf.Ship); err != nil {
+			fmt.Fprintf(os.Stderr, "log: new ship handler error(%v)\n", err)
+		} else {
+			hs = append(hs, sh)
+		}
+	}
+	for i := range hs {
+		if conf.AsyncBuffer > 0 {
+			hs[i] = NewAsync(hs[i], conf.AsyncBuffer)
+		}
+		if conf.SampleFirst > 0 {
+			hs[i] = NewSample(hs[i], conf.SampleFirst, conf.SampleThereafter)
+		}
 	}
 	h = newHandlers(conf.Filter, hs...)
 	c = conf
+	if err := SetSetting(&Setting{Level: conf.Level, V: conf.V, Module: conf.Module, Filter: conf.Filter}); err != nil {
+		fmt.Fprintf(os.Stderr, "log: set setting error(%v)\n", err)
+	}
 }
 
 // Debug logs a message at the debug log level.
 func Debug(format string, args ...interface{}) {
-	if int32(_debugLevel) >= c.V {
+	if enabled(_debugLevel) {
 		h.Log(context.Background(), _debugLevel, KVString(_log, fmt.Sprintf(format, args...)))
 	}
 }
 
 // Info logs a message at the info log level.
 func Info(format string, args ...interface{}) {
-	if int32(_infoLevel) >= c.V {
+	if enabled(_infoLevel) {
 		h.Log(context.Background(), _infoLevel, KVString(_log, fmt.Sprintf(format, args...)))
 	}
 }
 
 // Warn logs a message at the warning log level.
 func Warn(format string, args ...interface{}) {
-	if int32(_warnLevel) >= c.V {
+	if enabled(_warnLevel) {
 		h.Log(context.Background(), _warnLevel, KVString(_log, fmt.Sprintf(format, args...)))
 	}
 }
 
 // Error logs a message at the error log level.
 func Error(format string, args ...interface{}) {
-	if int32(_errorLevel) >= c.V {
+	if enabled(_errorLevel) {
 		h.Log(context.Background(), _errorLevel, KVString(_log, fmt.Sprintf(format, args...)))
 	}
 }
 
 // Fatal logs a message at the fatal log level.
 func Fatal(format string, args ...interface{}) {
-	if int32(_fatalLevel) >= c.V {
+	if enabled(_fatalLevel) {
 		h.Log(context.Background(), _fatalLevel, KVString(_log, fmt.Sprintf(format, args...)))
 	}
 }
 
 // Debugc logs a message at the debug log level.
 func Debugc(ctx context.Context, format string, args ...interface{}) {
-	if int32(_debugLevel) >= c.V {
+	if enabled(_debugLevel) {
 		h.Log(ctx, _debugLevel, KVString(_log, fmt.Sprintf(format, args...)))
 	}
 }
 
 // Infoc logs a message at the info log level.
 func Infoc(ctx context.Context, format string, args ...interface{}) {
-	if int32(_infoLevel) >= c.V {
+	if enabled(_infoLevel) {
 		h.Log(ctx, _infoLevel, KVString(_log, fmt.Sprintf(format, args...)))
 	}
 }
 
 // Errorc logs a message at the error log level.
 func Errorc(ctx context.Context, format string, args ...interface{}) {
-	if int32(_errorLevel) >= c.V {
+	if enabled(_errorLevel) {
 		h.Log(ctx, _errorLevel, KVString(_log, fmt.Sprintf(format, args...)))
 	}
 }
 
 // Warnc logs a message at the warning log level.
 func Warnc(ctx context.Context, format string, args ...interface{}) {
-	if int32(_warnLevel) >= c.V {
+	if enabled(_warnLevel) {
 		h.Log(ctx, _warnLevel, KVString(_log, fmt.Sprintf(format, args...)))
 	}
 }
 
 // Fatalc logs a message at the fatal log level.
 func Fatalc(ctx context.Context, format string, args ...interface{}) {
-	if int32(_fatalLevel) >= c.V {
+	if enabled(_fatalLevel) {
 		h.Log(ctx, _fatalLevel, KVString(_log, fmt.Sprintf(format, args...)))
 	}
 }
 
 // Debugv logs a message at the debug log level.
 func Debugv(ctx context.Context, args ...D) {
-	if int32(_debugLevel) >= c.V {
+	if enabled(_debugLevel) {
 		h.Log(ctx, _debugLevel, args...)
 	}
 }
 
 // Infov logs a message at the info log level.
 func Infov(ctx context.Context, args ...D) {
-	if int32(_infoLevel) >= c.V {
+	if enabled(_infoLevel) {
 		h.Log(ctx, _infoLevel, args...)
 	}
 }
 
 // Warnv logs a message at the warning log level.
 func Warnv(ctx context.Context, args ...D) {
-	if int32(_warnLevel) >= c.V {
+	if enabled(_warnLevel) {
 		h.Log(ctx, _warnLevel, args...)
 	}
 }
 
 // Errorv logs a message at the error log level.
 func Errorv(ctx context.Context, args ...D) {
-	if int32(_errorLevel) >= c.V {
+	if enabled(_errorLevel) {
 		h.Log(ctx, _errorLevel, args...)
 	}
 }
 
 // Fatalv logs a message at the error log level.
 func Fatalv(ctx context.Context, args ...D) {
-	if int32(_fatalLevel) >= c.V {
+	if enabled(_fatalLevel) {
 		h.Log(ctx, _fatalLevel, args...)
 	}
 }
@@ -260,35 +349,35 @@ func logw(args []interface{}) []D {
 
 // Debugw logs a message with some additional context. The variadic key-value pairs are treated as they are in With.
 func Debugw(ctx context.Context, args ...interface{}) {
-	if int32(_debugLevel) >= c.V {
+	if enabled(_debugLevel) {
 		h.Log(ctx, _debugLevel, logw(args)...)
 	}
 }
 
 // Infow logs a message with some additional context. The variadic key-value pairs are treated as they are in With.
 func Infow(ctx context.Context, args ...interface{}) {
-	if int32(_infoLevel) >= c.V {
+	if enabled(_infoLevel) {
 		h.Log(ctx, _infoLevel, logw(args)...)
 	}
 }
 
 // Warnw logs a message with some additional context. The variadic key-value pairs are treated as they are in With.
 func Warnw(ctx context.Context, args ...interface{}) {
-	if int32(_warnLevel) >= c.V {
+	if enabled(_warnLevel) {
 		h.Log(ctx, _warnLevel, logw(args)...)
 	}
 }
 
 // Errorw logs a message with some additional context. The variadic key-value pairs are treated as they are in With.
 func Errorw(ctx context.Context, args ...interface{}) {
-	if int32(_errorLevel) >= c.V {
+	if enabled(_errorLevel) {
 		h.Log(ctx, _errorLevel, logw(args)...)
 	}
 }
 
 // Fatalw logs a message with some additional context. The variadic key-value pairs are treated as they are in With.
 func Fatalw(ctx context.Context, args ...interface{}) {
-	if int32(_fatalLevel) >= c.V {
+	if enabled(_fatalLevel) {
 		h.Log(ctx, _fatalLevel, logw(args)...)
 	}
 }
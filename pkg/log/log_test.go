@@ -112,3 +112,10 @@ func BenchmarkLog(b *testing.B) {
 		}
 	})
 }
+
+func TestInitUnknownCompress(t *testing.T) {
+	assert.NotPanics(t, func() {
+		Init(&Config{Dir: "/tmp", Compress: "lz4"})
+	})
+	Close()
+}
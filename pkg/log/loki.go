@@ -0,0 +1,156 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/conf/env"
+	"github.com/go-kratos/kratos/pkg/log/internal/core"
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/pkg/errors"
+)
+
+const _lokiPushPath = "/loki/api/v1/push"
+
+// LokiConfig loki handler config.
+type LokiConfig struct {
+	// Addr the loki address, such as http://127.0.0.1:3100.
+	Addr string
+	// TenantID set as X-Scope-OrgID header if not empty.
+	TenantID string
+	// Labels extra stream labels besides app, zone and level.
+	Labels map[string]string
+	// BatchSize default 1024.
+	BatchSize int
+	// QueueSize default 8192, logs are dropped when the queue is full.
+	QueueSize     int
+	FlushInterval xtime.Duration
+	// Timeout of push request, default 5s.
+	Timeout xtime.Duration
+}
+
+// LokiHandler pushes json lines to loki in batches, each batch is grouped
+// into streams by the labels app, zone and level.
+type LokiHandler struct {
+	c      *LokiConfig
+	url    string
+	labels map[string]string
+	enc    core.Encoder
+	client *http.Client
+	*batcher
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiPush struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+// NewLoki create a loki log handler.
+func NewLoki(c *LokiConfig) *LokiHandler {
+	if c.Timeout <= 0 {
+		c.Timeout = xtime.Duration(5 * time.Second)
+	}
+	labels := map[string]string{}
+	if env.AppID != "" {
+		labels["app"] = env.AppID
+	}
+	if env.Zone != "" {
+		labels["zone"] = env.Zone
+	}
+	for k, v := range c.Labels {
+		labels[k] = v
+	}
+	h := &LokiHandler{
+		c:      c,
+		url:    strings.TrimRight(c.Addr, "/") + _lokiPushPath,
+		labels: labels,
+		enc:    newJSONEncoder(),
+		client: &http.Client{Timeout: time.Duration(c.Timeout)},
+	}
+	h.batcher = newBatcher(c.BatchSize, c.QueueSize, time.Duration(c.FlushInterval), 0, h.flush)
+	return h
+}
+
+// Log put a json line of args into the batch.
+func (h *LokiHandler) Log(ctx context.Context, lv Level, args ...D) {
+	buf := core.GetPool()
+	defer buf.Free()
+	if err := encodeJSON(h.enc, buf, ctx, args); err != nil {
+		return
+	}
+	buf.TrimNewline()
+	h.push(record{ts: time.Now(), lv: lv, line: append([]byte(nil), buf.Bytes()...)})
+}
+
+func (h *LokiHandler) flush(rs []record) {
+	if len(rs) == 0 {
+		return
+	}
+	streams := make(map[Level]*lokiStream)
+	req := new(lokiPush)
+	for _, r := range rs {
+		s, ok := streams[r.lv]
+		if !ok {
+			labels := make(map[string]string, len(h.labels)+1)
+			for k, v := range h.labels {
+				labels[k] = v
+			}
+			labels["level"] = strings.ToLower(r.lv.String())
+			s = &lokiStream{Stream: labels}
+			streams[r.lv] = s
+			req.Streams = append(req.Streams, s)
+		}
+		s.Values = append(s.Values, [2]string{strconv.FormatInt(r.ts.UnixNano(), 10), string(r.line)})
+	}
+	sort.Slice(req.Streams, func(i, j int) bool { return req.Streams[i].Stream["level"] < req.Streams[j].Stream["level"] })
+	if err := h.send(req); err != nil {
+		h.drop(rs...)
+	}
+}
+
+func (h *LokiHandler) send(req *lokiPush) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	hr, err := http.NewRequest(http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	hr.Header.Set("Content-Type", "application/json")
+	if h.c.TenantID != "" {
+		hr.Header.Set("X-Scope-OrgID", h.c.TenantID)
+	}
+	resp, err := h.client.Do(hr)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("log: loki push %s status %d", h.url, resp.StatusCode)
+	}
+	return nil
+}
+
+// SetFormat is not supported by loki handler.
+func (h *LokiHandler) SetFormat(string) {}
+
+// Close flush the rest logs to loki.
+func (h *LokiHandler) Close() error {
+	h.close(time.Duration(h.c.Timeout) * 2)
+	return nil
+}
@@ -0,0 +1,92 @@
+package log
+
+import (
+	"context"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+)
+
+const _sampleSlots = 4096
+
+// SampleHandler samples logs by message, in every tick it logs the first N
+// logs of the same level and message, and every Mth log thereafter.
+type SampleHandler struct {
+	Handler
+	first      uint64
+	thereafter uint64
+	tick       int64
+	counters   [_sampleSlots]sampleCounter
+	now        func() time.Time
+}
+
+type sampleCounter struct {
+	resetAt int64
+	count   uint64
+}
+
+// NewSample create a sample log handler of h, it logs the first logs of the
+// same message per second, and every thereafter log after that.
+// thereafter 0 means drop all logs after the first.
+func NewSample(h Handler, first, thereafter int) *SampleHandler {
+	return &SampleHandler{
+		Handler:    h,
+		first:      uint64(first),
+		thereafter: uint64(thereafter),
+		tick:       int64(time.Second),
+		now:        time.Now,
+	}
+}
+
+// Log sample and logging.
+func (h *SampleHandler) Log(ctx context.Context, lv Level, args ...D) {
+	if !h.sampled(lv, sampleKey(args)) {
+		metricSampledCount.Inc(lv.String())
+		return
+	}
+	h.Handler.Log(ctx, lv, args...)
+}
+
+func (h *SampleHandler) sampled(lv Level, msg string) bool {
+	hash := fnv.New32a()
+	hash.Write([]byte{byte(lv)})
+	hash.Write([]byte(msg))
+	counter := &h.counters[hash.Sum32()%_sampleSlots]
+
+	now := h.now().UnixNano()
+	resetAt := atomic.LoadInt64(&counter.resetAt)
+	var n uint64
+	if resetAt > now {
+		n = atomic.AddUint64(&counter.count, 1)
+	} else {
+		atomic.StoreUint64(&counter.count, 1)
+		atomic.StoreInt64(&counter.resetAt, now+h.tick)
+		n = 1
+	}
+	if n <= h.first {
+		return true
+	}
+	return h.thereafter > 0 && (n-h.first)%h.thereafter == 0
+}
+
+// sampleKey returns the log message of args, or the source if no message.
+func sampleKey(args []D) string {
+	var source string
+	for _, arg := range args {
+		switch arg.Key {
+		case _log:
+			return stringValue(arg)
+		case _source:
+			source = stringValue(arg)
+		}
+	}
+	return source
+}
+
+func stringValue(d D) string {
+	if d.StringVal != "" {
+		return d.StringVal
+	}
+	s, _ := d.Value.(string)
+	return s
+}
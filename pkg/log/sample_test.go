@@ -0,0 +1,59 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countHandler struct {
+	mu   sync.Mutex
+	logs []string
+}
+
+func (h *countHandler) Log(ctx context.Context, lv Level, args ...D) {
+	h.mu.Lock()
+	h.logs = append(h.logs, sampleKey(args))
+	h.mu.Unlock()
+}
+func (h *countHandler) SetFormat(string) {}
+func (h *countHandler) Close() error     { return nil }
+
+func (h *countHandler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.logs)
+}
+
+func TestSampleHandler(t *testing.T) {
+	ch := &countHandler{}
+	h := NewSample(ch, 3, 10)
+	now := time.Unix(1000, 0)
+	h.now = func() time.Time { return now }
+
+	for i := 0; i < 100; i++ {
+		h.Log(context.Background(), _infoLevel, KVString(_log, "hot"))
+	}
+	// the first 3 and the 13th, 23th ... 93th
+	assert.Equal(t, 3+9, ch.count())
+	h.Log(context.Background(), _infoLevel, KVString(_log, "cold"))
+	h.Log(context.Background(), _errorLevel, KVString(_log, "hot"))
+	assert.Equal(t, 14, ch.count())
+
+	now = now.Add(time.Second)
+	ch.logs = nil
+	for i := 0; i < 5; i++ {
+		h.Log(context.Background(), _infoLevel, KVString(_log, "hot"))
+	}
+	assert.Equal(t, 3, ch.count())
+
+	h = NewSample(ch, 1, 0)
+	ch.logs = nil
+	for i := 0; i < 5; i++ {
+		h.Log(context.Background(), _infoLevel, KVString(_source, "a.go:1"))
+	}
+	assert.Equal(t, 1, ch.count())
+}
@@ -0,0 +1,237 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+)
+
+// Setting the log level, verbosity and filter which can be changed at runtime.
+type Setting struct {
+	// Level the lowest level to log: debug, info, warn, error or fatal, empty means debug.
+	Level string `json:"level" toml:"level"`
+	// V see Config.V.
+	V int32 `json:"v" toml:"v"`
+	// Module see Config.Module.
+	Module map[string]int32 `json:"module" toml:"module"`
+	// Filter see Config.Filter.
+	Filter []string `json:"filter" toml:"filter"`
+	// TTL revert to the last setting without TTL after TTL, 0 means never.
+	TTL xtime.Duration `json:"ttl" toml:"ttl"`
+}
+
+// setting the parsed Setting used by logging.
+type setting struct {
+	level  Level
+	v      int32
+	module map[string]int32
+}
+
+var (
+	_setting atomic.Value // *setting
+
+	_settingMu sync.Mutex
+	_current   *Setting
+	_base      *Setting
+	_revertAt  time.Time
+	_revert    *time.Timer
+)
+
+func init() {
+	s := &Setting{}
+	_setting.Store(&setting{})
+	_current, _base = s, s
+}
+
+func loadSetting() *setting {
+	return _setting.Load().(*setting)
+}
+
+// enabled reports whether logs of lv should be written.
+func enabled(lv Level) bool {
+	s := loadSetting()
+	return int32(lv) >= s.v && lv >= s.level
+}
+
+func parseLevel(s string) (Level, error) {
+	if s == "" {
+		return _debugLevel, nil
+	}
+	for lv, name := range levelNames {
+		if strings.EqualFold(s, name) || (Level(lv) == _warnLevel && strings.EqualFold(s, "warning")) {
+			return Level(lv), nil
+		}
+	}
+	return 0, errors.Errorf("log: unknown level %q", s)
+}
+
+func (s *Setting) clone() *Setting {
+	n := *s
+	n.Module = make(map[string]int32, len(s.Module))
+	for k, v := range s.Module {
+		n.Module[k] = v
+	}
+	n.Filter = append([]string(nil), s.Filter...)
+	return &n
+}
+
+// CurrentSetting returns the setting in use, TTL is the time left before reverting.
+func CurrentSetting() *Setting {
+	_settingMu.Lock()
+	defer _settingMu.Unlock()
+	s := _current.clone()
+	if s.TTL > 0 {
+		if s.TTL = xtime.Duration(time.Until(_revertAt)); s.TTL < 0 {
+			s.TTL = 0
+		}
+	}
+	return s
+}
+
+// SetSetting change the level, verbosity and filter of the logs, if s.TTL is
+// set, it's reverted to the last setting without TTL after s.TTL.
+func SetSetting(s *Setting) error {
+	lv, err := parseLevel(s.Level)
+	if err != nil {
+		return err
+	}
+	s = s.clone()
+	_settingMu.Lock()
+	defer _settingMu.Unlock()
+	if _revert != nil {
+		_revert.Stop()
+		_revert = nil
+	}
+	applySetting(s, lv)
+	if s.TTL <= 0 {
+		_base = s
+		return nil
+	}
+	_revertAt = time.Now().Add(time.Duration(s.TTL))
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Duration(s.TTL), func() {
+		_settingMu.Lock()
+		defer _settingMu.Unlock()
+		// a later SetSetting has stopped this timer.
+		if _revert != timer {
+			return
+		}
+		_revert = nil
+		lv, _ := parseLevel(_base.Level)
+		applySetting(_base, lv)
+	})
+	_revert = timer
+	return nil
+}
+
+// applySetting must be called with _settingMu held.
+func applySetting(s *Setting, lv Level) {
+	_current = s
+	_setting.Store(&setting{level: lv, v: s.V, module: s.Module})
+	if hs, ok := h.(*Handlers); ok {
+		hs.setFilters(s.Filter)
+	}
+}
+
+// Set implements paladin.Setter, it decodes text as toml over the last setting
+// without TTL and applies it, so that the log setting can be hot reloaded by
+// paladin.Watch("log.toml", &log.Setting{}).
+//
+// log.toml:
+//
+//	level = "info"
+//	v = 1
+//	filter = ["password"]
+//	[module]
+//	  "dao*" = 2
+func (s *Setting) Set(text string) error {
+	_settingMu.Lock()
+	n := _base.clone()
+	_settingMu.Unlock()
+	n.TTL = 0
+	if _, err := toml.Decode(text, n); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := SetSetting(n); err != nil {
+		return err
+	}
+	*s = *n
+	return nil
+}
+
+// ServeSetting serve the log setting over http, GET returns the current
+// setting as json, POST changes the setting by the form values:
+//
+//	level: debug, info, warn, error or fatal.
+//	v: the verbose level.
+//	module: the verbose level of modules, format: file=1,file2=2.
+//	filter: the sensitive fields, format: field1,field2.
+//	ttl: revert after ttl, such as 10m.
+//
+// The values absent are unchanged.
+func ServeSetting(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost, http.MethodPut:
+		if err := setSettingForm(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	s := CurrentSetting()
+	json.NewEncoder(w).Encode(struct {
+		*Setting
+		TTL string `json:"ttl"`
+	}{s, time.Duration(s.TTL).String()})
+}
+
+func setSettingForm(r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	s := CurrentSetting()
+	s.TTL = 0
+	if _, ok := r.Form["level"]; ok {
+		s.Level = r.FormValue("level")
+	}
+	if _, ok := r.Form["v"]; ok {
+		v, err := strconv.ParseInt(r.FormValue("v"), 10, 32)
+		if err != nil {
+			return errors.Errorf("log: invalid v %q", r.FormValue("v"))
+		}
+		s.V = int32(v)
+	}
+	if _, ok := r.Form["module"]; ok {
+		m := verboseModule{}
+		if value := r.FormValue("module"); value != "" {
+			m.Set(value)
+		}
+		s.Module = m
+	}
+	if _, ok := r.Form["filter"]; ok {
+		var f logFilter
+		if value := r.FormValue("filter"); value != "" {
+			f.Set(value)
+		}
+		s.Filter = f
+	}
+	if ttl := r.FormValue("ttl"); ttl != "" {
+		if err := s.TTL.UnmarshalText([]byte(ttl)); err != nil || s.TTL < 0 {
+			return errors.Errorf("log: invalid ttl %q", ttl)
+		}
+	}
+	return SetSetting(s)
+}
@@ -0,0 +1,127 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func resetSetting(t *testing.T) {
+	assert.NoError(t, SetSetting(&Setting{}))
+}
+
+func TestSetSetting(t *testing.T) {
+	defer resetSetting(t)
+
+	assert.NoError(t, SetSetting(&Setting{Level: "warn", V: 2, Module: map[string]int32{"setting_test": 3}}))
+	assert.False(t, enabled(_infoLevel))
+	assert.True(t, enabled(_warnLevel))
+	assert.True(t, bool(V(2)))
+	// module of this file.
+	assert.True(t, bool(V(3)))
+	assert.False(t, bool(V(4)))
+	assert.Error(t, SetSetting(&Setting{Level: "verbose"}))
+	assert.Equal(t, "warn", CurrentSetting().Level)
+}
+
+func TestSettingTTL(t *testing.T) {
+	defer resetSetting(t)
+
+	assert.NoError(t, SetSetting(&Setting{Level: "error"}))
+	assert.NoError(t, SetSetting(&Setting{Level: "debug", TTL: xtime.Duration(50 * time.Millisecond)}))
+	assert.True(t, enabled(_debugLevel))
+	assert.True(t, CurrentSetting().TTL > 0)
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, enabled(_warnLevel))
+	assert.Equal(t, "error", CurrentSetting().Level)
+	assert.Zero(t, CurrentSetting().TTL)
+
+	// a later setting cancels the revert.
+	assert.NoError(t, SetSetting(&Setting{Level: "debug", TTL: xtime.Duration(50 * time.Millisecond)}))
+	assert.NoError(t, SetSetting(&Setting{Level: "info"}))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, "info", CurrentSetting().Level)
+}
+
+func TestSettingSet(t *testing.T) {
+	defer resetSetting(t)
+
+	assert.NoError(t, SetSetting(&Setting{Filter: []string{"password"}}))
+	s := &Setting{}
+	assert.NoError(t, s.Set(`
+level = "info"
+v = 1
+[module]
+  "dao*" = 2
+`))
+	assert.Equal(t, "info", s.Level)
+	cur := CurrentSetting()
+	assert.Equal(t, int32(1), cur.V)
+	assert.Equal(t, map[string]int32{"dao*": 2}, cur.Module)
+	// absent keys are unchanged.
+	assert.Equal(t, []string{"password"}, cur.Filter)
+	assert.Error(t, s.Set(`level = "unknown"`))
+}
+
+func TestSettingFilter(t *testing.T) {
+	defer resetSetting(t)
+
+	var got []D
+	old := h
+	h = newHandlers(nil, handlerFunc(func(ctx context.Context, lv Level, d ...D) { got = d }))
+	defer func() { h = old }()
+	assert.NoError(t, SetSetting(&Setting{Filter: []string{"secret"}}))
+	Infov(context.Background(), KVString("secret", "123"))
+	assert.Equal(t, "***", got[0].StringVal)
+}
+
+type handlerFunc func(context.Context, Level, ...D)
+
+func (f handlerFunc) Log(ctx context.Context, lv Level, d ...D) { f(ctx, lv, d...) }
+func (f handlerFunc) SetFormat(string)                          {}
+func (f handlerFunc) Close() error                              { return nil }
+
+func TestServeSetting(t *testing.T) {
+	defer resetSetting(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(ServeSetting))
+	defer srv.Close()
+
+	resp, err := http.PostForm(srv.URL, url.Values{"level": {"debug"}, "module": {"a=1,b=2"}, "ttl": {"1m"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := new(Setting)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(s))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "debug", s.Level)
+	assert.Equal(t, map[string]int32{"a": 1, "b": 2}, s.Module)
+	assert.True(t, s.TTL > 0)
+
+	resp, err = http.PostForm(srv.URL, url.Values{"v": {"x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	s = new(Setting)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(s))
+	assert.Equal(t, "debug", s.Level)
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json"))
+}
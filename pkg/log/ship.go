@@ -0,0 +1,258 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/log/internal/core"
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	_spoolExt             = ".spool"
+	_defaultSpoolMaxSize  = 256 * 1024 * 1024
+	_defaultShipBlockTime = 100 * time.Millisecond
+)
+
+// ShipConfig shipper handler config.
+type ShipConfig struct {
+	// Network is tcp or http.
+	Network string
+	// Addr is host:port for tcp, or the url for http.
+	Addr string
+	// BatchSize default 1024.
+	BatchSize int
+	// QueueSize default 8192.
+	QueueSize     int
+	FlushInterval xtime.Duration
+	// Timeout of dial and write, default 5s.
+	Timeout xtime.Duration
+	// Block is the max time Log waits when the queue is full before dropping
+	// the log, default 100ms, negative means drop at once.
+	Block xtime.Duration
+	// SpoolDir keep the batches failed to send on disk and resend them when
+	// the sink is available again, empty means drop them.
+	SpoolDir string
+	// SpoolMaxSize the max bytes of SpoolDir, default 256MB.
+	SpoolMaxSize int64
+}
+
+// ShipHandler ships json lines in batches to a tcp or http sink, such as
+// a kafka rest proxy or a log collector.
+//
+// The tcp sink receives newline delimited json, the http sink receives a
+// POST of application/x-ndjson for each batch.
+type ShipHandler struct {
+	c      *ShipConfig
+	enc    core.Encoder
+	client *http.Client
+	conn   net.Conn
+	spool  *spool
+	*batcher
+}
+
+// NewShip create a shipper log handler.
+func NewShip(c *ShipConfig) (*ShipHandler, error) {
+	switch c.Network {
+	case "tcp", "http":
+	default:
+		return nil, errors.Errorf("log: unsupported ship network %q", c.Network)
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = xtime.Duration(5 * time.Second)
+	}
+	if c.Block == 0 {
+		c.Block = xtime.Duration(_defaultShipBlockTime)
+	}
+	h := &ShipHandler{
+		c:   c,
+		enc: newJSONEncoder(),
+	}
+	if c.Network == "http" {
+		h.client = &http.Client{Timeout: time.Duration(c.Timeout)}
+	}
+	if c.SpoolDir != "" {
+		s, err := newSpool(c.SpoolDir, c.SpoolMaxSize)
+		if err != nil {
+			return nil, err
+		}
+		h.spool = s
+	}
+	h.batcher = newBatcher(c.BatchSize, c.QueueSize, time.Duration(c.FlushInterval), time.Duration(c.Block), h.flush)
+	return h, nil
+}
+
+// Log put a json line of args into the batch.
+func (h *ShipHandler) Log(ctx context.Context, lv Level, args ...D) {
+	buf := core.GetPool()
+	defer buf.Free()
+	if err := encodeJSON(h.enc, buf, ctx, args); err != nil {
+		return
+	}
+	h.push(record{ts: time.Now(), lv: lv, line: append([]byte(nil), buf.Bytes()...)})
+}
+
+// flush send the spooled batches first to keep the order, then rs.
+func (h *ShipHandler) flush(rs []record) {
+	var err error
+	if h.spool != nil {
+		err = h.spool.replay(h.send)
+	}
+	if len(rs) == 0 {
+		return
+	}
+	var b bytes.Buffer
+	for _, r := range rs {
+		b.Write(r.line)
+	}
+	if err == nil {
+		if err = h.send(b.Bytes()); err == nil {
+			return
+		}
+	}
+	if h.spool == nil || h.spool.write(b.Bytes()) != nil {
+		h.drop(rs...)
+	}
+}
+
+func (h *ShipHandler) send(b []byte) error {
+	if h.c.Network == "http" {
+		return h.post(b)
+	}
+	if h.conn == nil {
+		conn, err := net.DialTimeout("tcp", h.c.Addr, time.Duration(h.c.Timeout))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		h.conn = conn
+	}
+	h.conn.SetWriteDeadline(time.Now().Add(time.Duration(h.c.Timeout)))
+	if _, err := h.conn.Write(b); err != nil {
+		h.conn.Close()
+		h.conn = nil
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (h *ShipHandler) post(b []byte) error {
+	resp, err := h.client.Post(h.c.Addr, "application/x-ndjson", bytes.NewReader(b))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("log: ship %s status %d", h.c.Addr, resp.StatusCode)
+	}
+	return nil
+}
+
+// SetFormat is not supported by shipper handler.
+func (h *ShipHandler) SetFormat(string) {}
+
+// Close flush the rest logs, the logs failed to send are spooled if SpoolDir is set.
+func (h *ShipHandler) Close() error {
+	h.close(time.Duration(h.c.Timeout) * 2)
+	if h.conn != nil {
+		h.conn.Close()
+	}
+	return nil
+}
+
+// spool keeps the failed batches as files in dir, one file per batch.
+// It is only used in the batcher goroutine.
+type spool struct {
+	dir     string
+	maxSize int64
+	size    int64
+	seq     uint64
+}
+
+func newSpool(dir string, maxSize int64) (*spool, error) {
+	if maxSize <= 0 {
+		maxSize = _defaultSpoolMaxSize
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s := &spool{dir: dir, maxSize: maxSize}
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if fi, err := os.Stat(f); err == nil {
+			s.size += fi.Size()
+		}
+	}
+	return s, nil
+}
+
+// files returns the spooled files from the oldest.
+func (s *spool) files() ([]string, error) {
+	fis, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var files []string
+	for _, fi := range fis {
+		if !fi.IsDir() && strings.HasSuffix(fi.Name(), _spoolExt) {
+			files = append(files, filepath.Join(s.dir, fi.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (s *spool) write(b []byte) error {
+	if s.size+int64(len(b)) > s.maxSize {
+		return errors.Errorf("log: spool %s is full", s.dir)
+	}
+	seq := atomic.AddUint64(&s.seq, 1)
+	name := filepath.Join(s.dir, fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), seq%1000000, _spoolExt))
+	if err := ioutil.WriteFile(name, b, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	s.size += int64(len(b))
+	return nil
+}
+
+// replay send the spooled files in order, stop at the first error.
+func (s *spool) replay(send func([]byte) error) error {
+	if s.size == 0 {
+		return nil
+	}
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err = send(b); err != nil {
+			return err
+		}
+		os.Remove(f)
+		if s.size -= int64(len(b)); s.size < 0 {
+			s.size = 0
+		}
+	}
+	s.size = 0
+	return nil
+}
@@ -0,0 +1,167 @@
+package log
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLokiHandler(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		reqs []lokiPush
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, _lokiPushPath, r.URL.Path)
+		assert.Equal(t, "tenant", r.Header.Get("X-Scope-OrgID"))
+		var req lokiPush
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		reqs = append(reqs, req)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	h := NewLoki(&LokiConfig{Addr: srv.URL, TenantID: "tenant", Labels: map[string]string{"job": "test"}, BatchSize: 3})
+	h.Log(context.Background(), _infoLevel, KVString(_log, "info1"))
+	h.Log(context.Background(), _errorLevel, KVString(_log, "error1"))
+	h.Log(context.Background(), _infoLevel, KVString(_log, "info2"))
+	h.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !assert.Len(t, reqs, 1) {
+		return
+	}
+	streams := reqs[0].Streams
+	assert.Len(t, streams, 2)
+	assert.Equal(t, "error", streams[0].Stream["level"])
+	assert.Equal(t, "test", streams[0].Stream["job"])
+	assert.Len(t, streams[0].Values, 1)
+	assert.Equal(t, "info", streams[1].Stream["level"])
+	assert.Len(t, streams[1].Values, 2)
+	assert.Contains(t, streams[1].Values[1][1], `"log":"info2"`)
+}
+
+func TestShipTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	lines := make(chan string, 10)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s := bufio.NewScanner(conn)
+		for s.Scan() {
+			lines <- s.Text()
+		}
+	}()
+	h, err := NewShip(&ShipConfig{Network: "tcp", Addr: l.Addr().String(), BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.Log(context.Background(), _infoLevel, KVString(_log, "first"))
+	h.Log(context.Background(), _infoLevel, KVString(_log, "second"))
+	for _, want := range []string{"first", "second"} {
+		select {
+		case line := <-lines:
+			m := make(map[string]interface{})
+			assert.NoError(t, json.Unmarshal([]byte(line), &m))
+			assert.Equal(t, want, m[_log])
+		case <-time.After(time.Second):
+			t.Fatal("ship line timeout")
+		}
+	}
+}
+
+func TestShipSpool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log_spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		files, _ := ioutil.ReadDir(dir)
+		assert.Empty(t, files)
+		os.RemoveAll(dir)
+	}()
+
+	var (
+		mu    sync.Mutex
+		up    bool
+		lines []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !up {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		b, _ := ioutil.ReadAll(r.Body)
+		lines = append(lines, strings.Split(strings.TrimSpace(string(b)), "\n")...)
+	}))
+	defer srv.Close()
+
+	h, err := NewShip(&ShipConfig{
+		Network:       "http",
+		Addr:          srv.URL,
+		BatchSize:     1,
+		FlushInterval: xtime.Duration(10 * time.Millisecond),
+		SpoolDir:      dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Log(context.Background(), _infoLevel, KVString(_log, "first"))
+	h.Log(context.Background(), _infoLevel, KVString(_log, "second"))
+	time.Sleep(50 * time.Millisecond)
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(t, files, 2)
+
+	mu.Lock()
+	up = true
+	mu.Unlock()
+	h.Log(context.Background(), _infoLevel, KVString(_log, "third"))
+	h.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !assert.Len(t, lines, 3) {
+		return
+	}
+	for i, want := range []string{"first", "second", "third"} {
+		assert.Contains(t, lines[i], `"log":"`+want+`"`)
+	}
+	assert.Equal(t, uint64(0), h.Dropped())
+}
+
+func TestBatcherBackpressure(t *testing.T) {
+	// no daemon consumes the queue.
+	b := &batcher{queue: make(chan record, 1), block: 10 * time.Millisecond}
+	b.push(record{lv: _infoLevel})
+	start := time.Now()
+	b.push(record{lv: _infoLevel})
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+	assert.Equal(t, uint64(1), b.Dropped())
+}
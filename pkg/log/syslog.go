@@ -0,0 +1,278 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/conf/env"
+	"github.com/go-kratos/kratos/pkg/log/internal/core"
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// _syslogSDID the structured data id of log fields, 32473 is the example
+	// private enterprise number reserved by RFC5612.
+	_syslogSDID       = "kratos@32473"
+	_syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+	// the backoff of redialing a syslog server that failed to dial.
+	_syslogMinBackoff = 100 * time.Millisecond
+	_syslogMaxBackoff = 30 * time.Second
+)
+
+var _syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+var _syslogSeverities = [...]int{
+	_debugLevel: 7,
+	_infoLevel:  6,
+	_warnLevel:  4,
+	_errorLevel: 3,
+	_fatalLevel: 2,
+}
+
+// SyslogConfig syslog handler config.
+type SyslogConfig struct {
+	// Network is one of udp, tcp, unix and unixgram.
+	Network string
+	Addr    string
+	// Facility default local0.
+	Facility string
+	// AppName default env.AppID.
+	AppName string
+	// Timeout of dial and write, default 1s.
+	Timeout xtime.Duration
+}
+
+// SyslogHandler writes RFC5424 messages to a syslog server, the message
+// is framed by octet counting (RFC6587) on the stream networks.
+//
+// Writes are synchronous, wrap it with NewAsync to avoid blocking on a slow
+// server. A server failed to dial is redialed with exponential backoff, the
+// messages are dropped while it's disconnected.
+type SyslogHandler struct {
+	c        *SyslogConfig
+	facility int
+	stream   bool
+	hostname string
+	pid      string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	backoff time.Duration
+	retryAt time.Time
+}
+
+// NewSyslog create a syslog log handler.
+func NewSyslog(c *SyslogConfig) (*SyslogHandler, error) {
+	facility, ok := _syslogFacilities[c.Facility]
+	if c.Facility == "" {
+		facility, ok = _syslogFacilities["local0"], true
+	}
+	if !ok {
+		return nil, errors.Errorf("log: unknown syslog facility %q", c.Facility)
+	}
+	var stream bool
+	switch c.Network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		stream = true
+	case "udp", "udp4", "udp6", "unixgram":
+	default:
+		return nil, errors.Errorf("log: unsupported syslog network %q", c.Network)
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = xtime.Duration(time.Second)
+	}
+	if c.AppName == "" {
+		c.AppName = env.AppID
+	}
+	h := &SyslogHandler{
+		c:        c,
+		facility: facility,
+		stream:   stream,
+		hostname: env.Hostname,
+		pid:      strconv.Itoa(os.Getpid()),
+	}
+	return h, nil
+}
+
+// Log write a syslog message of args.
+func (h *SyslogHandler) Log(ctx context.Context, lv Level, args ...D) {
+	msg := h.format(ctx, lv, args)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// retry once with a new connection if the server closed it.
+	for i := 0; i < 2; i++ {
+		if h.conn == nil {
+			if h.conn = h.dial(); h.conn == nil {
+				metricDroppedCount.Inc(lv.String())
+				return
+			}
+		}
+		h.conn.SetWriteDeadline(time.Now().Add(time.Duration(h.c.Timeout)))
+		if _, err := h.conn.Write(msg); err == nil {
+			return
+		}
+		h.conn.Close()
+		h.conn = nil
+	}
+	metricDroppedCount.Inc(lv.String())
+}
+
+// dial returns nil if the server is backing off or failed to dial.
+func (h *SyslogHandler) dial() net.Conn {
+	now := time.Now()
+	if now.Before(h.retryAt) {
+		return nil
+	}
+	conn, err := net.DialTimeout(h.c.Network, h.c.Addr, time.Duration(h.c.Timeout))
+	if err != nil {
+		if h.backoff *= 2; h.backoff < _syslogMinBackoff {
+			h.backoff = _syslogMinBackoff
+		} else if h.backoff > _syslogMaxBackoff {
+			h.backoff = _syslogMaxBackoff
+		}
+		h.retryAt = now.Add(h.backoff)
+		return nil
+	}
+	h.backoff = 0
+	return conn
+}
+
+// format RFC5424 message: <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG
+func (h *SyslogHandler) format(ctx context.Context, lv Level, args []D) []byte {
+	var (
+		ts  = time.Now()
+		log string
+		sd  bytes.Buffer
+	)
+	sd.WriteString("[" + _syslogSDID)
+	if ti, ok := traceFromContext(ctx); ok {
+		writeSDParam(&sd, _traceID, ti.traceID)
+		if ti.spanID != "" {
+			writeSDParam(&sd, _spanID, ti.spanID)
+		}
+	}
+	for _, d := range commonFields(ctx, args) {
+		switch d.Key {
+		case _log:
+			log = fieldString(d)
+		case _time:
+			if t, ok := d.Value.(time.Time); ok {
+				ts = t
+			}
+		case _level, _levelValue:
+		default:
+			writeSDParam(&sd, d.Key, fieldString(d))
+		}
+	}
+	sd.WriteByte(']')
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %s - ", h.facility*8+_syslogSeverities[lv], ts.Format(_syslogTimeFormat),
+		syslogHeader(h.hostname, 255), syslogHeader(h.c.AppName, 48), h.pid)
+	buf.Write(sd.Bytes())
+	if log != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(log)
+	}
+	if !h.stream {
+		return buf.Bytes()
+	}
+	return append([]byte(strconv.Itoa(buf.Len())+" "), buf.Bytes()...)
+}
+
+// SetFormat is not supported by syslog handler.
+func (h *SyslogHandler) SetFormat(string) {}
+
+// Close the connection to syslog server.
+func (h *SyslogHandler) Close() (err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conn != nil {
+		err = h.conn.Close()
+		h.conn = nil
+	}
+	return
+}
+
+// syslogHeader returns s as a header field of printable ascii, "-" if empty.
+func syslogHeader(s string, max int) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < max; i++ {
+		if s[i] > ' ' && s[i] < 127 {
+			b = append(b, s[i])
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+// writeSDParam writes a SD-PARAM, the name is at most 32 ascii bytes except
+// '=', ' ', ']' and '"', the value escapes '"', '\' and ']'.
+func writeSDParam(buf *bytes.Buffer, name, value string) {
+	buf.WriteByte(' ')
+	n := 0
+	for i := 0; i < len(name) && n < 32; i++ {
+		c := name[i]
+		if c <= ' ' || c >= 127 || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		buf.WriteByte(c)
+		n++
+	}
+	if n == 0 {
+		buf.WriteByte('_')
+	}
+	buf.WriteString(`="`)
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '"', '\\', ']':
+			buf.WriteByte('\\')
+		}
+		buf.WriteByte(value[i])
+	}
+	buf.WriteByte('"')
+}
+
+// fieldString returns the value of d as string.
+func fieldString(d D) string {
+	switch d.Type {
+	case core.StringType:
+		return d.StringVal
+	case core.IntTpye, core.Int64Type:
+		return strconv.FormatInt(d.Int64Val, 10)
+	case core.UintType, core.Uint64Type:
+		return strconv.FormatUint(uint64(d.Int64Val), 10)
+	case core.Float32Type:
+		return strconv.FormatFloat(float64(math.Float32frombits(uint32(d.Int64Val))), 'g', -1, 32)
+	case core.Float64Type:
+		return strconv.FormatFloat(math.Float64frombits(uint64(d.Int64Val)), 'g', -1, 64)
+	case core.DurationType:
+		return time.Duration(d.Int64Val).String()
+	}
+	switch v := d.Value.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(_syslogTimeFormat)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(d.Value)
+}
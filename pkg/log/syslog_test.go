@@ -0,0 +1,117 @@
+package log
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyslogUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	h, err := NewSyslog(&SyslogConfig{Network: "udp", Addr: pc.LocalAddr().String(), AppName: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.Log(context.Background(), _errorLevel, KVString(_log, "hello"), KVString("key", `a"b]c`), KVInt("n", 1))
+
+	b := make([]byte, 4096)
+	pc.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := pc.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(b[:n])
+	// local0(16)*8 + err(3)
+	assert.True(t, strings.HasPrefix(msg, "<131>1 "), msg)
+	parts := strings.SplitN(msg, " ", 7)
+	assert.Equal(t, "app", parts[3])
+	assert.Equal(t, "-", parts[5])
+	assert.Contains(t, msg, `[kratos@32473 key="a\"b\]c" n="1"`)
+	assert.True(t, strings.HasSuffix(msg, "] hello"), msg)
+}
+
+func TestSyslogTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	msgs := make(chan string, 2)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			size, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(size))
+			b := make([]byte, n)
+			if _, err = r.Read(b); err != nil {
+				return
+			}
+			msgs <- string(b)
+		}
+	}()
+	h, err := NewSyslog(&SyslogConfig{Network: "tcp", Addr: l.Addr().String(), Facility: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.Log(context.Background(), _infoLevel, KVString(_log, "first"))
+	h.Log(context.Background(), _debugLevel, KVString(_log, "second"))
+	for _, want := range []string{"<14>1 ", "<15>1 "} {
+		select {
+		case msg := <-msgs:
+			assert.True(t, strings.HasPrefix(msg, want), msg)
+		case <-time.After(time.Second):
+			t.Fatal("syslog message timeout")
+		}
+	}
+}
+
+func TestSyslogDialBackoff(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	h, err := NewSyslog(&SyslogConfig{Network: "tcp", Addr: l.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.Log(context.Background(), _infoLevel, KVString(_log, "first"))
+	retryAt := h.retryAt
+	assert.Equal(t, _syslogMinBackoff, h.backoff)
+	// dropped without dialing while backing off.
+	h.Log(context.Background(), _infoLevel, KVString(_log, "second"))
+	assert.Equal(t, retryAt, h.retryAt)
+	assert.Equal(t, _syslogMinBackoff, h.backoff)
+
+	h.retryAt = time.Time{}
+	h.Log(context.Background(), _infoLevel, KVString(_log, "third"))
+	assert.Equal(t, 2*_syslogMinBackoff, h.backoff)
+}
+
+func TestNewSyslogError(t *testing.T) {
+	_, err := NewSyslog(&SyslogConfig{Network: "udp", Facility: "unknown"})
+	assert.Error(t, err)
+	_, err = NewSyslog(&SyslogConfig{Network: "ip"})
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package log
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"runtime"
 	"strconv"
@@ -14,28 +15,77 @@ import (
 )
 
 func addExtraField(ctx context.Context, fields map[string]interface{}) {
-	if t, ok := trace.FromContext(ctx); ok {
-		fields[_tid] = t.TraceID()
+	if ti, ok := traceFromContext(ctx); ok {
+		fields[_tid] = ti.legacy
 	}
+	for _, f := range commonFields(ctx, nil) {
+		fields[f.Key] = f.Value
+	}
+}
+
+// commonFields appends the fields of metadata and env to d.
+func commonFields(ctx context.Context, d []D) []D {
 	if caller := metadata.String(ctx, metadata.Caller); caller != "" {
-		fields[_caller] = caller
+		d = append(d, KV(_caller, caller))
 	}
 	if color := metadata.String(ctx, metadata.Color); color != "" {
-		fields[_color] = color
+		d = append(d, KV(_color, color))
 	}
 	if env.Color != "" {
-		fields[_envColor] = env.Color
+		d = append(d, KV(_envColor, env.Color))
 	}
 	if cluster := metadata.String(ctx, metadata.Cluster); cluster != "" {
-		fields[_cluster] = cluster
+		d = append(d, KV(_cluster, cluster))
 	}
-	fields[_deplyEnv] = env.DeployEnv
-	fields[_zone] = env.Zone
-	fields[_appID] = c.Family
-	fields[_instanceID] = c.Host
+	d = append(d, KV(_deplyEnv, env.DeployEnv), KV(_zone, env.Zone), KV(_appID, c.Family), KV(_instanceID, c.Host))
 	if metadata.String(ctx, metadata.Mirror) != "" {
-		fields[_mirror] = true
+		d = append(d, KV(_mirror, true))
+	}
+	return d
+}
+
+// traceInfo the trace ids of log.
+type traceInfo struct {
+	// legacy the kratos trace string.
+	legacy  string
+	traceID string
+	spanID  string
+}
+
+type traceInfoKey struct{}
+
+// traceFromContext returns the trace ids from context or the snapshot of detachContext.
+func traceFromContext(ctx context.Context) (ti traceInfo, ok bool) {
+	if ti, ok = ctx.Value(traceInfoKey{}).(traceInfo); ok {
+		return
+	}
+	t, ok := trace.FromContext(ctx)
+	if !ok {
+		return
+	}
+	ti.legacy = t.TraceID()
+	if sp, ok := t.(*trace.Span); ok {
+		sc := sp.Context()
+		if sc.TraceIDHigh != 0 {
+			ti.traceID = fmt.Sprintf("%016x%016x", sc.TraceIDHigh, sc.TraceID)
+		} else {
+			ti.traceID = strconv.FormatUint(sc.TraceID, 16)
+		}
+		ti.spanID = strconv.FormatUint(sc.SpanID, 16)
+	} else {
+		ti.traceID = ti.legacy
+	}
+	return ti, true
+}
+
+// detachContext returns a context retaining the metadata and a snapshot of trace
+// ids, it's safe to be used after the request finished.
+func detachContext(ctx context.Context) context.Context {
+	nctx := metadata.WithContext(ctx)
+	if ti, ok := traceFromContext(ctx); ok {
+		nctx = context.WithValue(nctx, traceInfoKey{}, ti)
 	}
+	return nctx
 }
 
 // funcName get func name.
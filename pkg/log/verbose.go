@@ -26,10 +26,11 @@ import (
 func V(v int32) Verbose {
 	var (
 		file string
+		s    = loadSetting()
 	)
 	if v < 0 {
 		return Verbose(false)
-	} else if c.V >= v {
+	} else if s.v >= v {
 		return Verbose(true)
 	}
 	if pc, _, _, ok := runtime.Caller(1); ok {
@@ -41,7 +42,7 @@ func V(v int32) Verbose {
 	if slash := strings.LastIndex(file, "/"); slash >= 0 {
 		file = file[slash+1:]
 	}
-	for filter, lvl := range c.Module {
+	for filter, lvl := range s.module {
 		var match bool
 		if match = filter == file; !match {
 			match, _ = filepath.Match(filter, file)
@@ -0,0 +1,140 @@
+// Package auth provides the jwt and api key authentication middlewares of
+// blademaster, the authenticated principal is put into the metadata so the
+// warden clients propagate it downstream.
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-kratos/kratos/pkg/ecode"
+	"github.com/go-kratos/kratos/pkg/log"
+	bm "github.com/go-kratos/kratos/pkg/net/http/blademaster"
+	"github.com/go-kratos/kratos/pkg/net/metadata"
+)
+
+const _principalKey = "kratos/auth/principal"
+
+// Principal is the authenticated identity of the request.
+type Principal struct {
+	// Subject identifies the caller, which is propagated as metadata.Principal.
+	Subject string
+	// Type is the authentication type, jwt or apikey.
+	Type   string
+	Scopes []string
+	// Claims of jwt, nil for api key.
+	Claims Claims
+}
+
+// HasScope reports whether the principal is granted scope.
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// FromContext returns the principal authenticated by the middlewares.
+func FromContext(c *bm.Context) (*Principal, bool) {
+	v, ok := c.Get(_principalKey)
+	if !ok {
+		return nil, false
+	}
+	p, ok := v.(*Principal)
+	return p, ok
+}
+
+// set principal into context
+// NOTE: This method is not thread safe.
+func setPrincipal(c *bm.Context, p *Principal) {
+	c.Set(_principalKey, p)
+	if md, ok := metadata.FromContext(c); ok {
+		md[metadata.Principal] = p.Subject
+	}
+}
+
+func unauthorized(c *bm.Context, scheme string) {
+	c.Writer.Header().Set("WWW-Authenticate", scheme)
+	c.JSON(nil, ecode.Unauthorized)
+	c.Abort()
+}
+
+// JWT returns the middleware authenticating the bearer token of Authorization header.
+func JWT(v *JWTVerifier) bm.HandlerFunc {
+	return func(c *bm.Context) {
+		token := bearerToken(c.Request.Header.Get("Authorization"))
+		if token == "" {
+			unauthorized(c, "Bearer")
+			return
+		}
+		claims, err := v.Verify(token)
+		if err != nil {
+			log.Warn("auth: verify jwt error(%v)", err)
+			unauthorized(c, `Bearer error="invalid_token"`)
+			return
+		}
+		setPrincipal(c, &Principal{
+			Subject: claims.Subject(),
+			Type:    "jwt",
+			Scopes:  claims.Scopes(),
+			Claims:  claims,
+		})
+	}
+}
+
+func bearerToken(header string) string {
+	const prefix = "bearer "
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(header[len(prefix):])
+}
+
+// APIKeyStore looks up the principal of api key.
+type APIKeyStore interface {
+	// Lookup returns the principal of key, or ecode.Unauthorized if the key is unknown.
+	Lookup(ctx context.Context, key string) (*Principal, error)
+}
+
+// StaticAPIKeyStore is an api key store with fixed keys.
+type StaticAPIKeyStore map[string]*Principal
+
+// Lookup implements APIKeyStore.
+func (s StaticAPIKeyStore) Lookup(_ context.Context, key string) (*Principal, error) {
+	p, ok := s[key]
+	if !ok {
+		return nil, ecode.Unauthorized
+	}
+	return p, nil
+}
+
+// APIKey returns the middleware authenticating the api key carried by header,
+// default header is X-API-Key.
+func APIKey(store APIKeyStore, header string) bm.HandlerFunc {
+	if header == "" {
+		header = "X-API-Key"
+	}
+	return func(c *bm.Context) {
+		key := c.Request.Header.Get(header)
+		if key == "" {
+			unauthorized(c, "APIKey")
+			return
+		}
+		p, err := store.Lookup(c, key)
+		if err != nil {
+			if !ecode.Equal(ecode.Cause(err), ecode.Unauthorized) {
+				log.Error("auth: lookup api key error(%v)", err)
+				c.JSON(nil, err)
+				c.Abort()
+				return
+			}
+			unauthorized(c, "APIKey")
+			return
+		}
+		principal := *p
+		principal.Type = "apikey"
+		setPrincipal(c, &principal)
+	}
+}
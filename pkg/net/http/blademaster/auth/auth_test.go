@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/ecode"
+	bm "github.com/go-kratos/kratos/pkg/net/http/blademaster"
+	"github.com/go-kratos/kratos/pkg/net/metadata"
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type errStore struct{}
+
+func (errStore) Lookup(context.Context, string) (*Principal, error) {
+	return nil, ecode.ServiceUnavailable
+}
+
+func newTestEngine() *bm.Engine {
+	engine := bm.NewServer(&bm.ServerConfig{Timeout: xtime.Duration(time.Second)})
+	engine.SetEnvelope(bm.ProblemEnvelope)
+	handler := func(c *bm.Context) {
+		p, ok := FromContext(c)
+		if !ok {
+			c.JSON(nil, ecode.ServerErr)
+			return
+		}
+		c.JSON(map[string]interface{}{
+			"subject":  p.Subject,
+			"type":     p.Type,
+			"metadata": metadata.String(c, metadata.Principal),
+		}, nil)
+	}
+	engine.GET("/jwt", JWT(NewJWTVerifier(nil, SecretKeySet([]byte("secret")))), handler)
+	engine.GET("/apikey", APIKey(StaticAPIKeyStore{"key": {Subject: "job"}}, ""), handler)
+	engine.GET("/apikey/err", APIKey(errStore{}, "X-Key"), handler)
+	engine.GET("/anonymous", func(c *bm.Context) {
+		c.JSON(map[string]interface{}{"metadata": metadata.String(c, metadata.Principal)}, nil)
+	})
+	return engine
+}
+
+func TestMiddleware(t *testing.T) {
+	engine := newTestEngine()
+	do := func(path, header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	token := sign(t, "HS256", "", []byte("secret"), Claims{"sub": "10086"})
+	w := do("/jwt", "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"subject":"10086","type":"jwt","metadata":"10086"}`, w.Body.String())
+
+	w = do("/jwt", "", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+	w = do("/jwt", "Authorization", "Bearer "+sign(t, "HS256", "", []byte("other"), Claims{}))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = do("/apikey", "X-API-Key", "key")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"subject":"job","type":"apikey","metadata":"job"}`, w.Body.String())
+	w = do("/apikey", "X-API-Key", "unknown")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = do("/apikey/err", "X-Key", "key")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	// the principal can't be forged by the metadata header.
+	w = do("/anonymous", "X-Bm-Metadata-Principal", "admin")
+	assert.JSONEq(t, `{"metadata":""}`, w.Body.String())
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/log"
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrKeyNotFound is returned when no key of the key set matches the token.
+var ErrKeyNotFound = errors.New("auth: key not found")
+
+// KeySet provides the keys verifying jwt.
+type KeySet interface {
+	// Key returns the key of kid for alg, it's []byte for HS, *rsa.PublicKey
+	// for RS and *ecdsa.PublicKey for ES.
+	Key(kid, alg string) (interface{}, error)
+}
+
+// StaticKeySet is a key set with fixed keys by kid, the key of empty kid is
+// used by the tokens without kid.
+type StaticKeySet map[string]interface{}
+
+// Key implements KeySet.
+func (s StaticKeySet) Key(kid, alg string) (interface{}, error) {
+	key, ok := s[kid]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// SecretKeySet returns the key set of a single HMAC secret.
+func SecretKeySet(secret []byte) KeySet {
+	return StaticKeySet{"": secret}
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// oct
+	K string `json:"k"`
+}
+
+type jsonWebKeySet struct {
+	Keys []*jsonWebKey `json:"keys"`
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (k *jsonWebKey) key() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("auth: unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		b, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return b, nil
+	}
+	return nil, errors.Errorf("auth: unsupported key type %s", k.Kty)
+}
+
+// ParseJWKS parses the JSON Web Key Set (RFC 7517) into a static key set,
+// keys not used for signature are skipped.
+func ParseJWKS(data []byte) (StaticKeySet, error) {
+	jwks := new(jsonWebKeySet)
+	if err := json.Unmarshal(data, jwks); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	keys := make(StaticKeySet, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.key()
+		if err != nil {
+			return nil, err
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+// NewFileKeySet returns the key set loaded from the JWKS file.
+func NewFileKeySet(path string) (KeySet, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return ParseJWKS(data)
+}
+
+// RemoteKeySetConfig contains configs of remote key set.
+type RemoteKeySetConfig struct {
+	URL string
+	// Refresh is the interval reloading the key set, default is 1 hour.
+	Refresh xtime.Duration
+	// MinRefresh limits reloading when an unknown kid shows up, default is 10 seconds.
+	MinRefresh xtime.Duration
+	Timeout    xtime.Duration
+}
+
+type remoteKeySet struct {
+	conf   *RemoteKeySetConfig
+	client *http.Client
+
+	mu      sync.RWMutex
+	keys    StaticKeySet
+	fetched time.Time
+	tried   time.Time
+}
+
+// NewRemoteKeySet returns the key set fetched from the JWKS url and cached.
+func NewRemoteKeySet(conf *RemoteKeySetConfig) KeySet {
+	if conf.Refresh <= 0 {
+		conf.Refresh = xtime.Duration(time.Hour)
+	}
+	if conf.MinRefresh <= 0 {
+		conf.MinRefresh = xtime.Duration(10 * time.Second)
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = xtime.Duration(time.Second)
+	}
+	return &remoteKeySet{
+		conf:   conf,
+		client: &http.Client{Timeout: time.Duration(conf.Timeout)},
+	}
+}
+
+// Key implements KeySet, the key set is reloaded when it's stale or the kid is unknown.
+func (s *remoteKeySet) Key(kid, alg string) (interface{}, error) {
+	s.mu.RLock()
+	keys, fetched, tried := s.keys, s.fetched, s.tried
+	s.mu.RUnlock()
+	if keys != nil && time.Since(fetched) < time.Duration(s.conf.Refresh) {
+		if key, err := keys.Key(kid, alg); err == nil {
+			return key, nil
+		}
+	}
+	if time.Since(tried) >= time.Duration(s.conf.MinRefresh) {
+		// keep using the stale keys while the url is unavailable.
+		if err := s.refresh(); err != nil {
+			log.Error("auth: refresh jwks(%s) error(%v)", s.conf.URL, err)
+		}
+	}
+	s.mu.RLock()
+	keys = s.keys
+	s.mu.RUnlock()
+	if keys == nil {
+		return nil, ErrKeyNotFound
+	}
+	return keys.Key(kid, alg)
+}
+
+// refresh reloads the keys, the key set is fetched without holding the lock so
+// that the requests with the known keys aren't blocked.
+func (s *remoteKeySet) refresh() error {
+	s.mu.Lock()
+	// another goroutine may have refreshed the keys.
+	if time.Since(s.tried) < time.Duration(s.conf.MinRefresh) {
+		s.mu.Unlock()
+		return nil
+	}
+	s.tried = time.Now()
+	s.mu.Unlock()
+	keys, err := s.fetch()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.keys = keys
+	s.fetched = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *remoteKeySet) fetch() (StaticKeySet, error) {
+	req, err := http.NewRequest(http.MethodGet, s.conf.URL, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.conf.Timeout))
+	defer cancel()
+	resp, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("auth: fetch jwks(%s) status code %d", s.conf.URL, resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return ParseJWKS(data)
+}
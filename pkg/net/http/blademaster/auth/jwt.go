@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	// register the hash functions used by jwt.
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"github.com/pkg/errors"
+)
+
+// JWT errors.
+var (
+	ErrTokenMalformed = errors.New("auth: token is malformed")
+	ErrTokenAlgorithm = errors.New("auth: token algorithm is not allowed")
+	ErrTokenSignature = errors.New("auth: token signature is invalid")
+	ErrTokenExpired   = errors.New("auth: token is expired")
+	ErrTokenNotValid  = errors.New("auth: token is not valid yet")
+	ErrTokenIssuer    = errors.New("auth: token issuer is invalid")
+	ErrTokenAudience  = errors.New("auth: token audience is invalid")
+)
+
+var _algorithms = map[string]crypto.Hash{
+	"HS256": crypto.SHA256,
+	"HS384": crypto.SHA384,
+	"HS512": crypto.SHA512,
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// JWTConfig contains configs of jwt verification.
+type JWTConfig struct {
+	// Algorithms allowed to sign the token, default is all of HS, RS and ES.
+	Algorithms []string
+	// Issuer is checked against the iss claim if it's not empty.
+	Issuer string
+	// Audience is checked against the aud claim if it's not empty.
+	Audience string
+	// Leeway tolerates the clock skew when checking exp and nbf.
+	Leeway xtime.Duration
+}
+
+// Claims is the payload of jwt.
+type Claims map[string]interface{}
+
+// Subject returns the sub claim.
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+// Issuer returns the iss claim.
+func (c Claims) Issuer() string {
+	s, _ := c["iss"].(string)
+	return s
+}
+
+// Audience returns the aud claim, which is either a string or an array.
+func (c Claims) Audience() []string {
+	switch aud := c["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []interface{}:
+		auds := make([]string, 0, len(aud))
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				auds = append(auds, s)
+			}
+		}
+		return auds
+	}
+	return nil
+}
+
+// Scopes returns the space separated scope claim.
+func (c Claims) Scopes() []string {
+	s, _ := c["scope"].(string)
+	return strings.Fields(s)
+}
+
+func (c Claims) time(key string) (t time.Time, ok bool) {
+	var v float64
+	if v, ok = c[key].(float64); ok {
+		t = time.Unix(int64(v), 0)
+	}
+	return
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// JWTVerifier verifies jwt signed by the keys of key set.
+type JWTVerifier struct {
+	conf       *JWTConfig
+	keys       KeySet
+	algorithms map[string]bool
+	now        func() time.Time
+}
+
+// NewJWTVerifier returns a jwt verifier.
+func NewJWTVerifier(conf *JWTConfig, keys KeySet) *JWTVerifier {
+	if conf == nil {
+		conf = &JWTConfig{}
+	}
+	v := &JWTVerifier{
+		conf:       conf,
+		keys:       keys,
+		algorithms: make(map[string]bool),
+		now:        time.Now,
+	}
+	algs := conf.Algorithms
+	if len(algs) == 0 {
+		for alg := range _algorithms {
+			algs = append(algs, alg)
+		}
+	}
+	for _, alg := range algs {
+		v.algorithms[alg] = true
+	}
+	return v
+}
+
+// Verify verifies the signature and the registered claims of token, then returns the claims.
+func (v *JWTVerifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+	header := new(jwtHeader)
+	if err := decodeSegment(parts[0], header); err != nil {
+		return nil, err
+	}
+	hash, ok := _algorithms[header.Alg]
+	if !ok || !v.algorithms[header.Alg] {
+		return nil, ErrTokenAlgorithm
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	key, err := v.keys.Key(header.Kid, header.Alg)
+	if err != nil {
+		return nil, err
+	}
+	if err = verifySignature(header.Alg, hash, key, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+	claims := Claims{}
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err = v.validate(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *JWTVerifier) validate(claims Claims) error {
+	now := v.now()
+	leeway := time.Duration(v.conf.Leeway)
+	if exp, ok := claims.time("exp"); ok && now.After(exp.Add(leeway)) {
+		return ErrTokenExpired
+	}
+	if nbf, ok := claims.time("nbf"); ok && now.Add(leeway).Before(nbf) {
+		return ErrTokenNotValid
+	}
+	if v.conf.Issuer != "" && claims.Issuer() != v.conf.Issuer {
+		return ErrTokenIssuer
+	}
+	if v.conf.Audience != "" {
+		for _, aud := range claims.Audience() {
+			if aud == v.conf.Audience {
+				return nil
+			}
+		}
+		return ErrTokenAudience
+	}
+	return nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return ErrTokenMalformed
+	}
+	if err = json.Unmarshal(b, v); err != nil {
+		return ErrTokenMalformed
+	}
+	return nil
+}
+
+func verifySignature(alg string, hash crypto.Hash, key interface{}, signing string, sig []byte) error {
+	h := hash.New()
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return ErrTokenAlgorithm
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write([]byte(signing))
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return ErrTokenSignature
+		}
+	case "RS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrTokenAlgorithm
+		}
+		h.Write([]byte(signing))
+		if err := rsa.VerifyPKCS1v15(pub, hash, h.Sum(nil), sig); err != nil {
+			return ErrTokenSignature
+		}
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrTokenAlgorithm
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return ErrTokenSignature
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		h.Write([]byte(signing))
+		if !ecdsa.Verify(pub, h.Sum(nil), r, s) {
+			return ErrTokenSignature
+		}
+	default:
+		return ErrTokenAlgorithm
+	}
+	return nil
+}
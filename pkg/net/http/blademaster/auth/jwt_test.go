@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeSegment(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func sign(t *testing.T, alg, kid string, key interface{}, claims Claims) string {
+	signing := encodeSegment(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + encodeSegment(claims)
+	hash := _algorithms[alg]
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(hash.New, k)
+		mac.Write([]byte(signing))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		h := hash.New()
+		h.Write([]byte(signing))
+		var err error
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, hash, h.Sum(nil))
+		assert.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		h := hash.New()
+		h.Write([]byte(signing))
+		r, s, err := ecdsa.Sign(rand.Reader, k, h.Sum(nil))
+		assert.NoError(t, err)
+		size := (k.Curve.Params().BitSize + 7) / 8
+		sig = make([]byte, 2*size)
+		rb, sb := r.Bytes(), s.Bytes()
+		copy(sig[size-len(rb):size], rb)
+		copy(sig[2*size-len(sb):], sb)
+	}
+	return signing + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func testJWKS(rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) []byte {
+	return []byte(fmt.Sprintf(`{"keys":[
+		{"kty":"RSA","kid":"rsa","use":"sig","n":"%s","e":"%s"},
+		{"kty":"EC","kid":"ec","crv":"P-256","x":"%s","y":"%s"},
+		{"kty":"oct","kid":"oct","k":"%s"},
+		{"kty":"RSA","kid":"enc","use":"enc","n":"%s","e":"%s"}
+	]}`,
+		encodeBigInt(rsaKey.N), encodeBigInt(big.NewInt(int64(rsaKey.E))),
+		encodeBigInt(ecKey.X), encodeBigInt(ecKey.Y),
+		base64.RawURLEncoding.EncodeToString([]byte("secret")),
+		encodeBigInt(rsaKey.N), encodeBigInt(big.NewInt(int64(rsaKey.E)))))
+}
+
+func TestJWTVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	keys, err := ParseJWKS(testJWKS(rsaKey, ecKey))
+	assert.NoError(t, err)
+	assert.Len(t, keys, 3)
+
+	now := time.Unix(1600000000, 0)
+	v := NewJWTVerifier(&JWTConfig{Issuer: "kratos", Audience: "api", Leeway: xtime.Duration(time.Minute)}, keys)
+	v.now = func() time.Time { return now }
+	claims := Claims{"sub": "10086", "iss": "kratos", "aud": []string{"web", "api"}, "exp": now.Unix() + 60, "scope": "read write"}
+
+	for _, test := range []struct {
+		alg string
+		kid string
+		key interface{}
+	}{
+		{"HS256", "oct", []byte("secret")},
+		{"RS256", "rsa", rsaKey},
+		{"RS512", "rsa", rsaKey},
+		{"ES256", "ec", ecKey},
+	} {
+		got, err := v.Verify(sign(t, test.alg, test.kid, test.key, claims))
+		assert.NoError(t, err, test.alg)
+		assert.Equal(t, "10086", got.Subject())
+		assert.Equal(t, []string{"read", "write"}, got.Scopes())
+	}
+
+	tests := []struct {
+		token string
+		err   error
+	}{
+		{"foo.bar", ErrTokenMalformed},
+		{sign(t, "HS256", "oct", []byte("other"), claims), ErrTokenSignature},
+		{sign(t, "HS256", "rsa", []byte("secret"), claims), ErrTokenAlgorithm},
+		{sign(t, "RS256", "unknown", rsaKey, claims), ErrKeyNotFound},
+		{sign(t, "RS256", "enc", rsaKey, claims), ErrKeyNotFound},
+		{sign(t, "HS256", "oct", []byte("secret"), Claims{"iss": "kratos", "aud": "api", "exp": now.Unix() - 61}), ErrTokenExpired},
+		{sign(t, "HS256", "oct", []byte("secret"), Claims{"iss": "kratos", "aud": "api", "nbf": now.Unix() + 61}), ErrTokenNotValid},
+		{sign(t, "HS256", "oct", []byte("secret"), Claims{"iss": "other", "aud": "api"}), ErrTokenIssuer},
+		{sign(t, "HS256", "oct", []byte("secret"), Claims{"iss": "kratos", "aud": "web"}), ErrTokenAudience},
+	}
+	for i, test := range tests {
+		_, err := v.Verify(test.token)
+		assert.Equal(t, test.err, err, "case %d", i)
+	}
+
+	// algorithms not configured are rejected
+	v = NewJWTVerifier(&JWTConfig{Algorithms: []string{"RS256"}}, keys)
+	_, err = v.Verify(sign(t, "HS256", "oct", []byte("secret"), Claims{}))
+	assert.Equal(t, ErrTokenAlgorithm, err)
+}
+
+func TestFileKeySet(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	dir, err := ioutil.TempDir("", "jwks")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "jwks.json")
+	assert.NoError(t, ioutil.WriteFile(path, testJWKS(rsaKey, ecKey), 0644))
+
+	keys, err := NewFileKeySet(path)
+	assert.NoError(t, err)
+	key, err := keys.Key("rsa", "RS256")
+	assert.NoError(t, err)
+	assert.Equal(t, rsaKey.N, key.(*rsa.PublicKey).N)
+
+	_, err = NewFileKeySet(filepath.Join(dir, "none.json"))
+	assert.Error(t, err)
+}
+
+func TestRemoteKeySet(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var hits int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&hits, 1)
+		w.Write(testJWKS(rsaKey, ecKey))
+	}))
+	defer srv.Close()
+
+	keys := NewRemoteKeySet(&RemoteKeySetConfig{URL: srv.URL, MinRefresh: xtime.Duration(time.Hour)})
+	for i := 0; i < 3; i++ {
+		key, err := keys.Key("ec", "ES256")
+		assert.NoError(t, err)
+		assert.Equal(t, ecKey.X, key.(*ecdsa.PublicKey).X)
+	}
+	// unknown kid does not reload within MinRefresh
+	_, err := keys.Key("unknown", "ES256")
+	assert.Equal(t, ErrKeyNotFound, err)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&hits))
+}
+
+func TestRemoteKeySetRefreshUnlocked(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var hits int64
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&hits, 1) > 1 {
+			<-block
+		}
+		w.Write(testJWKS(rsaKey, ecKey))
+	}))
+	defer srv.Close()
+	defer close(block)
+
+	keys := NewRemoteKeySet(&RemoteKeySetConfig{
+		URL:        srv.URL,
+		Refresh:    xtime.Duration(time.Millisecond),
+		MinRefresh: xtime.Duration(50 * time.Millisecond),
+	})
+	_, err := keys.Key("ec", "ES256")
+	assert.NoError(t, err)
+	time.Sleep(60 * time.Millisecond)
+	// the stale keys are served while the refresh is in flight.
+	go keys.Key("ec", "ES256")
+	for atomic.LoadInt64(&hits) != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	key, err := keys.Key("ec", "ES256")
+	assert.NoError(t, err)
+	assert.Equal(t, ecKey.X, key.(*ecdsa.PublicKey).X)
+}
@@ -16,6 +16,11 @@ const (
 	MIMEPlain             = "text/plain"
 	MIMEPOSTForm          = "application/x-www-form-urlencoded"
 	MIMEMultipartPOSTForm = "multipart/form-data"
+	MIMEPROTOBUF          = "application/x-protobuf"
+	MIMEYAML              = "application/x-yaml"
+	MIMEYAML2             = "application/yaml"
+	MIMEMSGPACK           = "application/x-msgpack"
+	MIMEMSGPACK2          = "application/msgpack"
 )
 
 // Binding http binding request interface.
@@ -53,6 +58,9 @@ var (
 	Query         = queryBinding{}
 	FormPost      = formPostBinding{}
 	FormMultipart = formMultipartBinding{}
+	ProtoBuf      = protobufBinding{}
+	YAML          = yamlBinding{}
+	MsgPack       = msgpackBinding{}
 )
 
 // Default get by binding type by method and contexttype.
@@ -67,6 +75,12 @@ func Default(method, contentType string) Binding {
 		return JSON
 	case MIMEXML, MIMEXML2:
 		return XML
+	case MIMEPROTOBUF:
+		return ProtoBuf
+	case MIMEYAML, MIMEYAML2:
+		return YAML
+	case MIMEMSGPACK, MIMEMSGPACK2:
+		return MsgPack
 	default: //case MIMEPOSTForm, MIMEMultipartPOSTForm:
 		return Form
 	}
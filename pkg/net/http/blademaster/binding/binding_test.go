@@ -6,7 +6,11 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/go-kratos/kratos/pkg/net/http/blademaster/binding/example"
+
+	"github.com/gogo/protobuf/proto"
 	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
 )
 
 type FooStruct struct {
@@ -61,6 +65,15 @@ func TestBindingDefault(t *testing.T) {
 
 	assert.Equal(t, Default("POST", MIMEMultipartPOSTForm), Form)
 	assert.Equal(t, Default("PUT", MIMEMultipartPOSTForm), Form)
+
+	assert.Equal(t, Default("POST", MIMEPROTOBUF), ProtoBuf)
+	assert.Equal(t, Default("PUT", MIMEPROTOBUF), ProtoBuf)
+
+	assert.Equal(t, Default("POST", MIMEYAML), YAML)
+	assert.Equal(t, Default("PUT", MIMEYAML2+"; charset=utf-8"), YAML)
+
+	assert.Equal(t, Default("POST", MIMEMSGPACK), MsgPack)
+	assert.Equal(t, Default("PUT", MIMEMSGPACK2), MsgPack)
 }
 
 func TestStripContentType(t *testing.T) {
@@ -167,6 +180,58 @@ func TestBindingXML(t *testing.T) {
 		"<map><foo>bar</foo></map>", "<map><bar>foo</bar></map>")
 }
 
+func TestBindingYAML(t *testing.T) {
+	testBodyBinding(t,
+		YAML, "yaml",
+		"/", "/",
+		"foo: bar", "bar: foo")
+}
+
+func TestBindingProtoBuf(t *testing.T) {
+	test := &example.Test{
+		Label: proto.String("yes"),
+	}
+	data, _ := proto.Marshal(test)
+
+	b := ProtoBuf
+	assert.Equal(t, b.Name(), "protobuf")
+
+	obj := example.Test{}
+	req := requestWithBody("POST", "/", string(data))
+	req.Header.Add("Content-Type", MIMEPROTOBUF)
+	err := b.Bind(req, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, *obj.Label, "yes")
+
+	req = requestWithBody("POST", "/", "foo")
+	err = b.Bind(req, &FooStruct{})
+	assert.Error(t, err)
+}
+
+func TestBindingMsgPack(t *testing.T) {
+	test := FooStruct{
+		Foo: "bar",
+	}
+	buf := bytes.NewBuffer(nil)
+	err := codec.NewEncoder(buf, new(codec.MsgpackHandle)).Encode(test)
+	assert.NoError(t, err)
+
+	b := MsgPack
+	assert.Equal(t, b.Name(), "msgpack")
+
+	obj := FooStruct{}
+	req := requestWithBody("POST", "/", buf.String())
+	req.Header.Add("Content-Type", MIMEMSGPACK)
+	err = b.Bind(req, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, obj.Foo, "bar")
+
+	obj = FooStruct{}
+	req = requestWithBody("POST", "/", "")
+	err = b.Bind(req, &obj)
+	assert.Error(t, err)
+}
+
 func createFormPostRequest() *http.Request {
 	req, _ := http.NewRequest("POST", "/?foo=getfoo&bar=getbar", bytes.NewBufferString("foo=bar&bar=foo"))
 	req.Header.Set("Content-Type", MIMEPOSTForm)
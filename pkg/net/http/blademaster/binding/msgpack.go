@@ -0,0 +1,22 @@
+package binding
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/ugorji/go/codec"
+)
+
+type msgpackBinding struct{}
+
+func (msgpackBinding) Name() string {
+	return "msgpack"
+}
+
+func (msgpackBinding) Bind(req *http.Request, obj interface{}) error {
+	decoder := codec.NewDecoder(req.Body, new(codec.MsgpackHandle))
+	if err := decoder.Decode(obj); err != nil {
+		return errors.WithStack(err)
+	}
+	return validate(obj)
+}
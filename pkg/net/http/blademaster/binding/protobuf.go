@@ -0,0 +1,30 @@
+package binding
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+type protobufBinding struct{}
+
+func (protobufBinding) Name() string {
+	return "protobuf"
+}
+
+func (protobufBinding) Bind(req *http.Request, obj interface{}) error {
+	msg, ok := obj.(proto.Message)
+	if !ok {
+		return errors.Errorf("binding: %T is not a proto.Message", obj)
+	}
+	buf, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = proto.Unmarshal(buf, msg); err != nil {
+		return errors.WithStack(err)
+	}
+	return validate(obj)
+}
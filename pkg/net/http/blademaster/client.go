@@ -220,7 +220,7 @@ func (client *Client) Raw(c context.Context, req *xhttp.Request, v ...string) (b
 	// stat
 	now := time.Now()
 	defer func() {
-		_metricClientReqDur.Observe(int64(time.Since(now)/time.Millisecond), uri, req.Method)
+		_metricClientReqDur.ObserveContext(c, int64(time.Since(now)/time.Millisecond), uri, req.Method)
 		if code != "" {
 			_metricClientReqCodeTotal.Inc(uri, req.Method, code)
 		}
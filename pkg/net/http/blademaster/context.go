@@ -16,7 +16,6 @@ import (
 	"github.com/go-kratos/kratos/pkg/net/http/blademaster/render"
 
 	"github.com/gogo/protobuf/proto"
-	"github.com/gogo/protobuf/types"
 	"github.com/pkg/errors"
 )
 
@@ -49,8 +48,9 @@ type Context struct {
 
 	Error error
 
-	method string
-	engine *Engine
+	method   string
+	engine   *Engine
+	envelope Envelope
 
 	RoutePath string
 
@@ -67,6 +67,7 @@ func (c *Context) reset() {
 	c.Keys = nil
 	c.Error = nil
 	c.method = ""
+	c.envelope = nil
 	c.RoutePath = ""
 	c.Params = c.Params[0:0]
 }
@@ -239,38 +240,50 @@ func (c *Context) Render(code int, r render.Render) {
 	}
 }
 
-// JSON serializes the given struct as JSON into the response body.
-// It also sets the Content-Type as "application/json".
-func (c *Context) JSON(data interface{}, err error) {
-	code := http.StatusOK
+// Envelope returns the response envelope of the current request, which is
+// set by the RouterGroup of the route, then the Engine, then DefaultEnvelope.
+func (c *Context) Envelope() Envelope {
+	if c.envelope != nil {
+		return c.envelope
+	}
+	if c.engine != nil && c.engine.envelope != nil {
+		return c.engine.envelope
+	}
+	return DefaultEnvelope
+}
+
+// render serializes data and err in format through the envelope of the request.
+func (c *Context) render(format string, data interface{}, err error) {
 	c.Error = err
 	bcode := ecode.Cause(err)
-	// TODO app allow 5xx?
-	/*
-		if bcode.Code() == -500 {
-			code = http.StatusServiceUnavailable
-		}
-	*/
+	envelope := c.Envelope()
+	r, rerr := envelope.Render(format, bcode, data)
+	if rerr != nil {
+		c.Error = rerr
+		return
+	}
 	writeStatusCode(c.Writer, bcode.Code())
-	c.Render(code, render.JSON{
-		Code:    bcode.Code(),
-		Message: bcode.Message(),
-		Data:    data,
-	})
+	c.Render(envelope.Status(bcode), r)
+}
+
+// JSON serializes the given struct as JSON into the response body.
+// It also sets the Content-Type as "application/json".
+func (c *Context) JSON(data interface{}, err error) {
+	c.render(binding.MIMEJSON, data, err)
 }
 
 // JSONMap serializes the given map as map JSON into the response body.
 // It also sets the Content-Type as "application/json".
+// The code and message are merged into the map with DefaultEnvelope,
+// other envelopes render the map as the data of JSON.
 func (c *Context) JSONMap(data map[string]interface{}, err error) {
+	if _, ok := c.Envelope().(kratosEnvelope); !ok {
+		c.render(binding.MIMEJSON, data, err)
+		return
+	}
 	code := http.StatusOK
 	c.Error = err
 	bcode := ecode.Cause(err)
-	// TODO app allow 5xx?
-	/*
-		if bcode.Code() == -500 {
-			code = http.StatusServiceUnavailable
-		}
-	*/
 	writeStatusCode(c.Writer, bcode.Code())
 	data["code"] = bcode.Code()
 	if _, ok := data["message"]; !ok {
@@ -282,49 +295,23 @@ func (c *Context) JSONMap(data map[string]interface{}, err error) {
 // XML serializes the given struct as XML into the response body.
 // It also sets the Content-Type as "application/xml".
 func (c *Context) XML(data interface{}, err error) {
-	code := http.StatusOK
-	c.Error = err
-	bcode := ecode.Cause(err)
-	// TODO app allow 5xx?
-	/*
-		if bcode.Code() == -500 {
-			code = http.StatusServiceUnavailable
-		}
-	*/
-	writeStatusCode(c.Writer, bcode.Code())
-	c.Render(code, render.XML{
-		Code:    bcode.Code(),
-		Message: bcode.Message(),
-		Data:    data,
-	})
+	c.render(binding.MIMEXML, data, err)
 }
 
 // Protobuf serializes the given struct as PB into the response body.
 // It also sets the ContentType as "application/x-protobuf".
 func (c *Context) Protobuf(data proto.Message, err error) {
-	var (
-		bytes []byte
-	)
-
-	code := http.StatusOK
-	c.Error = err
-	bcode := ecode.Cause(err)
-
-	any := new(types.Any)
-	if data != nil {
-		if bytes, err = proto.Marshal(data); err != nil {
-			c.Error = errors.WithStack(err)
-			return
-		}
-		any.TypeUrl = "type.googleapis.com/" + proto.MessageName(data)
-		any.Value = bytes
+	if data == nil {
+		c.render(binding.MIMEPROTOBUF, nil, err)
+		return
 	}
-	writeStatusCode(c.Writer, bcode.Code())
-	c.Render(code, render.PB{
-		Code:    int64(bcode.Code()),
-		Message: bcode.Message(),
-		Data:    any,
-	})
+	c.render(binding.MIMEPROTOBUF, data, err)
+}
+
+// MsgPack serializes the given struct as MsgPack into the response body.
+// It also sets the Content-Type as "application/x-msgpack".
+func (c *Context) MsgPack(data interface{}, err error) {
+	c.render(binding.MIMEMSGPACK, data, err)
 }
 
 // Bytes writes some data into the body stream and updates the HTTP code.
@@ -372,12 +359,8 @@ func (c *Context) Bind(obj interface{}) error {
 // See the binding package.
 func (c *Context) mustBindWith(obj interface{}, b binding.Binding) (err error) {
 	if err = b.Bind(c.Request, obj); err != nil {
+		c.render(binding.MIMEJSON, nil, ecode.Error(ecode.RequestErr, err.Error()))
 		c.Error = ecode.RequestErr
-		c.Render(http.StatusOK, render.JSON{
-			Code:    ecode.RequestErr.Code(),
-			Message: err.Error(),
-			Data:    nil,
-		})
 		c.Abort()
 	}
 	return
@@ -0,0 +1,153 @@
+package blademaster
+
+import (
+	"net/http"
+
+	"github.com/go-kratos/kratos/pkg/ecode"
+	"github.com/go-kratos/kratos/pkg/net/http/blademaster/binding"
+	"github.com/go-kratos/kratos/pkg/net/http/blademaster/render"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+)
+
+var (
+	// DefaultEnvelope wraps data in the {code, message, ttl, data} envelope
+	// and always responds with http status 200, it's used unless the Engine or
+	// RouterGroup sets another one.
+	DefaultEnvelope Envelope = kratosEnvelope{}
+	// ProblemEnvelope writes data as plain body on success and RFC 7807
+	// problem details on error, the http status is mapped by HTTPStatus.
+	ProblemEnvelope Envelope = problemEnvelope{}
+)
+
+// Envelope decides the http status code and the body shape of the render
+// helpers of Context: JSON, JSONMap, XML, Protobuf, MsgPack and Negotiate.
+type Envelope interface {
+	// Status returns the http status code of the response carrying bcode.
+	Status(bcode ecode.Codes) int
+	// Render returns the render writing data and bcode in format, which is one
+	// of binding.MIMEJSON, binding.MIMEXML, binding.MIMEPROTOBUF and binding.MIMEMSGPACK.
+	Render(format string, bcode ecode.Codes, data interface{}) (render.Render, error)
+}
+
+// HTTPStatus maps the common ecode to http status code, the codes out of the
+// common ecode are reported as 500.
+func HTTPStatus(bcode ecode.Codes) int {
+	switch bcode.Code() {
+	case ecode.OK.Code():
+		return http.StatusOK
+	case ecode.NotModified.Code():
+		return http.StatusNotModified
+	case ecode.TemporaryRedirect.Code():
+		return http.StatusTemporaryRedirect
+	case ecode.RequestErr.Code():
+		return http.StatusBadRequest
+	case ecode.Unauthorized.Code():
+		return http.StatusUnauthorized
+	case ecode.AccessDenied.Code():
+		return http.StatusForbidden
+	case ecode.NothingFound.Code():
+		return http.StatusNotFound
+	case ecode.MethodNotAllowed.Code():
+		return http.StatusMethodNotAllowed
+	case ecode.Conflict.Code():
+		return http.StatusConflict
+	case ecode.Canceled.Code():
+		return 499
+	case ecode.ServiceUnavailable.Code():
+		return http.StatusServiceUnavailable
+	case ecode.Deadline.Code():
+		return http.StatusGatewayTimeout
+	case ecode.LimitExceed.Code():
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+func marshalAny(data interface{}) (*types.Any, error) {
+	any := new(types.Any)
+	if data == nil {
+		return any, nil
+	}
+	msg, ok := data.(proto.Message)
+	if !ok {
+		return nil, errors.Errorf("blademaster: %T is not a proto.Message", data)
+	}
+	bytes, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	any.TypeUrl = "type.googleapis.com/" + proto.MessageName(msg)
+	any.Value = bytes
+	return any, nil
+}
+
+type kratosEnvelope struct{}
+
+func (kratosEnvelope) Status(ecode.Codes) int {
+	// TODO app allow 5xx?
+	return http.StatusOK
+}
+
+func (kratosEnvelope) Render(format string, bcode ecode.Codes, data interface{}) (render.Render, error) {
+	switch format {
+	case binding.MIMEXML:
+		return render.XML{Code: bcode.Code(), Message: bcode.Message(), Data: data}, nil
+	case binding.MIMEMSGPACK:
+		return render.MsgPack{Code: bcode.Code(), Message: bcode.Message(), Data: data}, nil
+	case binding.MIMEPROTOBUF:
+		any, err := marshalAny(data)
+		if err != nil {
+			return nil, err
+		}
+		return render.PB{Code: int64(bcode.Code()), Message: bcode.Message(), Data: any}, nil
+	}
+	return render.JSON{Code: bcode.Code(), Message: bcode.Message(), Data: data}, nil
+}
+
+type problemEnvelope struct{}
+
+func (problemEnvelope) Status(bcode ecode.Codes) int {
+	return HTTPStatus(bcode)
+}
+
+func (e problemEnvelope) Render(format string, bcode ecode.Codes, data interface{}) (render.Render, error) {
+	if bcode.Code() == ecode.OK.Code() {
+		switch format {
+		case binding.MIMEXML:
+			return render.XML{Data: data}, nil
+		case binding.MIMEMSGPACK:
+			return render.PlainMsgPack{Data: data}, nil
+		case binding.MIMEPROTOBUF:
+			msg, ok := data.(proto.Message)
+			if !ok {
+				return nil, errors.Errorf("blademaster: %T is not a proto.Message", data)
+			}
+			bytes, err := proto.Marshal(msg)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			return render.Data{ContentType: binding.MIMEPROTOBUF, Data: [][]byte{bytes}}, nil
+		}
+		return render.PlainJSON{Data: data}, nil
+	}
+	status := e.Status(bcode)
+	problem := render.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: bcode.Message(),
+		Code:   bcode.Code(),
+	}
+	// protobuf has no problem details representation, the problem is
+	// written as application/problem+json like JSON.
+	switch format {
+	case binding.MIMEXML:
+		return render.XML{Data: problem}, nil
+	case binding.MIMEMSGPACK:
+		return render.PlainMsgPack{Data: problem}, nil
+	}
+	return problem, nil
+}
@@ -0,0 +1,97 @@
+package blademaster
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/ecode"
+	"github.com/go-kratos/kratos/pkg/net/http/blademaster/render"
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	assert.Equal(t, http.StatusOK, HTTPStatus(ecode.OK))
+	assert.Equal(t, http.StatusBadRequest, HTTPStatus(ecode.RequestErr))
+	assert.Equal(t, http.StatusNotFound, HTTPStatus(ecode.Error(ecode.NothingFound, "no user")))
+	assert.Equal(t, http.StatusTooManyRequests, HTTPStatus(ecode.LimitExceed))
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatus(ecode.New(10086)))
+}
+
+func TestEnvelope(t *testing.T) {
+	engine := NewServer(&ServerConfig{Timeout: xtime.Duration(time.Second)})
+	engine.GET("/kratos", func(c *Context) {
+		c.JSON(map[string]string{"foo": "bar"}, nil)
+	})
+	public := engine.Group("/public").SetEnvelope(ProblemEnvelope)
+	public.GET("/ok", func(c *Context) {
+		c.JSON(map[string]string{"foo": "bar"}, nil)
+	})
+	public.Group("/v1").GET("/err", func(c *Context) {
+		c.JSON(nil, ecode.Error(ecode.NothingFound, "no user"))
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/kratos", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"code":0,"message":"0","ttl":1,"data":{"foo":"bar"}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/public/ok", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"foo":"bar"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/public/v1/err", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/problem+json; charset=utf-8", w.Header().Get("Content-Type"))
+	problem := render.Problem{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, "no user", problem.Detail)
+	assert.Equal(t, ecode.NothingFound.Code(), problem.Code)
+
+	// the envelope of engine applies to the routes without one
+	engine.SetEnvelope(ProblemEnvelope)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/kratos", nil))
+	assert.JSONEq(t, `{"foo":"bar"}`, w.Body.String())
+}
+
+func TestEnvelopeXMLAndProtobuf(t *testing.T) {
+	engine := NewServer(&ServerConfig{Timeout: xtime.Duration(time.Second)})
+	engine.GET("/kratos", func(c *Context) {
+		c.XML(nil, ecode.RequestErr)
+	})
+	public := engine.Group("/public").SetEnvelope(ProblemEnvelope)
+	public.GET("/xml", func(c *Context) {
+		c.XML(nil, ecode.Error(ecode.NothingFound, "no user"))
+	})
+	public.GET("/pb", func(c *Context) {
+		c.Protobuf(nil, ecode.Error(ecode.NothingFound, "no user"))
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/kratos", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", w.Body.String())
+	assert.Equal(t, "-400", w.Header().Get("kratos-status-code"))
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/public/xml", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, `<problem xmlns="urn:ietf:rfc:7807"><type>about:blank</type><title>Not Found</title><status>404</status><detail>no user</detail><code>-404</code></problem>`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/public/pb", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/problem+json; charset=utf-8", w.Header().Get("Content-Type"))
+	problem := render.Problem{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "no user", problem.Detail)
+	assert.Equal(t, ecode.NothingFound.Code(), problem.Code)
+}
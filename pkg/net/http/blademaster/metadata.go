@@ -54,6 +54,10 @@ var _parser = map[string]func(string) interface{}{
 func parseMetadataTo(req *http.Request, to metadata.MD) {
 	for rawKey := range req.Header {
 		key := strings.ReplaceAll(strings.TrimPrefix(strings.ToLower(rawKey), _httpHeaderMetadata), "-", "_")
+		// the principal is set only by the auth middlewares, never by the caller.
+		if key == metadata.Principal {
+			continue
+		}
 		rawValue := req.Header.Get(rawKey)
 		var value interface{} = rawValue
 		parser, ok := _parser[key]
@@ -30,6 +30,13 @@ var (
 		Help:      "http server bbr total.",
 		Labels:    []string{"url", "method"},
 	})
+	_metricServerQuota = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: serverNamespace,
+		Subsystem: "",
+		Name:      "quota_total",
+		Help:      "http server requests over quota total.",
+		Labels:    []string{"path", "method", "name"},
+	})
 	_metricClientReqDur = metric.NewHistogramVec(&metric.HistogramVecOpts{
 		Namespace: clientNamespace,
 		Subsystem: "requests",
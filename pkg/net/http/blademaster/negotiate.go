@@ -0,0 +1,113 @@
+package blademaster
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-kratos/kratos/pkg/ecode"
+	"github.com/go-kratos/kratos/pkg/net/http/blademaster/binding"
+	"github.com/go-kratos/kratos/pkg/net/http/blademaster/render"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+type acceptSpec struct {
+	value string
+	q     float64
+}
+
+// parseAccept parses the Accept header into media ranges sorted by quality,
+// media ranges with the same quality keep the order of the header.
+func parseAccept(header string) []acceptSpec {
+	parts := strings.Split(header, ",")
+	specs := make([]acceptSpec, 0, len(parts))
+	for _, part := range parts {
+		params := strings.Split(part, ";")
+		spec := acceptSpec{value: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		if spec.value == "" {
+			continue
+		}
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) != 2 || strings.TrimSpace(kv[0]) != "q" {
+				continue
+			}
+			if q, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64); err == nil {
+				spec.q = q
+			}
+		}
+		if spec.q <= 0 {
+			continue
+		}
+		specs = append(specs, spec)
+	}
+	sort.SliceStable(specs, func(i, j int) bool {
+		return specs[i].q > specs[j].q
+	})
+	return specs
+}
+
+func matchAccept(accepted, offered string) bool {
+	if accepted == "*/*" || accepted == offered {
+		return true
+	}
+	if strings.HasSuffix(accepted, "/*") {
+		return strings.HasPrefix(offered, accepted[:len(accepted)-1])
+	}
+	return false
+}
+
+// NegotiateFormat returns the offered content type which best matches the
+// Accept header of the request. The first offered type is returned when the
+// request has no Accept header, and an empty string when nothing matches.
+func (c *Context) NegotiateFormat(offered ...string) string {
+	if len(offered) == 0 {
+		return ""
+	}
+	accept := c.Request.Header.Get("Accept")
+	if accept == "" {
+		return offered[0]
+	}
+	for _, spec := range parseAccept(accept) {
+		for _, o := range offered {
+			if matchAccept(spec.value, o) {
+				return o
+			}
+		}
+	}
+	return ""
+}
+
+// Negotiate serializes the given data into the response body with the
+// renderer picked from the Accept header of the request.
+// JSON, XML, MsgPack and Protobuf (only if data is a proto.Message) are offered,
+// and JSON is used when none of them is acceptable.
+// Every renderer keeps the same code and message mapping from err as Context.JSON,
+// XML is wrapped in the code and message envelope with DefaultEnvelope unlike
+// Context.XML.
+func (c *Context) Negotiate(data interface{}, err error) {
+	offered := []string{binding.MIMEJSON, binding.MIMEXML, binding.MIMEXML2, binding.MIMEMSGPACK, binding.MIMEMSGPACK2}
+	msg, isPB := data.(proto.Message)
+	if isPB || data == nil {
+		offered = append(offered, binding.MIMEPROTOBUF)
+	}
+	switch c.NegotiateFormat(offered...) {
+	case binding.MIMEXML, binding.MIMEXML2:
+		if _, ok := c.Envelope().(kratosEnvelope); ok {
+			c.Error = err
+			bcode := ecode.Cause(err)
+			writeStatusCode(c.Writer, bcode.Code())
+			c.Render(http.StatusOK, render.EnvelopedXML{Code: bcode.Code(), Message: bcode.Message(), Data: data})
+			return
+		}
+		c.XML(data, err)
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		c.MsgPack(data, err)
+	case binding.MIMEPROTOBUF:
+		c.Protobuf(msg, err)
+	default:
+		c.JSON(data, err)
+	}
+}
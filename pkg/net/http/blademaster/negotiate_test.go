@@ -0,0 +1,122 @@
+package blademaster
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kratos/kratos/pkg/ecode"
+	"github.com/go-kratos/kratos/pkg/net/http/blademaster/render"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+)
+
+func newNegotiateContext(accept string) (*Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest("GET", "/negotiate", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	return &Context{Request: req, Writer: w}, w
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		accept   string
+		offered  []string
+		expected string
+	}{
+		{"", []string{"application/json", "application/xml"}, "application/json"},
+		{"application/xml", []string{"application/json", "application/xml"}, "application/xml"},
+		{"text/html, application/xml;q=0.9, */*;q=0.8", []string{"application/json", "application/xml"}, "application/xml"},
+		{"application/json;q=0.5, application/x-msgpack", []string{"application/json", "application/x-msgpack"}, "application/x-msgpack"},
+		{"application/*", []string{"text/plain", "application/json"}, "application/json"},
+		{"text/html, application/json;q=0", []string{"application/json"}, ""},
+	}
+	for _, test := range tests {
+		c, _ := newNegotiateContext(test.accept)
+		assert.Equal(t, test.expected, c.NegotiateFormat(test.offered...), test.accept)
+	}
+}
+
+func TestNegotiateJSON(t *testing.T) {
+	c, w := newNegotiateContext("text/html, */*")
+	c.Negotiate(map[string]string{"foo": "bar"}, ecode.RequestErr)
+
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	resp := struct {
+		Code    int               `json:"code"`
+		Message string            `json:"message"`
+		TTL     int               `json:"ttl"`
+		Data    map[string]string `json:"data"`
+	}{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, ecode.RequestErr.Code(), resp.Code)
+	assert.Equal(t, 1, resp.TTL)
+	assert.Equal(t, "bar", resp.Data["foo"])
+}
+
+type negotiateXML struct {
+	Foo string
+}
+
+func TestNegotiateXML(t *testing.T) {
+	c, w := newNegotiateContext("application/xml")
+	c.Negotiate(&negotiateXML{Foo: "bar"}, nil)
+
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "<response><code>0</code><message>0</message><ttl>1</ttl><data><Foo>bar</Foo></data></response>", w.Body.String())
+
+	c, w = newNegotiateContext("text/xml")
+	c.Negotiate(nil, ecode.NothingFound)
+	assert.Equal(t, "-404", w.Header().Get("kratos-status-code"))
+	assert.Equal(t, "<response><code>-404</code><message>-404</message><ttl>1</ttl></response>", w.Body.String())
+}
+
+func TestXMLPlainBody(t *testing.T) {
+	c, w := newNegotiateContext("application/xml")
+	c.XML(&negotiateXML{Foo: "bar"}, nil)
+
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "<negotiateXML><Foo>bar</Foo></negotiateXML>", w.Body.String())
+}
+
+func TestNegotiateMsgPack(t *testing.T) {
+	c, w := newNegotiateContext("application/x-msgpack")
+	c.Negotiate(map[string]string{"foo": "bar"}, ecode.NothingFound)
+
+	assert.Equal(t, "application/x-msgpack; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "-404", w.Header().Get("kratos-status-code"))
+	resp := struct {
+		Code int               `codec:"code"`
+		TTL  int               `codec:"ttl"`
+		Data map[string]string `codec:"data"`
+	}{}
+	assert.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), new(codec.MsgpackHandle)).Decode(&resp))
+	assert.Equal(t, ecode.NothingFound.Code(), resp.Code)
+	assert.Equal(t, 1, resp.TTL)
+	assert.Equal(t, "bar", resp.Data["foo"])
+}
+
+func TestNegotiateProtobuf(t *testing.T) {
+	c, w := newNegotiateContext("application/x-protobuf")
+	c.Negotiate(&types.StringValue{Value: "bar"}, nil)
+
+	assert.Equal(t, "application/x-protobuf", w.Header().Get("Content-Type"))
+	resp := new(render.PB)
+	assert.NoError(t, proto.Unmarshal(w.Body.Bytes(), resp))
+	assert.Equal(t, uint64(1), resp.TTL)
+	value := new(types.StringValue)
+	assert.NoError(t, types.UnmarshalAny(resp.Data, value))
+	assert.Equal(t, "bar", value.Value)
+
+	// data which is not a proto message falls back to JSON
+	c, w = newNegotiateContext("application/x-protobuf")
+	c.Negotiate(map[string]string{"foo": "bar"}, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+}
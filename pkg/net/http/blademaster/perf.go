@@ -8,6 +8,8 @@ import (
 	"sync"
 
 	"github.com/go-kratos/kratos/pkg/conf/dsn"
+	"github.com/go-kratos/kratos/pkg/database/sqlstat"
+	"github.com/go-kratos/kratos/pkg/log"
 
 	"github.com/pkg/errors"
 )
@@ -40,9 +42,16 @@ func startPerf(engine *Engine) {
 				prefixRouter.GET("/mutex", pprofHandler(pprof.Handler("mutex").ServeHTTP))
 				prefixRouter.GET("/threadcreate", pprofHandler(pprof.Handler("threadcreate").ServeHTTP))
 			}
+			// changing the log setting and resetting the sql stats are only
+			// served on the perf listener.
+			engine.GET("/debug/log", pprofHandler(log.ServeSetting))
+			engine.GET("/debug/sql", pprofHandler(sqlstat.ServeTop))
+			engine.DELETE("/debug/sql", pprofHandler(sqlstat.ServeTop))
 			return
 		}
 
+		http.HandleFunc("/debug/log", log.ServeSetting)
+		http.HandleFunc("/debug/sql", sqlstat.ServeTop)
 		go func() {
 			d, err := dsn.Parse(_perfDSN)
 			if err != nil {
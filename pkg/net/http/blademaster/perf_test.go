@@ -0,0 +1,38 @@
+package blademaster
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/database/sqlstat"
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPerfSQLStat(t *testing.T) {
+	t.Setenv("HTTP_PERF", "")
+	_perfOnce = sync.Once{}
+	sqlstat.Reset()
+	defer sqlstat.Reset()
+	sqlstat.Record("perf", "SELECT * FROM user WHERE id=1", time.Millisecond, nil)
+	engine := NewServer(&ServerConfig{Timeout: xtime.Duration(time.Second)})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/debug/sql?db=perf", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"fingerprint":"select * from user where id=?"`)
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("DELETE", "/debug/sql", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/debug/sql?db=perf", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[]", strings.TrimSpace(w.Body.String()))
+}
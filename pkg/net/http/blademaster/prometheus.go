@@ -1,12 +1,18 @@
 package blademaster
 
 import (
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// _metricHandler exposes OpenMetrics if accepted so that the exemplars are exposed.
+var _metricHandler = promhttp.InstrumentMetricHandler(
+	prometheus.DefaultRegisterer,
+	promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+)
+
 func monitor() HandlerFunc {
 	return func(c *Context) {
-		h := promhttp.Handler()
-		h.ServeHTTP(c.Writer, c.Request)
+		_metricHandler.ServeHTTP(c.Writer, c.Request)
 	}
 }
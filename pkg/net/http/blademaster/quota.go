@@ -0,0 +1,104 @@
+package blademaster
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/go-kratos/kratos/pkg/ecode"
+	"github.com/go-kratos/kratos/pkg/log"
+	"github.com/go-kratos/kratos/pkg/net/metadata"
+	"github.com/go-kratos/kratos/pkg/ratelimit/quota"
+)
+
+// QuotaKeyFunc returns the key counting the quota of the request,
+// the request is not limited if the key is empty.
+type QuotaKeyFunc func(c *Context) string
+
+// QuotaKeyByIP counts the quota by client ip.
+func QuotaKeyByIP() QuotaKeyFunc {
+	return func(c *Context) string {
+		if ip := c.RemoteIP(); ip != "" {
+			return ip
+		}
+		return remoteIP(c.Request)
+	}
+}
+
+// QuotaKeyByMid counts the quota by metadata.Mid, requests without mid are not limited.
+func QuotaKeyByMid() QuotaKeyFunc {
+	return func(c *Context) string {
+		mid := metadata.Value(c, metadata.Mid)
+		if mid == nil {
+			return ""
+		}
+		return fmt.Sprint(mid)
+	}
+}
+
+// QuotaKeyByHeader counts the quota by the value of header key, requests without it are not limited.
+func QuotaKeyByHeader(key string) QuotaKeyFunc {
+	return func(c *Context) string {
+		return c.Request.Header.Get(key)
+	}
+}
+
+// QuotaKeyByRoute counts the quota by the route path.
+func QuotaKeyByRoute() QuotaKeyFunc {
+	return func(c *Context) string {
+		return c.RoutePath
+	}
+}
+
+// Quota is a middleware limiting requests by token bucket or sliding window
+// quota, it's used by RouterGroup.Use or as a handler of the route.
+type Quota struct {
+	name  string
+	rule  *quota.Rule
+	store quota.Store
+	key   QuotaKeyFunc
+}
+
+// NewQuota returns a quota middleware, the name prefixes the keys so quotas
+// can share the same store, the local store is used if store is nil. It panics
+// if the rule is invalid.
+func NewQuota(name string, rule *quota.Rule, store quota.Store, key QuotaKeyFunc) *Quota {
+	if err := rule.Validate(); err != nil {
+		panic(err)
+	}
+	if store == nil {
+		store = quota.NewLocalStore()
+	}
+	return &Quota{
+		name:  name,
+		rule:  rule,
+		store: store,
+		key:   key,
+	}
+}
+
+// ServeHTTP implements Handler, requests over quota are responded with
+// ecode.LimitExceed and the Retry-After header.
+func (q *Quota) ServeHTTP(c *Context) {
+	key := q.key(c)
+	if key == "" {
+		return
+	}
+	res, err := q.store.Take(c, q.name+":"+key, q.rule, 1)
+	if err != nil {
+		// let requests pass when the store is broken
+		log.Error("bm: quota(%s) take key(%s) error(%v)", q.name, key, err)
+		return
+	}
+	if res.Allowed {
+		return
+	}
+	_metricServerQuota.Inc(c.RoutePath, c.Request.Method, q.name)
+	retryAfter := int64(math.Ceil(res.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Writer.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+	c.JSON(nil, ecode.LimitExceed)
+	c.Abort()
+}
@@ -0,0 +1,51 @@
+package blademaster
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/ratelimit/quota"
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuota(t *testing.T) {
+	engine := NewServer(&ServerConfig{Timeout: xtime.Duration(time.Second)})
+	rule := &quota.Rule{Algorithm: quota.SlidingWindow, Limit: 2, Window: xtime.Duration(time.Minute)}
+	g := engine.Group("/quota").SetEnvelope(ProblemEnvelope)
+	g.Use(NewQuota("ip", rule, nil, QuotaKeyByHeader("X-Client")))
+	g.GET("/api", func(c *Context) {
+		c.JSON("ok", nil)
+	})
+
+	do := func(client string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/quota/api", nil)
+		if client != "" {
+			req.Header.Set("X-Client", client)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusOK, do("foo").Code)
+	}
+	w := do("foo")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, do("bar").Code)
+	// requests without key are not limited
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, do("").Code)
+	}
+}
+
+func TestQuotaInvalidRule(t *testing.T) {
+	assert.PanicsWithValue(t, quota.ErrInvalidRule, func() {
+		NewQuota("ip", &quota.Rule{Window: xtime.Duration(time.Minute)}, nil, QuotaKeyByHeader("X-Client"))
+	})
+}
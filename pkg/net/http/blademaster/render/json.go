@@ -56,3 +56,18 @@ func (m MapJSON) Render(w http.ResponseWriter) error {
 func (m MapJSON) WriteContentType(w http.ResponseWriter) {
 	writeContentType(w, jsonContentType)
 }
+
+// PlainJSON json struct without the code, message and ttl envelope.
+type PlainJSON struct {
+	Data interface{}
+}
+
+// Render (PlainJSON) writes data with json ContentType.
+func (r PlainJSON) Render(w http.ResponseWriter) error {
+	return writeJSON(w, r.Data)
+}
+
+// WriteContentType write json ContentType.
+func (r PlainJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
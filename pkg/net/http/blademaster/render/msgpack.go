@@ -0,0 +1,54 @@
+package render
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/ugorji/go/codec"
+)
+
+var msgpackContentType = []string{"application/x-msgpack; charset=utf-8"}
+
+// MsgPack common msgpack struct.
+type MsgPack struct {
+	Code    int         `codec:"code"`
+	Message string      `codec:"message"`
+	TTL     int         `codec:"ttl"`
+	Data    interface{} `codec:"data,omitempty"`
+}
+
+func writeMsgPack(w http.ResponseWriter, obj interface{}) (err error) {
+	writeContentType(w, msgpackContentType)
+	if err = codec.NewEncoder(w, new(codec.MsgpackHandle)).Encode(obj); err != nil {
+		err = errors.WithStack(err)
+	}
+	return
+}
+
+// Render (MsgPack) writes data with msgpack ContentType.
+func (r MsgPack) Render(w http.ResponseWriter) error {
+	if r.TTL <= 0 {
+		r.TTL = 1
+	}
+	return writeMsgPack(w, r)
+}
+
+// WriteContentType write msgpack ContentType.
+func (r MsgPack) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, msgpackContentType)
+}
+
+// PlainMsgPack msgpack struct without the code, message and ttl envelope.
+type PlainMsgPack struct {
+	Data interface{}
+}
+
+// Render (PlainMsgPack) writes data with msgpack ContentType.
+func (r PlainMsgPack) Render(w http.ResponseWriter) error {
+	return writeMsgPack(w, r.Data)
+}
+
+// WriteContentType write msgpack ContentType.
+func (r PlainMsgPack) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, msgpackContentType)
+}
@@ -0,0 +1,30 @@
+package render
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+var problemContentType = []string{"application/problem+json; charset=utf-8"}
+
+// Problem RFC 7807 problem details struct.
+type Problem struct {
+	XMLName  xml.Name `json:"-" xml:"urn:ietf:rfc:7807 problem" codec:"-"`
+	Type     string   `json:"type,omitempty" xml:"type,omitempty" codec:"type,omitempty"`
+	Title    string   `json:"title,omitempty" xml:"title,omitempty" codec:"title,omitempty"`
+	Status   int      `json:"status,omitempty" xml:"status,omitempty" codec:"status,omitempty"`
+	Detail   string   `json:"detail,omitempty" xml:"detail,omitempty" codec:"detail,omitempty"`
+	Instance string   `json:"instance,omitempty" xml:"instance,omitempty" codec:"instance,omitempty"`
+	Code     int      `json:"code" xml:"code" codec:"code"`
+}
+
+// Render (Problem) writes data with problem+json ContentType.
+func (r Problem) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return writeJSON(w, r)
+}
+
+// WriteContentType write problem+json ContentType.
+func (r Problem) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, problemContentType)
+}
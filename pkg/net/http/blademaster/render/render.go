@@ -20,6 +20,10 @@ var (
 	_ Render = Redirect{}
 	_ Render = Data{}
 	_ Render = PB{}
+	_ Render = MsgPack{}
+	_ Render = PlainJSON{}
+	_ Render = PlainMsgPack{}
+	_ Render = Problem{}
 )
 
 func writeContentType(w http.ResponseWriter, value []string) {
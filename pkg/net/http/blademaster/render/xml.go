@@ -16,16 +16,42 @@ type XML struct {
 
 var xmlContentType = []string{"application/xml; charset=utf-8"}
 
-// Render (XML) writes data with xml ContentType.
-func (r XML) Render(w http.ResponseWriter) (err error) {
-	r.WriteContentType(w)
-	if err = xml.NewEncoder(w).Encode(r.Data); err != nil {
+func writeXML(w http.ResponseWriter, obj interface{}) (err error) {
+	writeContentType(w, xmlContentType)
+	if err = xml.NewEncoder(w).Encode(obj); err != nil {
 		err = errors.WithStack(err)
 	}
 	return
 }
 
+// Render (XML) writes data with xml ContentType.
+func (r XML) Render(w http.ResponseWriter) error {
+	return writeXML(w, r.Data)
+}
+
 // WriteContentType write xml ContentType.
 func (r XML) WriteContentType(w http.ResponseWriter) {
 	writeContentType(w, xmlContentType)
 }
+
+// EnvelopedXML xml struct with the code, message and ttl envelope like JSON.
+type EnvelopedXML struct {
+	XMLName xml.Name    `xml:"response"`
+	Code    int         `xml:"code"`
+	Message string      `xml:"message"`
+	TTL     int         `xml:"ttl"`
+	Data    interface{} `xml:"data,omitempty"`
+}
+
+// Render (EnvelopedXML) writes data with xml ContentType.
+func (r EnvelopedXML) Render(w http.ResponseWriter) error {
+	if r.TTL <= 0 {
+		r.TTL = 1
+	}
+	return writeXML(w, r)
+}
+
+// WriteContentType write xml ContentType.
+func (r EnvelopedXML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, xmlContentType)
+}
@@ -31,6 +31,7 @@ type RouterGroup struct {
 	engine     *Engine
 	root       bool
 	baseConfig *MethodConfig
+	envelope   Envelope
 }
 
 var _ IRouter = &RouterGroup{}
@@ -52,12 +53,17 @@ func (group *RouterGroup) UseFunc(middleware ...HandlerFunc) IRoutes {
 // Group creates a new router group. You should add all the routes that have common middlwares or the same path prefix.
 // For example, all the routes that use a common middlware for authorization could be grouped.
 func (group *RouterGroup) Group(relativePath string, handlers ...HandlerFunc) *RouterGroup {
-	return &RouterGroup{
+	g := &RouterGroup{
 		Handlers: group.combineHandlers(handlers),
 		basePath: group.calculateAbsolutePath(relativePath),
 		engine:   group.engine,
 		root:     false,
 	}
+	// the envelope of root group is looked up from engine on each request.
+	if !group.root {
+		g.envelope = group.envelope
+	}
+	return g
 }
 
 // SetMethodConfig is used to set config on specified method
@@ -66,6 +72,13 @@ func (group *RouterGroup) SetMethodConfig(config *MethodConfig) *RouterGroup {
 	return group
 }
 
+// SetEnvelope is used to set the response envelope of the routes registered
+// after it, the envelope set on engine is used by all the routes without one.
+func (group *RouterGroup) SetEnvelope(envelope Envelope) *RouterGroup {
+	group.envelope = envelope
+	return group
+}
+
 // BasePath router group base path.
 func (group *RouterGroup) BasePath() string {
 	return group.basePath
@@ -75,6 +88,11 @@ func (group *RouterGroup) handle(httpMethod, relativePath string, handlers ...Ha
 	absolutePath := group.calculateAbsolutePath(relativePath)
 	injections := group.injections(relativePath)
 	handlers = group.combineHandlers(injections, handlers)
+	if envelope := group.envelope; envelope != nil && !group.root {
+		handlers = append([]HandlerFunc{func(c *Context) {
+			c.envelope = envelope
+		}}, handlers...)
+	}
 	group.engine.addRoute(httpMethod, absolutePath, handlers...)
 	if group.baseConfig != nil {
 		group.engine.SetMethodConfig(absolutePath, group.baseConfig)
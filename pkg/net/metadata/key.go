@@ -36,6 +36,9 @@ const (
 
 	// Criticality 重要性
 	Criticality = "criticality"
+
+	// Principal 认证后的调用方身份
+	Principal = "principal"
 )
 
 var outgoingKey = map[string]struct{}{
@@ -44,6 +47,7 @@ var outgoingKey = map[string]struct{}{
 	RemotePort:  {},
 	Mirror:      {},
 	Criticality: {},
+	Principal:   {},
 }
 
 var incomingKey = map[string]struct{}{
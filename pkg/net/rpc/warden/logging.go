@@ -88,7 +88,7 @@ func clientLogging(dialOptions ...grpc.DialOption) grpc.UnaryClientInterceptor {
 		code := ecode.Cause(err).Code()
 		duration := time.Since(startTime)
 		// monitor
-		_metricClientReqDur.Observe(int64(duration/time.Millisecond), method)
+		_metricClientReqDur.ObserveContext(ctx, int64(duration/time.Millisecond), method)
 		_metricClientReqCodeTotal.Inc(method, strconv.Itoa(code))
 
 		if logFlag&LogFlagDisable != 0 {
@@ -142,7 +142,7 @@ func serverLogging(logFlag int8) grpc.UnaryServerInterceptor {
 		code := ecode.Cause(err).Code()
 		duration := time.Since(startTime)
 		// monitor
-		_metricServerReqDur.Observe(int64(duration/time.Millisecond), info.FullMethod, caller)
+		_metricServerReqDur.ObserveContext(ctx, int64(duration/time.Millisecond), info.FullMethod, caller)
 		_metricServerReqCodeTotal.Inc(info.FullMethod, caller, strconv.Itoa(code))
 
 		if logFlag&LogFlagDisable != 0 {
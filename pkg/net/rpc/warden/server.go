@@ -113,6 +113,8 @@ func (s *Server) handle() grpc.UnaryServerInterceptor {
 		if gmd, ok := metadata.FromIncomingContext(ctx); ok {
 			t, _ = trace.Extract(trace.GRPCFormat, gmd)
 			for key, vals := range gmd {
+				// the principal from the warden client is kept, the one forged by
+				// an external caller is stripped by the blademaster edge.
 				if nmd.IsIncomingKey(key) {
 					cmd[key] = vals[0]
 				}
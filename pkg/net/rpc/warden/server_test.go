@@ -580,6 +580,7 @@ func TestMetadata(t *testing.T) {
 		assert.Equal(t, "red", nmd.String(ctx, nmd.Color))
 		assert.Equal(t, "2.2.3.3", nmd.String(ctx, nmd.RemoteIP))
 		assert.Equal(t, "2233", nmd.String(ctx, nmd.RemotePort))
+		assert.Equal(t, "admin", nmd.String(ctx, nmd.Principal))
 		return &pb.HelloReply{}, nil
 	}, nil, nil)
 	defer cancel()
@@ -588,6 +589,7 @@ func TestMetadata(t *testing.T) {
 		nmd.Color:      "red",
 		nmd.RemoteIP:   "2.2.3.3",
 		nmd.RemotePort: "2233",
+		nmd.Principal:  "admin",
 	})
 	_, err := cli.SayHello(ctx, &pb.HelloRequest{Name: "test"})
 	assert.Nil(t, err)
@@ -13,6 +13,13 @@ import (
 	xtime "github.com/go-kratos/kratos/pkg/time"
 )
 
+// Sampler names of Config.Sampler.
+const (
+	SamplerProbability  = "probability"
+	SamplerRateLimiting = "ratelimiting"
+	SamplerAdaptive     = "adaptive"
+)
+
 var _traceDSN = "unixgram:///var/run/dapper-collect/dapper-collect.sock"
 
 func init() {
@@ -35,8 +42,25 @@ type Config struct {
 	DisableSample bool `dsn:"query.disable_sample"`
 	// ProtocolVersion
 	ProtocolVersion int32 `dsn:"query.protocol_version,1"`
-	// Probability probability sampling
+	// Probability probability sampling, the lower bound probability for adaptive sampler.
 	Probability float32 `dsn:"-"`
+	// Sampler sampling strategy: probability, ratelimiting or adaptive, default is probability.
+	Sampler string `dsn:"query.sampler"`
+	// SamplingRate traces per second per operation for ratelimiting and adaptive sampler.
+	SamplingRate float64 `dsn:"query.sampling_rate,1"`
+	// TailSampling keeps the unsampled traces containing errors or slower than TailLatency.
+	TailSampling bool `dsn:"query.tail_sampling"`
+	// TailLatency latency threshold of the local root span.
+	TailLatency xtime.Duration `dsn:"query.tail_latency,1s"`
+	// TailTimeout max time to wait for the local root span.
+	TailTimeout xtime.Duration `dsn:"query.tail_timeout,10s"`
+	// TailMaxTraces max traces buffered in process.
+	TailMaxTraces int `dsn:"query.tail_max_traces,10000"`
+	// RedactStatement replaces literal values of db.statement with ?.
+	RedactStatement bool `dsn:"query.redact_statement"`
+	// Propagation formats injected into the outgoing requests, e.g. kratos, w3c, b3, b3multi.
+	// Extract accepts all of the formats, default is kratos.
+	Propagation []string `dsn:"query.propagation"`
 }
 
 func parseDSN(rawdsn string) (*Config, error) {
@@ -57,8 +81,32 @@ func TracerFromEnvFlag() (Tracer, error) {
 	if err != nil {
 		return nil, err
 	}
+	SetRedactStatement(cfg.RedactStatement)
 	report := newReport(cfg.Network, cfg.Addr, time.Duration(cfg.Timeout), cfg.ProtocolVersion)
-	return NewTracer(env.AppID, report, cfg.DisableSample), nil
+	return NewTracer(env.AppID, report, cfg.DisableSample, cfg.TracerOptions()...), nil
+}
+
+// TracerOptions returns the tracer options of propagation and sampling.
+func (c *Config) TracerOptions() []TracerOption {
+	opts := []TracerOption{WithPropagation(c.Propagation...)}
+	switch c.Sampler {
+	case SamplerRateLimiting:
+		if c.SamplingRate > 0 {
+			opts = append(opts, WithRateLimitingSampler(c.SamplingRate))
+		}
+	case SamplerAdaptive:
+		if c.SamplingRate > 0 {
+			opts = append(opts, WithAdaptiveSampler(c.SamplingRate, float64(c.Probability)))
+		}
+	default:
+		if c.Probability > 0 && c.Probability <= 1 {
+			opts = append(opts, WithProbabilitySampler(c.Probability))
+		}
+	}
+	if c.TailSampling {
+		opts = append(opts, WithTailSampling(time.Duration(c.TailLatency), time.Duration(c.TailTimeout), c.TailMaxTraces))
+	}
+	return opts
 }
 
 // Init init trace report.
@@ -70,6 +118,7 @@ func Init(cfg *Config) {
 			panic(fmt.Errorf("parse trace dsn error: %s", err))
 		}
 	}
+	SetRedactStatement(cfg.RedactStatement)
 	report := newReport(cfg.Network, cfg.Addr, time.Duration(cfg.Timeout), cfg.ProtocolVersion)
-	SetGlobalTracer(NewTracer(env.AppID, report, cfg.DisableSample))
+	SetGlobalTracer(NewTracer(env.AppID, report, cfg.DisableSample, cfg.TracerOptions()...))
 }
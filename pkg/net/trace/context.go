@@ -23,6 +23,10 @@ type spanContext struct {
 	// Usually generated as a random number.
 	TraceID uint64
 
+	// TraceIDHigh represents the high 64 bits of the 128 bits trace id
+	// extracted from W3C Trace Context or B3, it's 0 for kratos trace.
+	TraceIDHigh uint64
+
 	// SpanID represents span ID that must be unique within its trace,
 	// but does not have to be globally unique.
 	SpanID uint64
@@ -39,6 +43,13 @@ type spanContext struct {
 
 	// Level current level
 	Level int
+
+	// State is the W3C tracestate passed through.
+	State string
+
+	// recording the trace is not sampled but recorded for tail sampling, it's
+	// never propagated.
+	recording bool
 }
 
 func (c spanContext) isSampled() bool {
@@ -49,6 +60,16 @@ func (c spanContext) isDebug() bool {
 	return (c.Flags & flagDebug) == flagDebug
 }
 
+func (c spanContext) isRecording() bool {
+	return c.isSampled() || c.isDebug() || c.recording
+}
+
+// IsRecording reports whether the span is sampled or recorded for tail
+// sampling, that is, the span may be reported.
+func (c spanContext) IsRecording() bool {
+	return c.isRecording()
+}
+
 // IsValid check spanContext valid
 func (c spanContext) IsValid() bool {
 	return c.TraceID != 0 && c.SpanID != 0
@@ -14,13 +14,13 @@ const (
 )
 
 // NewTracer new a tracer.
-func NewTracer(serviceName string, report reporter, disableSample bool) Tracer {
+func NewTracer(serviceName string, report reporter, disableSample bool, opts ...TracerOption) Tracer {
 	sampler := newSampler(_probability)
 
 	// default internal tags
 	tags := extendTag()
 	stdlog := log.New(os.Stderr, "trace", log.LstdFlags)
-	return &dapper{
+	d := &dapper{
 		serviceName:   serviceName,
 		disableSample: disableSample,
 		propagators: map[interface{}]propagator{
@@ -33,17 +33,27 @@ func NewTracer(serviceName string, report reporter, disableSample bool) Tracer {
 		pool:     &sync.Pool{New: func() interface{} { return new(Span) }},
 		stdlog:   stdlog,
 	}
+	WithPropagation(PropagationKratos)(d)
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
 }
 
 type dapper struct {
 	serviceName   string
 	disableSample bool
-	tags          []Tag
-	reporter      reporter
-	propagators   map[interface{}]propagator
-	pool          *sync.Pool
-	stdlog        *log.Logger
-	sampler       sampler
+	// recordAll records the unsampled traces for tail sampling.
+	recordAll   bool
+	tags        []Tag
+	reporter    reporter
+	propagators map[interface{}]propagator
+	pool        *sync.Pool
+	stdlog      *log.Logger
+	sampler     sampler
+	// injectFormats are written by Inject, extractFormats are tried by Extract in order.
+	injectFormats  []wireFormat
+	extractFormats []wireFormat
 }
 
 func (d *dapper) New(operationName string, opts ...Option) Trace {
@@ -60,17 +70,26 @@ func (d *dapper) New(operationName string, opts ...Option) Trace {
 	} else {
 		sampled, probability = d.sampler.IsSampled(traceID, operationName)
 	}
-	pctx := spanContext{TraceID: traceID}
+	pctx := spanContext{TraceID: traceID, recording: d.recordAll}
 	if sampled {
 		pctx.Flags = flagSampled
 		pctx.Probability = probability
 	}
 	if opt.Debug {
 		pctx.Flags |= flagDebug
-		return d.newSpanWithContext(operationName, pctx).SetTag(TagString(TagSpanKind, "server")).SetTag(TagBool("debug", true))
+		return d.newRootSpan(operationName, pctx).SetTag(TagString(TagSpanKind, "server")).SetTag(TagBool("debug", true))
 	}
 	// 为了兼容临时为 New 的 Span 设置 span.kind
-	return d.newSpanWithContext(operationName, pctx).SetTag(TagString(TagSpanKind, "server"))
+	return d.newRootSpan(operationName, pctx).SetTag(TagString(TagSpanKind, "server"))
+}
+
+// newRootSpan new the first span of trace in this process.
+func (d *dapper) newRootSpan(operationName string, pctx spanContext) Trace {
+	t := d.newSpanWithContext(operationName, pctx)
+	if sp, ok := t.(*Span); ok {
+		sp.localRoot = true
+	}
+	return t
 }
 
 func (d *dapper) newSpanWithContext(operationName string, pctx spanContext) Trace {
@@ -86,10 +105,13 @@ func (d *dapper) newSpanWithContext(operationName string, pctx spanContext) Trac
 	}
 	level := pctx.Level + 1
 	nctx := spanContext{
-		TraceID:  pctx.TraceID,
-		ParentID: pctx.SpanID,
-		Flags:    pctx.Flags,
-		Level:    level,
+		TraceID:     pctx.TraceID,
+		TraceIDHigh: pctx.TraceIDHigh,
+		ParentID:    pctx.SpanID,
+		Flags:       pctx.Flags,
+		Level:       level,
+		State:       pctx.State,
+		recording:   pctx.recording,
 	}
 	if pctx.SpanID == 0 {
 		nctx.SpanID = pctx.TraceID
@@ -107,7 +129,7 @@ func (d *dapper) Inject(t Trace, format interface{}, carrier interface{}) error
 	// if carrier implement Carrier use direct, ignore format
 	carr, ok := carrier.(Carrier)
 	if ok {
-		t.Visit(carr.Set)
+		d.inject(t, carr)
 		return nil
 	}
 	// use Built-in propagators
@@ -120,11 +142,22 @@ func (d *dapper) Inject(t Trace, format interface{}, carrier interface{}) error
 		return err
 	}
 	if t != nil {
-		t.Visit(carr.Set)
+		d.inject(t, carr)
 	}
 	return nil
 }
 
+func (d *dapper) inject(t Trace, carr Carrier) {
+	sp, ok := t.(*Span)
+	if !ok {
+		t.Visit(carr.Set)
+		return
+	}
+	for _, f := range d.injectFormats {
+		f.inject(sp.context, carr)
+	}
+}
+
 func (d *dapper) Extract(format interface{}, carrier interface{}) (Trace, error) {
 	sp, err := d.extract(format, carrier)
 	if err != nil {
@@ -148,12 +181,21 @@ func (d *dapper) extract(format interface{}, carrier interface{}) (Trace, error)
 			return nil, err
 		}
 	}
-	pctx, err := contextFromString(carr.Get(KratosTraceID))
+	var (
+		pctx spanContext
+		err  error
+	)
+	for _, f := range d.extractFormats {
+		if pctx, err = f.extract(carr); err == nil {
+			break
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
+	pctx.recording = d.recordAll
 	// NOTE: call SetTitle after extract trace
-	return d.newSpanWithContext("", pctx), nil
+	return d.newRootSpan("", pctx), nil
 }
 
 func (d *dapper) Close() error {
@@ -161,7 +203,7 @@ func (d *dapper) Close() error {
 }
 
 func (d *dapper) report(sp *Span) {
-	if sp.context.isSampled() {
+	if sp.context.isSampled() || sp.context.recording {
 		if err := d.reporter.WriteSpan(sp); err != nil {
 			d.stdlog.Printf("marshal trace span error: %s", err)
 		}
@@ -183,6 +225,7 @@ func (d *dapper) getSpan() *Span {
 	sp := d.pool.Get().(*Span)
 	sp.dapper = d
 	sp.childs = 0
+	sp.localRoot = false
 	sp.tags = sp.tags[:0]
 	sp.logs = sp.logs[:0]
 	return sp
@@ -0,0 +1,258 @@
+package trace
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Propagation formats of span context on the wire.
+const (
+	// PropagationKratos the kratos-trace-id header.
+	PropagationKratos = "kratos"
+	// PropagationW3C the W3C Trace Context traceparent and tracestate headers.
+	PropagationW3C = "w3c"
+	// PropagationB3 the zipkin b3 single header.
+	PropagationB3 = "b3"
+	// PropagationB3Multi the zipkin x-b3-* headers.
+	PropagationB3Multi = "b3multi"
+)
+
+// W3C Trace Context and B3 keys, in lower case to be valid gRPC metadata keys.
+const (
+	W3CTraceParent = "traceparent"
+	W3CTraceState  = "tracestate"
+
+	B3Single       = "b3"
+	B3TraceID      = "x-b3-traceid"
+	B3SpanID       = "x-b3-spanid"
+	B3ParentSpanID = "x-b3-parentspanid"
+	B3Sampled      = "x-b3-sampled"
+	B3Flags        = "x-b3-flags"
+)
+
+// wireFormat injects and extracts span context into carrier in a wire format.
+type wireFormat interface {
+	inject(ctx spanContext, carr Carrier)
+	extract(carr Carrier) (spanContext, error)
+}
+
+var _wireFormats = map[string]wireFormat{
+	PropagationKratos:  kratosFormat{},
+	PropagationW3C:     w3cFormat{},
+	PropagationB3:      b3SingleFormat{},
+	PropagationB3Multi: b3MultiFormat{},
+}
+
+// _extractOrder is the order trying the formats not configured on extract.
+var _extractOrder = []string{PropagationKratos, PropagationW3C, PropagationB3, PropagationB3Multi}
+
+func formatTraceID(ctx spanContext) string {
+	if ctx.TraceIDHigh == 0 {
+		return fmt.Sprintf("%016x", ctx.TraceID)
+	}
+	return fmt.Sprintf("%016x%016x", ctx.TraceIDHigh, ctx.TraceID)
+}
+
+// parseTraceID parses the 64 or 128 bit hex trace id.
+func parseTraceID(s string) (high, low uint64, err error) {
+	switch {
+	case len(s) > 32:
+		err = errInvalidTracerString
+	case len(s) > 16:
+		if high, err = strconv.ParseUint(s[:len(s)-16], 16, 64); err != nil {
+			return 0, 0, errInvalidTracerString
+		}
+		if low, err = strconv.ParseUint(s[len(s)-16:], 16, 64); err != nil {
+			return 0, 0, errInvalidTracerString
+		}
+	default:
+		if low, err = strconv.ParseUint(s, 16, 64); err != nil {
+			return 0, 0, errInvalidTracerString
+		}
+	}
+	if err == nil && high == 0 && low == 0 {
+		err = errInvalidTracerString
+	}
+	return
+}
+
+func parseSpanID(s string) (uint64, error) {
+	if len(s) == 0 || len(s) > 16 {
+		return 0, errInvalidTracerString
+	}
+	id, err := strconv.ParseUint(s, 16, 64)
+	if err != nil || id == 0 {
+		return 0, errInvalidTracerString
+	}
+	return id, nil
+}
+
+type kratosFormat struct{}
+
+func (kratosFormat) inject(ctx spanContext, carr Carrier) {
+	carr.Set(KratosTraceID, ctx.String())
+}
+
+func (kratosFormat) extract(carr Carrier) (spanContext, error) {
+	return contextFromString(carr.Get(KratosTraceID))
+}
+
+// w3cFormat https://www.w3.org/TR/trace-context/
+type w3cFormat struct{}
+
+func (w3cFormat) inject(ctx spanContext, carr Carrier) {
+	flags := 0
+	if ctx.isSampled() {
+		flags = 1
+	}
+	carr.Set(W3CTraceParent, fmt.Sprintf("00-%016x%016x-%016x-%02x", ctx.TraceIDHigh, ctx.TraceID, ctx.SpanID, flags))
+	if ctx.State != "" {
+		carr.Set(W3CTraceState, ctx.State)
+	}
+}
+
+func (w3cFormat) extract(carr Carrier) (spanContext, error) {
+	value := strings.TrimSpace(carr.Get(W3CTraceParent))
+	if value == "" {
+		return emptyContext, errEmptyTracerString
+	}
+	items := strings.Split(value, "-")
+	// future versions may append fields.
+	if len(items) < 4 || len(items[0]) != 2 || items[0] == "ff" || (items[0] == "00" && len(items) != 4) {
+		return emptyContext, errInvalidTracerString
+	}
+	if len(items[1]) != 32 || len(items[2]) != 16 || len(items[3]) != 2 {
+		return emptyContext, errInvalidTracerString
+	}
+	high, low, err := parseTraceID(items[1])
+	if err != nil {
+		return emptyContext, err
+	}
+	spanID, err := parseSpanID(items[2])
+	if err != nil {
+		return emptyContext, err
+	}
+	flags, err := strconv.ParseUint(items[3], 16, 8)
+	if err != nil {
+		return emptyContext, errInvalidTracerString
+	}
+	ctx := spanContext{
+		TraceIDHigh: high,
+		TraceID:     low,
+		SpanID:      spanID,
+		State:       carr.Get(W3CTraceState),
+	}
+	if flags&0x01 == 0x01 {
+		ctx.Flags = flagSampled
+	}
+	return ctx, nil
+}
+
+func b3Sampled(ctx spanContext) string {
+	switch {
+	case ctx.isDebug():
+		return "d"
+	case ctx.isSampled():
+		return "1"
+	}
+	return "0"
+}
+
+func parseB3Sampled(s string) (byte, error) {
+	switch s {
+	case "", "0", "false":
+		return 0, nil
+	case "1", "true":
+		return flagSampled, nil
+	case "d":
+		return flagSampled | flagDebug, nil
+	}
+	return 0, errInvalidTracerString
+}
+
+// b3SingleFormat https://github.com/openzipkin/b3-propagation#single-header
+type b3SingleFormat struct{}
+
+func (b3SingleFormat) inject(ctx spanContext, carr Carrier) {
+	value := formatTraceID(ctx) + "-" + fmt.Sprintf("%016x", ctx.SpanID) + "-" + b3Sampled(ctx)
+	if ctx.ParentID != 0 {
+		value += "-" + fmt.Sprintf("%016x", ctx.ParentID)
+	}
+	carr.Set(B3Single, value)
+}
+
+func (b3SingleFormat) extract(carr Carrier) (spanContext, error) {
+	value := strings.TrimSpace(carr.Get(B3Single))
+	if value == "" {
+		return emptyContext, errEmptyTracerString
+	}
+	items := strings.Split(value, "-")
+	// only the sampling state is not a span context.
+	if len(items) < 2 || len(items) > 4 {
+		return emptyContext, errInvalidTracerString
+	}
+	high, low, err := parseTraceID(items[0])
+	if err != nil {
+		return emptyContext, err
+	}
+	spanID, err := parseSpanID(items[1])
+	if err != nil {
+		return emptyContext, err
+	}
+	ctx := spanContext{TraceIDHigh: high, TraceID: low, SpanID: spanID}
+	if len(items) > 2 {
+		if ctx.Flags, err = parseB3Sampled(items[2]); err != nil {
+			return emptyContext, err
+		}
+	}
+	if len(items) > 3 {
+		if ctx.ParentID, err = parseSpanID(items[3]); err != nil {
+			return emptyContext, err
+		}
+	}
+	return ctx, nil
+}
+
+// b3MultiFormat https://github.com/openzipkin/b3-propagation#multiple-headers
+type b3MultiFormat struct{}
+
+func (b3MultiFormat) inject(ctx spanContext, carr Carrier) {
+	carr.Set(B3TraceID, formatTraceID(ctx))
+	carr.Set(B3SpanID, fmt.Sprintf("%016x", ctx.SpanID))
+	if ctx.ParentID != 0 {
+		carr.Set(B3ParentSpanID, fmt.Sprintf("%016x", ctx.ParentID))
+	}
+	if ctx.isDebug() {
+		carr.Set(B3Flags, "1")
+	} else {
+		carr.Set(B3Sampled, b3Sampled(ctx))
+	}
+}
+
+func (b3MultiFormat) extract(carr Carrier) (spanContext, error) {
+	traceID := strings.TrimSpace(carr.Get(B3TraceID))
+	if traceID == "" {
+		return emptyContext, errEmptyTracerString
+	}
+	high, low, err := parseTraceID(traceID)
+	if err != nil {
+		return emptyContext, err
+	}
+	spanID, err := parseSpanID(strings.TrimSpace(carr.Get(B3SpanID)))
+	if err != nil {
+		return emptyContext, err
+	}
+	ctx := spanContext{TraceIDHigh: high, TraceID: low, SpanID: spanID}
+	if parent := strings.TrimSpace(carr.Get(B3ParentSpanID)); parent != "" {
+		if ctx.ParentID, err = parseSpanID(parent); err != nil {
+			return emptyContext, err
+		}
+	}
+	if carr.Get(B3Flags) == "1" {
+		ctx.Flags = flagSampled | flagDebug
+	} else if ctx.Flags, err = parseB3Sampled(strings.ToLower(carr.Get(B3Sampled))); err != nil {
+		return emptyContext, err
+	}
+	return ctx, nil
+}
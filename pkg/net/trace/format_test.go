@@ -0,0 +1,118 @@
+package trace
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestW3CFormat(t *testing.T) {
+	header := make(http.Header)
+	header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	header.Set("tracestate", "congo=t61rcWkgMzE")
+	ctx, err := w3cFormat{}.extract(httpCarrier(header))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0x0af7651916cd43dd), ctx.TraceIDHigh)
+	assert.Equal(t, uint64(0x8448eb211c80319c), ctx.TraceID)
+	assert.Equal(t, uint64(0xb7ad6b7169203331), ctx.SpanID)
+	assert.True(t, ctx.isSampled())
+	assert.Equal(t, "congo=t61rcWkgMzE", ctx.State)
+
+	out := make(http.Header)
+	w3cFormat{}.inject(ctx, httpCarrier(out))
+	assert.Equal(t, header.Get("traceparent"), out.Get("traceparent"))
+	assert.Equal(t, header.Get("tracestate"), out.Get("tracestate"))
+
+	for _, value := range []string{
+		"",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331",
+		"ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-00000000000000000000000000000000-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-0000000000000000-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-extra",
+	} {
+		header.Set("traceparent", value)
+		_, err = w3cFormat{}.extract(httpCarrier(header))
+		assert.Error(t, err, value)
+	}
+	// future versions may have more fields
+	header.Set("traceparent", "01-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00-extra")
+	ctx, err = w3cFormat{}.extract(httpCarrier(header))
+	assert.NoError(t, err)
+	assert.False(t, ctx.isSampled())
+}
+
+func TestB3Format(t *testing.T) {
+	md := metadata.MD{}
+	md.Set("b3", "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-d-05e3ac9a4f6e3b90")
+	ctx, err := b3SingleFormat{}.extract(grpcCarrier(md))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0x80f198ee56343ba8), ctx.TraceIDHigh)
+	assert.Equal(t, uint64(0x64fe8b2a57d3eff7), ctx.TraceID)
+	assert.Equal(t, uint64(0xe457b5a2e4d86bd1), ctx.SpanID)
+	assert.Equal(t, uint64(0x05e3ac9a4f6e3b90), ctx.ParentID)
+	assert.True(t, ctx.isDebug())
+
+	out := metadata.MD{}
+	b3SingleFormat{}.inject(ctx, grpcCarrier(out))
+	assert.Equal(t, md.Get("b3"), out.Get("b3"))
+
+	md.Set("b3", "1")
+	_, err = b3SingleFormat{}.extract(grpcCarrier(md))
+	assert.Error(t, err)
+
+	header := make(http.Header)
+	header.Set("X-B3-TraceId", "463ac35c9f6413ad")
+	header.Set("X-B3-SpanId", "a2fb4a1d1a96d312")
+	header.Set("X-B3-ParentSpanId", "0020000000000001")
+	header.Set("X-B3-Sampled", "1")
+	ctx, err = b3MultiFormat{}.extract(httpCarrier(header))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), ctx.TraceIDHigh)
+	assert.Equal(t, uint64(0x463ac35c9f6413ad), ctx.TraceID)
+	assert.Equal(t, uint64(0x0020000000000001), ctx.ParentID)
+	assert.True(t, ctx.isSampled())
+
+	out2 := make(http.Header)
+	b3MultiFormat{}.inject(ctx, httpCarrier(out2))
+	assert.Equal(t, header, out2)
+}
+
+func TestPropagation(t *testing.T) {
+	report := &mockReport{}
+	t1 := NewTracer("service1", report, true, WithPropagation(PropagationW3C, PropagationB3))
+	t2 := NewTracer("service2", report, true)
+
+	sp1 := t1.New("opt_1")
+	sp2 := sp1.Fork("", "opt_client")
+	header := make(http.Header)
+	assert.NoError(t, t1.Inject(sp2, HTTPFormat, header))
+	assert.NotEmpty(t, header.Get("traceparent"))
+	assert.NotEmpty(t, header.Get("b3"))
+	assert.Empty(t, header.Get(KratosTraceID))
+
+	// the tracer without w3c configured still extracts it
+	sp3, err := t2.Extract(HTTPFormat, header)
+	assert.NoError(t, err)
+	sp3.Finish(nil)
+	sp2.Finish(nil)
+	sp1.Finish(nil)
+	assert.Equal(t, report.sps[0].context.TraceID, report.sps[1].context.TraceID)
+	assert.Equal(t, report.sps[0].context.ParentID, report.sps[1].context.SpanID)
+
+	// 128 bits trace id is kept along the trace
+	header = make(http.Header)
+	header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	sp4, err := t1.Extract(HTTPFormat, header)
+	assert.NoError(t, err)
+	sp5 := sp4.Fork("", "opt_client")
+	md := metadata.MD{}
+	assert.NoError(t, t1.Inject(sp5, GRPCFormat, md))
+	assert.Contains(t, md.Get("traceparent")[0], "0af7651916cd43dd8448eb211c80319c")
+	assert.Contains(t, md.Get("b3")[0], "0af7651916cd43dd8448eb211c80319c")
+
+	_, err = t1.Extract(HTTPFormat, make(http.Header))
+	assert.Error(t, err)
+}
@@ -1,5 +1,7 @@
 package trace
 
+import "time"
+
 var defaultOption = option{}
 
 type option struct {
@@ -15,3 +17,74 @@ func EnableDebug() Option {
 		opt.Debug = true
 	}
 }
+
+// TracerOption dapper tracer Option
+type TracerOption func(*dapper)
+
+// WithProbabilitySampler samples the first trace of every second and then
+// samples with probability for each operation, it's the default sampler.
+func WithProbabilitySampler(probability float32) TracerOption {
+	return func(d *dapper) {
+		d.sampler = newSampler(probability)
+	}
+}
+
+// WithRateLimitingSampler samples at most rate traces per second for each operation.
+func WithRateLimitingSampler(rate float64) TracerOption {
+	return func(d *dapper) {
+		d.sampler = newRateLimitingSampler(rate)
+	}
+}
+
+// WithAdaptiveSampler adjusts the probability of each operation by its qps so
+// that about target traces per second are sampled, the probability is never
+// lower than minProbability.
+func WithAdaptiveSampler(target float64, minProbability float64) TracerOption {
+	return func(d *dapper) {
+		d.sampler = newAdaptiveSampler(target, minProbability)
+	}
+}
+
+// WithTailSampling records the unsampled traces in process and reports them
+// when they contain an error or the local root span takes longer than latency.
+// The trace is decided when the local root span finished or timeout elapsed,
+// at most maxTraces traces are buffered.
+func WithTailSampling(latency, timeout time.Duration, maxTraces int) TracerOption {
+	return func(d *dapper) {
+		if _, ok := d.reporter.(*tailReporter); ok {
+			return
+		}
+		d.recordAll = true
+		d.reporter = newTailReporter(d.reporter, latency, timeout, maxTraces)
+	}
+}
+
+// WithPropagation sets the propagation formats written by Inject, which are
+// PropagationKratos, PropagationW3C, PropagationB3 and PropagationB3Multi.
+// Extract tries these formats first then the others.
+func WithPropagation(formats ...string) TracerOption {
+	return func(d *dapper) {
+		d.injectFormats = d.injectFormats[:0]
+		d.extractFormats = d.extractFormats[:0]
+		configured := make(map[string]bool)
+		for _, name := range formats {
+			f, ok := _wireFormats[name]
+			if !ok || configured[name] {
+				continue
+			}
+			configured[name] = true
+			d.injectFormats = append(d.injectFormats, f)
+			d.extractFormats = append(d.extractFormats, f)
+		}
+		if len(d.injectFormats) == 0 {
+			configured[PropagationKratos] = true
+			d.injectFormats = append(d.injectFormats, kratosFormat{})
+			d.extractFormats = append(d.extractFormats, kratosFormat{})
+		}
+		for _, name := range _extractOrder {
+			if !configured[name] {
+				d.extractFormats = append(d.extractFormats, _wireFormats[name])
+			}
+		}
+	}
+}
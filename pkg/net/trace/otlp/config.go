@@ -0,0 +1,62 @@
+package otlp
+
+import (
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/conf/env"
+	"github.com/go-kratos/kratos/pkg/net/trace"
+	xtime "github.com/go-kratos/kratos/pkg/time"
+)
+
+// Protocol of OTLP exporter.
+const (
+	ProtocolGRPC         = "grpc"
+	ProtocolHTTPProtobuf = "http/protobuf"
+)
+
+// Config config.
+// Endpoint should be the collector to send the spans to, e.g.
+// 127.0.0.1:4317 for grpc and http://127.0.0.1:4318/v1/traces for http/protobuf.
+type Config struct {
+	Endpoint      string            `dsn:"endpoint"`
+	Protocol      string            `dsn:"query.protocol,grpc"`
+	Headers       map[string]string `dsn:"-"`
+	Timeout       xtime.Duration    `dsn:"query.timeout,5s"`
+	BatchSize     int               `dsn:"query.batch_size,512"`
+	FlushInterval xtime.Duration    `dsn:"query.flush_interval,1s"`
+	QueueSize     int               `dsn:"query.queue_size,2048"`
+	MaxRetry      int               `dsn:"query.max_retry,3"` // negative disables retry
+	RetryBackoff  xtime.Duration    `dsn:"query.retry_backoff,100ms"`
+	DisableSample bool              `dsn:"query.disable_sample"`
+}
+
+func (c *Config) fix() {
+	if c.Protocol == "" {
+		c.Protocol = ProtocolGRPC
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = xtime.Duration(5 * time.Second)
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 512
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = xtime.Duration(time.Second)
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 2048
+	}
+	if c.MaxRetry == 0 {
+		c.MaxRetry = 3
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = xtime.Duration(100 * time.Millisecond)
+	}
+}
+
+// Init init trace report, opts are the sampling and propagation options of the
+// tracer, e.g. the ones of trace.Config.TracerOptions.
+func Init(c *Config, opts ...trace.TracerOption) {
+	c.fix()
+	trace.SetGlobalTracer(trace.NewTracer(env.AppID, newReport(c), c.DisableSample, opts...))
+}
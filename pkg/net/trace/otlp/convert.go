@@ -0,0 +1,183 @@
+package otlp
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/go-kratos/kratos/pkg/conf/env"
+	"github.com/go-kratos/kratos/pkg/net/trace"
+	protogen "github.com/go-kratos/kratos/pkg/net/trace/proto"
+)
+
+const (
+	_scopeName    = "github.com/go-kratos/kratos/pkg/net/trace"
+	_refTypeKey   = "opentracing.ref_type"
+	_followsFrom  = "follows_from"
+	_childOf      = "child_of"
+	_defaultEvent = "log"
+)
+
+// resource build resource attributes from env.
+func resource(serviceName string) *Resource {
+	if serviceName == "" {
+		serviceName = env.AppID
+	}
+	attrs := []*KeyValue{stringKV("service.name", serviceName)}
+	for _, kv := range [][2]string{
+		{"host.name", env.Hostname},
+		{"cloud.region", env.Region},
+		{"cloud.availability_zone", env.Zone},
+		{"deployment.environment", env.DeployEnv},
+		{"kratos.color", env.Color},
+	} {
+		if kv[1] != "" {
+			attrs = append(attrs, stringKV(kv[0], kv[1]))
+		}
+	}
+	return &Resource{Attributes: attrs}
+}
+
+// convertSpan convert kratos span to otlp span, raw span will be put back to
+// pool after WriteSpan so it must be copied synchronously.
+func convertSpan(raw *trace.Span) *Span {
+	ctx := raw.Context()
+	start := raw.StartTime()
+	span := &Span{
+		TraceID:           traceID(ctx.TraceIDHigh, ctx.TraceID),
+		SpanID:            spanID(ctx.SpanID),
+		TraceState:        ctx.State,
+		Name:              raw.OperationName(),
+		Kind:              SpanKindInternal,
+		StartTimeUnixNano: uint64(start.UnixNano()),
+		EndTimeUnixNano:   uint64(start.Add(raw.Duration()).UnixNano()),
+	}
+	if ctx.ParentID != 0 {
+		span.ParentSpanID = spanID(ctx.ParentID)
+	}
+	var isErr bool
+	tags := raw.Tags()
+	span.Attributes = make([]*KeyValue, 0, len(tags))
+	for _, tag := range tags {
+		switch tag.Key {
+		case trace.TagSpanKind:
+			span.Kind = spanKind(tag.Value)
+			continue
+		case trace.TagError:
+			if b, ok := tag.Value.(bool); ok {
+				isErr = b
+			}
+		}
+		span.Attributes = append(span.Attributes, &KeyValue{Key: tag.Key, Value: anyValue(tag.Value)})
+	}
+	span.Events = convertLogs(raw.Logs())
+	if isErr {
+		span.Status = &Status{Code: StatusCodeError, Message: errorMessage(span.Events)}
+	}
+	if ctx.ParentID != 0 {
+		// kratos only has one reference which is the parent, producer spans created by
+		// Follow are follows_from, others are child_of.
+		refType := _childOf
+		if span.Kind == SpanKindProducer {
+			refType = _followsFrom
+		}
+		span.Links = []*Link{{
+			TraceID:    span.TraceID,
+			SpanID:     span.ParentSpanID,
+			TraceState: ctx.State,
+			Attributes: []*KeyValue{stringKV(_refTypeKey, refType)},
+		}}
+	}
+	return span
+}
+
+func convertLogs(logs []*protogen.Log) []*Event {
+	if len(logs) == 0 {
+		return nil
+	}
+	events := make([]*Event, 0, len(logs))
+	for _, lg := range logs {
+		event := &Event{
+			TimeUnixNano: uint64(lg.Timestamp),
+			Name:         _defaultEvent,
+			Attributes:   make([]*KeyValue, 0, len(lg.Fields)),
+		}
+		for _, field := range lg.Fields {
+			if field.Key == trace.LogEvent {
+				event.Name = string(field.Value)
+				continue
+			}
+			event.Attributes = append(event.Attributes, stringKV(field.Key, string(field.Value)))
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func errorMessage(events []*Event) string {
+	for _, event := range events {
+		for _, attr := range event.Attributes {
+			if attr.Key == trace.LogMessage && attr.Value.StringValue != nil {
+				return *attr.Value.StringValue
+			}
+		}
+	}
+	return ""
+}
+
+func spanKind(v interface{}) int32 {
+	s, _ := v.(string)
+	switch s {
+	case "client":
+		return SpanKindClient
+	case "server":
+		return SpanKindServer
+	case "producer":
+		return SpanKindProducer
+	case "consumer":
+		return SpanKindConsumer
+	}
+	return SpanKindInternal
+}
+
+func anyValue(v interface{}) *AnyValue {
+	switch val := v.(type) {
+	case string:
+		return &AnyValue{StringValue: &val}
+	case bool:
+		return &AnyValue{BoolValue: &val}
+	case int64:
+		return &AnyValue{IntValue: &val}
+	case int:
+		i := int64(val)
+		return &AnyValue{IntValue: &i}
+	case int32:
+		i := int64(val)
+		return &AnyValue{IntValue: &i}
+	case float64:
+		return &AnyValue{DoubleValue: &val}
+	case float32:
+		f := float64(val)
+		return &AnyValue{DoubleValue: &f}
+	case []byte:
+		return &AnyValue{BytesValue: val}
+	}
+	s := fmt.Sprint(v)
+	return &AnyValue{StringValue: &s}
+}
+
+func stringKV(key, value string) *KeyValue {
+	return &KeyValue{Key: key, Value: &AnyValue{StringValue: &value}}
+}
+
+func traceID(high, low uint64) []byte {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b[:8], high)
+	binary.BigEndian.PutUint64(b[8:], low)
+	return b
+}
+
+func spanID(id uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, id)
+	return b
+}
@@ -0,0 +1,121 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// exporter send a export request to collector.
+type exporter interface {
+	Export(req *ExportTraceServiceRequest) error
+	Close() error
+}
+
+// retryableError marks an export error which can be retried.
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+
+func retryable(err error) bool {
+	switch e := errors.Cause(err).(type) {
+	case *retryableError:
+		return true
+	case net.Error:
+		return true
+	default:
+		switch status.Code(e) {
+		case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded, codes.Aborted:
+			return true
+		}
+	}
+	return false
+}
+
+type grpcExporter struct {
+	c    *Config
+	conn *grpc.ClientConn
+	err  error
+}
+
+func newGRPCExporter(c *Config) *grpcExporter {
+	conn, err := grpc.Dial(c.Endpoint, grpc.WithInsecure())
+	return &grpcExporter{c: c, conn: conn, err: err}
+}
+
+func (e *grpcExporter) Export(req *ExportTraceServiceRequest) error {
+	if e.err != nil {
+		return e.err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(e.c.Timeout))
+	defer cancel()
+	if len(e.c.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(e.c.Headers))
+	}
+	return e.conn.Invoke(ctx, _exportMethod, req, new(ExportTraceServiceResponse))
+}
+
+func (e *grpcExporter) Close() error {
+	if e.conn == nil {
+		return nil
+	}
+	return e.conn.Close()
+}
+
+type httpExporter struct {
+	c      *Config
+	client *http.Client
+}
+
+func newHTTPExporter(c *Config) *httpExporter {
+	return &httpExporter{c: c, client: &http.Client{Timeout: time.Duration(c.Timeout)}}
+}
+
+func (e *httpExporter) Export(req *ExportTraceServiceRequest) error {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	hreq, err := http.NewRequest(http.MethodPost, e.c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	hreq.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range e.c.Headers {
+		hreq.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(hreq)
+	if err != nil {
+		return &retryableError{err: err}
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("otlp: collector response status %d", resp.StatusCode)
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return &retryableError{err: err}
+	}
+	return err
+}
+
+func (e *httpExporter) Close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
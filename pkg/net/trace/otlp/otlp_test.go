@@ -0,0 +1,249 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/net/trace"
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type collector struct {
+	mu    sync.Mutex
+	spans []*Span
+	reqs  []*ExportTraceServiceRequest
+}
+
+func (c *collector) add(req *ExportTraceServiceRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reqs = append(c.reqs, req)
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+}
+
+func (c *collector) span(name string) *Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func attr(attrs []*KeyValue, key string) *AnyValue {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return nil
+}
+
+func emit(t *testing.T, r *report) {
+	tracer := trace.NewTracer("service1", r, true)
+	root := tracer.New("root")
+	root.SetTag(trace.TagString(trace.TagSpanKind, "server"), trace.TagInt64("http.status_code", 500))
+	child := root.Fork("", "child")
+	child.SetLog(trace.Log(trace.LogEvent, "query"), trace.Log("sql", "select 1"))
+	err := errors.New("boom")
+	child.Finish(&err)
+	root.Follow("", "produce").Finish(nil)
+	root.Finish(nil)
+	assert.NoError(t, r.Close())
+}
+
+func TestHTTPExporter(t *testing.T) {
+	c := &collector{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "token", r.Header.Get("X-Auth"))
+		b, _ := ioutil.ReadAll(r.Body)
+		req := new(ExportTraceServiceRequest)
+		if err := proto.Unmarshal(b, req); err != nil {
+			t.Fatal(err)
+		}
+		c.add(req)
+	}))
+	defer ts.Close()
+
+	emit(t, newReport(&Config{
+		Endpoint: ts.URL,
+		Protocol: ProtocolHTTPProtobuf,
+		Headers:  map[string]string{"X-Auth": "token"},
+	}))
+
+	assert.Len(t, c.spans, 3)
+	rs := c.reqs[0].ResourceSpans[0]
+	assert.Equal(t, "service1", *attr(rs.Resource.Attributes, "service.name").StringValue)
+	assert.Equal(t, _scopeName, rs.ScopeSpans[0].Scope.Name)
+
+	root, child, produce := c.span("root"), c.span("child"), c.span("produce")
+	assert.Equal(t, SpanKindServer, root.Kind)
+	assert.Equal(t, int64(500), *attr(root.Attributes, "http.status_code").IntValue)
+	assert.Nil(t, root.ParentSpanID)
+	assert.Len(t, root.TraceID, 16)
+	assert.True(t, root.EndTimeUnixNano >= root.StartTimeUnixNano)
+
+	assert.Equal(t, SpanKindClient, child.Kind)
+	assert.Equal(t, root.TraceID, child.TraceID)
+	assert.Equal(t, root.SpanID, child.ParentSpanID)
+	assert.Equal(t, StatusCodeError, child.Status.Code)
+	assert.Equal(t, "boom", child.Status.Message)
+	assert.True(t, *attr(child.Attributes, trace.TagError).BoolValue)
+	if assert.Len(t, child.Events, 2) {
+		assert.Equal(t, "query", child.Events[0].Name)
+		assert.Equal(t, "select 1", *attr(child.Events[0].Attributes, "sql").StringValue)
+	}
+	assert.Equal(t, _childOf, *attr(child.Links[0].Attributes, _refTypeKey).StringValue)
+
+	assert.Equal(t, SpanKindProducer, produce.Kind)
+	assert.Equal(t, _followsFrom, *attr(produce.Links[0].Attributes, _refTypeKey).StringValue)
+}
+
+func TestHTTPExporterRetry(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if calls++; calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	emit(t, newReport(&Config{
+		Endpoint:     ts.URL,
+		Protocol:     ProtocolHTTPProtobuf,
+		RetryBackoff: xtime.Duration(time.Millisecond),
+	}))
+	assert.Equal(t, 3, calls)
+}
+
+type traceService interface {
+	Export(context.Context, *ExportTraceServiceRequest) (*ExportTraceServiceResponse, error)
+}
+
+type grpcCollector struct {
+	collector
+	fails int
+}
+
+func (c *grpcCollector) Export(ctx context.Context, req *ExportTraceServiceRequest) (*ExportTraceServiceResponse, error) {
+	c.mu.Lock()
+	if c.fails > 0 {
+		c.fails--
+		c.mu.Unlock()
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	}
+	c.mu.Unlock()
+	c.add(req)
+	return &ExportTraceServiceResponse{}, nil
+}
+
+var _traceServiceDesc = grpc.ServiceDesc{
+	ServiceName: "opentelemetry.proto.collector.trace.v1.TraceService",
+	HandlerType: (*traceService)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Export",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := new(ExportTraceServiceRequest)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			return srv.(traceService).Export(ctx, in)
+		},
+	}},
+}
+
+func TestGRPCExporter(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &grpcCollector{fails: 1}
+	s := grpc.NewServer()
+	s.RegisterService(&_traceServiceDesc, c)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	emit(t, newReport(&Config{
+		Endpoint:     lis.Addr().String(),
+		RetryBackoff: xtime.Duration(time.Millisecond),
+	}))
+	assert.Len(t, c.spans, 3)
+	assert.Equal(t, SpanKindClient, c.span("child").Kind)
+}
+
+type blockExporter struct {
+	block chan struct{}
+}
+
+func (e *blockExporter) Export(req *ExportTraceServiceRequest) error {
+	<-e.block
+	return nil
+}
+
+func (e *blockExporter) Close() error { return nil }
+
+func TestQueueFull(t *testing.T) {
+	exp := &blockExporter{block: make(chan struct{})}
+	c := &Config{QueueSize: 1, BatchSize: 1}
+	c.fix()
+	r := newReportWithExporter(c, exp)
+	tracer := trace.NewTracer("service1", r, true)
+	var dropped int
+	for i := 0; i < 10; i++ {
+		sp := tracer.New("op")
+		sp.Finish(nil)
+	}
+	for i := 0; i < 10; i++ {
+		if err := r.WriteSpan(tracer.New("op").(*trace.Span)); err != nil {
+			dropped++
+		}
+	}
+	assert.True(t, dropped > 0)
+	close(exp.block)
+	assert.NoError(t, r.Close())
+	assert.Error(t, r.WriteSpan(tracer.New("op").(*trace.Span)))
+}
+
+func TestRetryable(t *testing.T) {
+	assert.True(t, retryable(status.Error(codes.Unavailable, "")))
+	assert.False(t, retryable(status.Error(codes.InvalidArgument, "")))
+	assert.True(t, retryable(&retryableError{err: errors.New("503")}))
+	assert.False(t, retryable(errors.New("400")))
+}
+
+func TestInitTracerOptions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	Init(&Config{Endpoint: ts.URL, Protocol: ProtocolHTTPProtobuf}, trace.WithPropagation(trace.PropagationW3C))
+	defer trace.Close()
+	header := make(http.Header)
+	assert.NoError(t, trace.Inject(trace.New("root"), trace.HTTPFormat, header))
+	assert.NotEmpty(t, header.Get(trace.W3CTraceParent))
+	assert.Empty(t, header.Get(trace.KratosTraceID))
+}
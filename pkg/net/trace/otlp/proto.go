@@ -0,0 +1,173 @@
+package otlp
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// The messages below are the subset of opentelemetry-proto v1 used by the
+// exporter, field numbers follow opentelemetry/proto/trace/v1/trace.proto,
+// opentelemetry/proto/common/v1/common.proto and
+// opentelemetry/proto/collector/trace/v1/trace_service.proto.
+
+// SpanKind the type of span.
+const (
+	SpanKindUnspecified int32 = 0
+	SpanKindInternal    int32 = 1
+	SpanKindServer      int32 = 2
+	SpanKindClient      int32 = 3
+	SpanKindProducer    int32 = 4
+	SpanKindConsumer    int32 = 5
+)
+
+// StatusCode the status of span.
+const (
+	StatusCodeUnset int32 = 0
+	StatusCodeOk    int32 = 1
+	StatusCodeError int32 = 2
+)
+
+const _exportMethod = "/opentelemetry.proto.collector.trace.v1.TraceService/Export"
+
+// ExportTraceServiceRequest the request of TraceService.Export.
+type ExportTraceServiceRequest struct {
+	ResourceSpans []*ResourceSpans `protobuf:"bytes,1,rep,name=resource_spans,json=resourceSpans,proto3"`
+}
+
+func (m *ExportTraceServiceRequest) Reset()         { *m = ExportTraceServiceRequest{} }
+func (m *ExportTraceServiceRequest) String() string { return proto.CompactTextString(m) }
+func (*ExportTraceServiceRequest) ProtoMessage()    {}
+
+// ExportTraceServiceResponse the response of TraceService.Export.
+type ExportTraceServiceResponse struct {
+	PartialSuccess *ExportTracePartialSuccess `protobuf:"bytes,1,opt,name=partial_success,json=partialSuccess,proto3"`
+}
+
+func (m *ExportTraceServiceResponse) Reset()         { *m = ExportTraceServiceResponse{} }
+func (m *ExportTraceServiceResponse) String() string { return proto.CompactTextString(m) }
+func (*ExportTraceServiceResponse) ProtoMessage()    {}
+
+// ExportTracePartialSuccess the spans rejected by collector.
+type ExportTracePartialSuccess struct {
+	RejectedSpans int64  `protobuf:"varint,1,opt,name=rejected_spans,json=rejectedSpans,proto3"`
+	ErrorMessage  string `protobuf:"bytes,2,opt,name=error_message,json=errorMessage,proto3"`
+}
+
+func (m *ExportTracePartialSuccess) Reset()         { *m = ExportTracePartialSuccess{} }
+func (m *ExportTracePartialSuccess) String() string { return proto.CompactTextString(m) }
+func (*ExportTracePartialSuccess) ProtoMessage()    {}
+
+// ResourceSpans the spans of a resource.
+type ResourceSpans struct {
+	Resource   *Resource     `protobuf:"bytes,1,opt,name=resource,proto3"`
+	ScopeSpans []*ScopeSpans `protobuf:"bytes,2,rep,name=scope_spans,json=scopeSpans,proto3"`
+}
+
+func (m *ResourceSpans) Reset()         { *m = ResourceSpans{} }
+func (m *ResourceSpans) String() string { return proto.CompactTextString(m) }
+func (*ResourceSpans) ProtoMessage()    {}
+
+// Resource the entity producing spans.
+type Resource struct {
+	Attributes []*KeyValue `protobuf:"bytes,1,rep,name=attributes,proto3"`
+}
+
+func (m *Resource) Reset()         { *m = Resource{} }
+func (m *Resource) String() string { return proto.CompactTextString(m) }
+func (*Resource) ProtoMessage()    {}
+
+// ScopeSpans the spans of an instrumentation scope.
+type ScopeSpans struct {
+	Scope *InstrumentationScope `protobuf:"bytes,1,opt,name=scope,proto3"`
+	Spans []*Span               `protobuf:"bytes,2,rep,name=spans,proto3"`
+}
+
+func (m *ScopeSpans) Reset()         { *m = ScopeSpans{} }
+func (m *ScopeSpans) String() string { return proto.CompactTextString(m) }
+func (*ScopeSpans) ProtoMessage()    {}
+
+// InstrumentationScope the library producing spans.
+type InstrumentationScope struct {
+	Name    string `protobuf:"bytes,1,opt,name=name,proto3"`
+	Version string `protobuf:"bytes,2,opt,name=version,proto3"`
+}
+
+func (m *InstrumentationScope) Reset()         { *m = InstrumentationScope{} }
+func (m *InstrumentationScope) String() string { return proto.CompactTextString(m) }
+func (*InstrumentationScope) ProtoMessage()    {}
+
+// Span a single operation of trace.
+type Span struct {
+	TraceID           []byte      `protobuf:"bytes,1,opt,name=trace_id,json=traceId,proto3"`
+	SpanID            []byte      `protobuf:"bytes,2,opt,name=span_id,json=spanId,proto3"`
+	TraceState        string      `protobuf:"bytes,3,opt,name=trace_state,json=traceState,proto3"`
+	ParentSpanID      []byte      `protobuf:"bytes,4,opt,name=parent_span_id,json=parentSpanId,proto3"`
+	Name              string      `protobuf:"bytes,5,opt,name=name,proto3"`
+	Kind              int32       `protobuf:"varint,6,opt,name=kind,proto3"`
+	StartTimeUnixNano uint64      `protobuf:"fixed64,7,opt,name=start_time_unix_nano,json=startTimeUnixNano,proto3"`
+	EndTimeUnixNano   uint64      `protobuf:"fixed64,8,opt,name=end_time_unix_nano,json=endTimeUnixNano,proto3"`
+	Attributes        []*KeyValue `protobuf:"bytes,9,rep,name=attributes,proto3"`
+	Events            []*Event    `protobuf:"bytes,11,rep,name=events,proto3"`
+	Links             []*Link     `protobuf:"bytes,13,rep,name=links,proto3"`
+	Status            *Status     `protobuf:"bytes,15,opt,name=status,proto3"`
+}
+
+func (m *Span) Reset()         { *m = Span{} }
+func (m *Span) String() string { return proto.CompactTextString(m) }
+func (*Span) ProtoMessage()    {}
+
+// Event a time stamped annotation of span.
+type Event struct {
+	TimeUnixNano uint64      `protobuf:"fixed64,1,opt,name=time_unix_nano,json=timeUnixNano,proto3"`
+	Name         string      `protobuf:"bytes,2,opt,name=name,proto3"`
+	Attributes   []*KeyValue `protobuf:"bytes,3,rep,name=attributes,proto3"`
+}
+
+func (m *Event) Reset()         { *m = Event{} }
+func (m *Event) String() string { return proto.CompactTextString(m) }
+func (*Event) ProtoMessage()    {}
+
+// Link a pointer from span to another span.
+type Link struct {
+	TraceID    []byte      `protobuf:"bytes,1,opt,name=trace_id,json=traceId,proto3"`
+	SpanID     []byte      `protobuf:"bytes,2,opt,name=span_id,json=spanId,proto3"`
+	TraceState string      `protobuf:"bytes,3,opt,name=trace_state,json=traceState,proto3"`
+	Attributes []*KeyValue `protobuf:"bytes,4,rep,name=attributes,proto3"`
+}
+
+func (m *Link) Reset()         { *m = Link{} }
+func (m *Link) String() string { return proto.CompactTextString(m) }
+func (*Link) ProtoMessage()    {}
+
+// Status the status of span.
+type Status struct {
+	Message string `protobuf:"bytes,2,opt,name=message,proto3"`
+	Code    int32  `protobuf:"varint,3,opt,name=code,proto3"`
+}
+
+func (m *Status) Reset()         { *m = Status{} }
+func (m *Status) String() string { return proto.CompactTextString(m) }
+func (*Status) ProtoMessage()    {}
+
+// KeyValue an attribute.
+type KeyValue struct {
+	Key   string    `protobuf:"bytes,1,opt,name=key,proto3"`
+	Value *AnyValue `protobuf:"bytes,2,opt,name=value,proto3"`
+}
+
+func (m *KeyValue) Reset()         { *m = KeyValue{} }
+func (m *KeyValue) String() string { return proto.CompactTextString(m) }
+func (*KeyValue) ProtoMessage()    {}
+
+// AnyValue the value of attribute, exactly one of the fields is set. The
+// fields are pointers so the zero values are still encoded like the oneof.
+type AnyValue struct {
+	StringValue *string  `protobuf:"bytes,1,opt,name=string_value,json=stringValue"`
+	BoolValue   *bool    `protobuf:"varint,2,opt,name=bool_value,json=boolValue"`
+	IntValue    *int64   `protobuf:"varint,3,opt,name=int_value,json=intValue"`
+	DoubleValue *float64 `protobuf:"fixed64,4,opt,name=double_value,json=doubleValue"`
+	BytesValue  []byte   `protobuf:"bytes,7,opt,name=bytes_value,json=bytesValue"`
+}
+
+func (m *AnyValue) Reset()         { *m = AnyValue{} }
+func (m *AnyValue) String() string { return proto.CompactTextString(m) }
+func (*AnyValue) ProtoMessage()    {}
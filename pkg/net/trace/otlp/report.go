@@ -0,0 +1,152 @@
+package otlp
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/log"
+	"github.com/go-kratos/kratos/pkg/net/trace"
+)
+
+type item struct {
+	service string
+	span    *Span
+}
+
+type report struct {
+	c   *Config
+	exp exporter
+
+	rmx    sync.RWMutex
+	closed bool
+
+	queue chan item
+	done  chan struct{}
+}
+
+func newReport(c *Config) *report {
+	c.fix()
+	var exp exporter
+	switch c.Protocol {
+	case ProtocolHTTPProtobuf:
+		exp = newHTTPExporter(c)
+	default:
+		exp = newGRPCExporter(c)
+	}
+	return newReportWithExporter(c, exp)
+}
+
+func newReportWithExporter(c *Config, exp exporter) *report {
+	r := &report{
+		c:     c,
+		exp:   exp,
+		queue: make(chan item, c.QueueSize),
+		done:  make(chan struct{}),
+	}
+	go r.daemon()
+	return r
+}
+
+// WriteSpan write a trace span to queue, the span is dropped if queue is full.
+func (r *report) WriteSpan(raw *trace.Span) error {
+	it := item{service: raw.ServiceName(), span: convertSpan(raw)}
+	r.rmx.RLock()
+	defer r.rmx.RUnlock()
+	if r.closed {
+		return fmt.Errorf("otlp: report already closed")
+	}
+	select {
+	case r.queue <- it:
+		return nil
+	default:
+		return fmt.Errorf("otlp: queue is full, drop span %s", raw.OperationName())
+	}
+}
+
+// Close flush the queued spans and close the report.
+func (r *report) Close() error {
+	r.rmx.Lock()
+	if r.closed {
+		r.rmx.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.queue)
+	r.rmx.Unlock()
+
+	retry := r.c.MaxRetry
+	if retry < 0 {
+		retry = 0
+	}
+	t := time.NewTimer(time.Duration(r.c.Timeout) * time.Duration(retry+2))
+	defer t.Stop()
+	select {
+	case <-r.done:
+	case <-t.C:
+		r.exp.Close()
+		return fmt.Errorf("otlp: close report timeout force close")
+	}
+	return r.exp.Close()
+}
+
+func (r *report) daemon() {
+	defer close(r.done)
+	ticker := time.NewTicker(time.Duration(r.c.FlushInterval))
+	defer ticker.Stop()
+	batch := make([]item, 0, r.c.BatchSize)
+	for {
+		select {
+		case it, ok := <-r.queue:
+			if !ok {
+				r.flush(batch)
+				return
+			}
+			if batch = append(batch, it); len(batch) >= r.c.BatchSize {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			r.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (r *report) flush(batch []item) {
+	if len(batch) == 0 {
+		return
+	}
+	req := buildRequest(batch)
+	backoff := time.Duration(r.c.RetryBackoff)
+	for attempt := 0; ; attempt++ {
+		err := r.exp.Export(req)
+		if err == nil {
+			return
+		}
+		if attempt >= r.c.MaxRetry || !retryable(err) {
+			log.Error("otlp: export %d spans error(%v)", len(batch), err)
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func buildRequest(batch []item) *ExportTraceServiceRequest {
+	req := &ExportTraceServiceRequest{}
+	idx := make(map[string]*ScopeSpans)
+	for _, it := range batch {
+		ss, ok := idx[it.service]
+		if !ok {
+			ss = &ScopeSpans{Scope: &InstrumentationScope{Name: _scopeName}}
+			idx[it.service] = ss
+			req.ResourceSpans = append(req.ResourceSpans, &ResourceSpans{
+				Resource:   resource(it.service),
+				ScopeSpans: []*ScopeSpans{ss},
+			})
+		}
+		ss.Spans = append(ss.Spans, it.span)
+	}
+	return req
+}
@@ -1,7 +1,9 @@
 package trace
 
 import (
+	"math"
 	"math/rand"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -40,12 +42,19 @@ type probabilitySampling struct {
 	slot        [slotLength]int64
 }
 
-func (p *probabilitySampling) IsSampled(traceID uint64, operationName string) (bool, float32) {
+func isIgnored(operationName string) bool {
 	for _, ignored := range ignoreds {
 		if operationName == ignored {
-			return false, 0
+			return true
 		}
 	}
+	return false
+}
+
+func (p *probabilitySampling) IsSampled(traceID uint64, operationName string) (bool, float32) {
+	if isIgnored(operationName) {
+		return false, 0
+	}
 	now := time.Now().Unix()
 	idx := oneAtTimeHash(operationName) % slotLength
 	old := atomic.LoadInt64(&p.slot[idx])
@@ -65,3 +74,171 @@ func newSampler(probability float32) sampler {
 	}
 	return &probabilitySampling{probability: probability}
 }
+
+const (
+	// _samplerIdleTimeout the operations not seen in it are swept from the
+	// per operation samplers.
+	_samplerIdleTimeout = time.Minute
+	// _otherOperation is shared by the operations beyond samplerMaxOperations.
+	_otherOperation = "other"
+)
+
+// samplerMaxOperations caps the operations tracked by a per operation
+// sampler, e.g. raw url paths; it's overridden in tests.
+var samplerMaxOperations = 10000
+
+// rateLimitingSampling samples at most rate traces per second for each operation.
+type rateLimitingSampling struct {
+	rate      float64
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimitingSampler new rate limiting sampler, rate is traces per second per operation.
+func newRateLimitingSampler(rate float64) sampler {
+	if rate <= 0 {
+		panic("rate must be greater than 0")
+	}
+	return &rateLimitingSampling{rate: rate, buckets: make(map[string]*tokenBucket), now: time.Now}
+}
+
+func (r *rateLimitingSampling) IsSampled(traceID uint64, operationName string) (bool, float32) {
+	if isIgnored(operationName) {
+		return false, 0
+	}
+	// the burst is one second of traces and at least one trace
+	burst := math.Max(r.rate, 1)
+	now := r.now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sweep(now)
+	b, ok := r.buckets[operationName]
+	if !ok {
+		if len(r.buckets) >= samplerMaxOperations {
+			operationName = _otherOperation
+			b, ok = r.buckets[operationName]
+		}
+		if !ok {
+			b = &tokenBucket{tokens: burst, last: now}
+			r.buckets[operationName] = b
+		}
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*r.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, 0
+	}
+	b.tokens--
+	return true, 1
+}
+
+// sweep removes the idle buckets, it must be called with lock held.
+func (r *rateLimitingSampling) sweep(now time.Time) {
+	if now.Before(r.nextSweep) {
+		return
+	}
+	r.nextSweep = now.Add(_samplerIdleTimeout)
+	for name, b := range r.buckets {
+		if now.Sub(b.last) > _samplerIdleTimeout {
+			delete(r.buckets, name)
+		}
+	}
+}
+
+func (r *rateLimitingSampling) Close() error { return nil }
+
+const _adaptiveWindow = time.Second
+
+// adaptiveSampling adjusts the probability of each operation every second by
+// the observed qps, so that every operation reports about target traces per second.
+type adaptiveSampling struct {
+	target         float64
+	minProbability float64
+	mu             sync.Mutex
+	operations     map[string]*adaptiveOperation
+	nextSweep      time.Time
+	now            func() time.Time
+}
+
+type adaptiveOperation struct {
+	windowStart time.Time
+	count       int64
+	probability float64
+	lastSampled int64
+}
+
+// newAdaptiveSampler new adaptive sampler, target is traces per second per operation,
+// minProbability is the lower bound of probability for high qps operations.
+func newAdaptiveSampler(target float64, minProbability float64) sampler {
+	if target <= 0 {
+		panic("target must be greater than 0")
+	}
+	if minProbability <= 0 || minProbability > 1 {
+		minProbability = _probability
+	}
+	return &adaptiveSampling{
+		target:         target,
+		minProbability: minProbability,
+		operations:     make(map[string]*adaptiveOperation),
+		now:            time.Now,
+	}
+}
+
+func (a *adaptiveSampling) IsSampled(traceID uint64, operationName string) (bool, float32) {
+	if isIgnored(operationName) {
+		return false, 0
+	}
+	now := a.now()
+	a.mu.Lock()
+	a.sweep(now)
+	op, ok := a.operations[operationName]
+	if !ok {
+		if len(a.operations) >= samplerMaxOperations {
+			operationName = _otherOperation
+			op, ok = a.operations[operationName]
+		}
+		if !ok {
+			op = &adaptiveOperation{windowStart: now, probability: 1}
+			a.operations[operationName] = op
+		}
+	}
+	if elapsed := now.Sub(op.windowStart); elapsed >= _adaptiveWindow {
+		qps := float64(op.count) / elapsed.Seconds()
+		op.probability = math.Max(a.minProbability, math.Min(1, a.target/qps))
+		op.windowStart = now
+		op.count = 0
+	}
+	op.count++
+	probability := op.probability
+	// always sample the first trace of every second like probabilitySampling
+	if sec := now.Unix(); op.lastSampled != sec {
+		op.lastSampled = sec
+		a.mu.Unlock()
+		return true, 1
+	}
+	a.mu.Unlock()
+	return rand.Float64() < probability, float32(probability)
+}
+
+// sweep removes the idle operations, it must be called with lock held. The
+// window of an operation in use starts within _adaptiveWindow.
+func (a *adaptiveSampling) sweep(now time.Time) {
+	if now.Before(a.nextSweep) {
+		return
+	}
+	a.nextSweep = now.Add(_samplerIdleTimeout)
+	for name, op := range a.operations {
+		if now.Sub(op.windowStart) > _samplerIdleTimeout {
+			delete(a.operations, name)
+		}
+	}
+}
+
+func (a *adaptiveSampling) Close() error { return nil }
@@ -2,6 +2,9 @@ package trace
 
 import (
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestProbabilitySampling(t *testing.T) {
@@ -33,3 +36,86 @@ func BenchmarkProbabilitySampling(b *testing.B) {
 		sampler.IsSampled(0, "test_opt_xxx")
 	}
 }
+
+func TestRateLimitingSampling(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := newRateLimitingSampler(2).(*rateLimitingSampling)
+	s.now = func() time.Time { return now }
+	count := func(op string, n int) (c int) {
+		for i := 0; i < n; i++ {
+			if sampled, _ := s.IsSampled(0, op); sampled {
+				c++
+			}
+		}
+		return
+	}
+	assert.Equal(t, 2, count("op1", 10))
+	assert.Equal(t, 2, count("op2", 10))
+	now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, 1, count("op1", 10))
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, 2, count("op1", 10))
+	assert.Equal(t, 0, count("/metrics", 10))
+}
+
+func TestAdaptiveSampling(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := newAdaptiveSampler(10, 0.001).(*adaptiveSampling)
+	s.now = func() time.Time { return now }
+	// first window samples everything
+	for i := 0; i < 1000; i++ {
+		s.IsSampled(0, "hot")
+		s.IsSampled(0, "cold")
+	}
+	now = now.Add(time.Second)
+	s.IsSampled(0, "hot")
+	assert.InDelta(t, 0.01, s.operations["hot"].probability, 0.0001)
+	count := 0
+	for i := 0; i < 100000; i++ {
+		if sampled, _ := s.IsSampled(0, "hot"); sampled {
+			count++
+		}
+	}
+	assert.InDelta(t, 1000, count, 200)
+
+	s = newAdaptiveSampler(10, 0.05).(*adaptiveSampling)
+	s.now = func() time.Time { return now }
+	for i := 0; i < 100000; i++ {
+		s.IsSampled(0, "hot")
+	}
+	now = now.Add(time.Second)
+	s.IsSampled(0, "hot")
+	assert.Equal(t, 0.05, s.operations["hot"].probability)
+}
+
+func TestSamplingOperationsBounded(t *testing.T) {
+	max := samplerMaxOperations
+	samplerMaxOperations = 2
+	defer func() { samplerMaxOperations = max }()
+
+	now := time.Unix(1000, 0)
+	r := newRateLimitingSampler(1).(*rateLimitingSampling)
+	r.now = func() time.Time { return now }
+	a := newAdaptiveSampler(1, 0.1).(*adaptiveSampling)
+	a.now = func() time.Time { return now }
+	for _, op := range []string{"/a", "/b", "/c", "/d"} {
+		r.IsSampled(0, op)
+		a.IsSampled(0, op)
+	}
+	// the operations beyond the cap share one entry.
+	assert.Len(t, r.buckets, 3)
+	assert.Contains(t, r.buckets, _otherOperation)
+	assert.Len(t, a.operations, 3)
+	assert.Contains(t, a.operations, _otherOperation)
+	sampled, _ := r.IsSampled(0, "/e")
+	assert.False(t, sampled)
+
+	// the idle operations are swept.
+	now = now.Add(2 * _samplerIdleTimeout)
+	r.IsSampled(0, "/e")
+	a.IsSampled(0, "/e")
+	assert.Len(t, r.buckets, 1)
+	assert.Contains(t, r.buckets, "/e")
+	assert.Len(t, a.operations, 1)
+	assert.Contains(t, a.operations, "/e")
+}
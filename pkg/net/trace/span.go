@@ -25,6 +25,8 @@ type Span struct {
 	tags          []Tag
 	logs          []*protogen.Log
 	childs        int
+	// localRoot the first span of the trace in this process.
+	localRoot bool
 }
 
 func (s *Span) ServiceName() string {
@@ -87,7 +89,7 @@ func (s *Span) Finish(perr *error) {
 }
 
 func (s *Span) SetTag(tags ...Tag) Trace {
-	if !s.context.isSampled() && !s.context.isDebug() {
+	if !s.context.isRecording() {
 		return s
 	}
 	if len(s.tags) < _maxTags {
@@ -102,7 +104,7 @@ func (s *Span) SetTag(tags ...Tag) Trace {
 // LogFields is an efficient and type-checked way to record key:value
 // NOTE current unsupport
 func (s *Span) SetLog(logs ...LogField) Trace {
-	if !s.context.isSampled() && !s.context.isDebug() {
+	if !s.context.isRecording() {
 		return s
 	}
 	if len(s.logs) < _maxLogs {
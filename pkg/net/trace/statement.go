@@ -0,0 +1,150 @@
+package trace
+
+import (
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// _redactStatement replaces literal values of db.statement with ?.
+var _redactStatement int32
+
+// SetRedactStatement enables or disables redacting literal values of the
+// statements recorded by database and cache clients.
+func SetRedactStatement(redact bool) {
+	var v int32
+	if redact {
+		v = 1
+	}
+	atomic.StoreInt32(&_redactStatement, v)
+}
+
+// RedactStatement reports whether literal values of statements are redacted.
+func RedactStatement() bool {
+	return atomic.LoadInt32(&_redactStatement) == 1
+}
+
+// SQLStatement returns the normalized sql for the db.statement tag, the
+// whitespaces are collapsed and literal values are replaced with ? if
+// SetRedactStatement(true).
+func SQLStatement(query string) string {
+	return normalizeSQL(query, RedactStatement())
+}
+
+// RedactSQL returns the normalized sql whose literal values are replaced with ?.
+func RedactSQL(query string) string {
+	return normalizeSQL(query, true)
+}
+
+// CacheStatement returns the db.statement of cache command, the arguments
+// after the keys are literal values and replaced with ? if SetRedactStatement(true).
+func CacheStatement(command string, keys []string, args ...string) string {
+	var b strings.Builder
+	b.WriteString(command)
+	for _, key := range keys {
+		b.WriteByte(' ')
+		b.WriteString(key)
+	}
+	redact := RedactStatement()
+	for _, arg := range args {
+		b.WriteByte(' ')
+		if redact {
+			b.WriteByte('?')
+		} else {
+			b.WriteString(arg)
+		}
+	}
+	return b.String()
+}
+
+func normalizeSQL(query string, redact bool) string {
+	var (
+		b     strings.Builder
+		space bool
+		rs    = []rune(query)
+	)
+	b.Grow(len(query))
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		case space:
+			if b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+		}
+		switch {
+		case r == '\'' || r == '"' || r == '`':
+			end := closeQuote(rs, i)
+			if redact && r != '`' {
+				b.WriteByte('?')
+			} else {
+				b.WriteString(string(rs[i:end]))
+			}
+			i = end - 1
+		case redact && isNumberStart(rs, i):
+			end := i + 1
+			for end < len(rs) && (isIdentRune(rs[end]) || rs[end] == '.') {
+				end++
+			}
+			b.WriteByte('?')
+			i = end - 1
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// closeQuote returns the index after the closing quote started at i.
+func closeQuote(rs []rune, i int) int {
+	quote := rs[i]
+	for j := i + 1; j < len(rs); j++ {
+		switch rs[j] {
+		case '\\':
+			j++
+		case quote:
+			// doubled quote is an escaped quote
+			if j+1 < len(rs) && rs[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(rs)
+}
+
+func isNumberStart(rs []rune, i int) bool {
+	r := rs[i]
+	if r == '-' || r == '+' || r == '.' {
+		if i+1 >= len(rs) || !unicode.IsDigit(rs[i+1]) {
+			return false
+		}
+		// a sign is part of the number only after an operator or opening
+		if r != '.' && i > 0 && !isOperatorBefore(rs, i) {
+			return false
+		}
+	} else if !unicode.IsDigit(r) {
+		return false
+	}
+	// digits inside identifiers, e.g. table_01, are not literals
+	return i == 0 || !isIdentRune(rs[i-1])
+}
+
+func isOperatorBefore(rs []rune, i int) bool {
+	for j := i - 1; j >= 0; j-- {
+		if unicode.IsSpace(rs[j]) {
+			continue
+		}
+		return strings.ContainsRune("=<>(,+-*/", rs[j])
+	}
+	return true
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,27 @@
+package trace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLStatement(t *testing.T) {
+	query := "SELECT id,  name\n\tFROM `user_01` WHERE id = 10 AND name='a''b\\'c' AND score > -1.5 AND mid IN (1, 2) AND x = ?"
+	assert.Equal(t, "SELECT id, name FROM `user_01` WHERE id = 10 AND name='a''b\\'c' AND score > -1.5 AND mid IN (1, 2) AND x = ?", SQLStatement(query))
+	assert.Equal(t, "SELECT id, name FROM `user_01` WHERE id = ? AND name=? AND score > ? AND mid IN (?, ?) AND x = ?", RedactSQL(query))
+	assert.Equal(t, "UPDATE t SET a=a-? WHERE b=?", RedactSQL("UPDATE t SET a=a-1 WHERE b=0x1f"))
+	assert.Equal(t, "INSERT INTO t2 VALUES(?,?)", RedactSQL("  INSERT INTO t2 VALUES(\"x\",2)  "))
+
+	SetRedactStatement(true)
+	defer SetRedactStatement(false)
+	assert.Equal(t, "SELECT * FROM t WHERE id=?", SQLStatement("SELECT * FROM t WHERE id=1"))
+}
+
+func TestCacheStatement(t *testing.T) {
+	assert.Equal(t, "SET key 1", CacheStatement("SET", []string{"key"}, "1"))
+	assert.Equal(t, "GET key", CacheStatement("GET", []string{"key"}))
+	SetRedactStatement(true)
+	defer SetRedactStatement(false)
+	assert.Equal(t, "INCR a b ?", CacheStatement("INCR", []string{"a", "b"}, "10"))
+}
@@ -84,6 +84,14 @@ const (
 	// type string
 	TagSpanKind = "span.kind"
 
+	// Number of rows affected by a database statement, e.g. the RowsAffected of sql exec.
+	// type integer
+	TagDBRowsAffected = "db.rows_affected"
+
+	// true if the cache lookup found all of the keys, false if any key missed.
+	// type bool
+	TagCacheHit = "cache.hit"
+
 	// legacy tag
 	TagAnnotation = "legacy.annotation"
 	TagAddress    = "legacy.address"
@@ -0,0 +1,160 @@
+package trace
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	_defaultTailLatency   = time.Second
+	_defaultTailTimeout   = 10 * time.Second
+	_defaultTailMaxTraces = 10000
+)
+
+// tailReporter buffers the spans of unsampled traces in process until the
+// local root span finished, the trace is reported if any span has error or
+// the local root span exceeds the latency threshold, otherwise dropped.
+type tailReporter struct {
+	next      reporter
+	latency   time.Duration
+	timeout   time.Duration
+	maxTraces int
+
+	mu        sync.Mutex
+	traces    map[uint64]*tailTrace
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+type tailTrace struct {
+	spans    []*Span
+	keep     bool
+	deadline time.Time
+}
+
+func newTailReporter(next reporter, latency, timeout time.Duration, maxTraces int) *tailReporter {
+	if latency <= 0 {
+		latency = _defaultTailLatency
+	}
+	if timeout <= 0 {
+		timeout = _defaultTailTimeout
+	}
+	if maxTraces <= 0 {
+		maxTraces = _defaultTailMaxTraces
+	}
+	return &tailReporter{
+		next:      next,
+		latency:   latency,
+		timeout:   timeout,
+		maxTraces: maxTraces,
+		traces:    make(map[uint64]*tailTrace),
+		now:       time.Now,
+	}
+}
+
+// WriteSpan write sampled span to next reporter directly, buffer the others.
+func (r *tailReporter) WriteSpan(sp *Span) error {
+	if sp.context.isSampled() {
+		return r.next.WriteSpan(sp)
+	}
+	now := r.now()
+	traceID := sp.context.TraceID
+	r.mu.Lock()
+	expired := r.sweep(now)
+	t, ok := r.traces[traceID]
+	if !ok {
+		if len(r.traces) >= r.maxTraces {
+			r.mu.Unlock()
+			r.flush(expired)
+			return fmt.Errorf("tail sampling buffer is full, drop trace %x", traceID)
+		}
+		t = &tailTrace{deadline: now.Add(r.timeout)}
+		r.traces[traceID] = t
+	}
+	t.spans = append(t.spans, copySpan(sp))
+	if hasError(sp) {
+		t.keep = true
+	}
+	var done *tailTrace
+	if sp.localRoot {
+		if sp.duration >= r.latency {
+			t.keep = true
+		}
+		delete(r.traces, traceID)
+		done = t
+	}
+	r.mu.Unlock()
+	if done != nil {
+		expired = append(expired, done)
+	}
+	return r.flush(expired)
+}
+
+// sweep removes the traces whose local root span never finished in timeout.
+func (r *tailReporter) sweep(now time.Time) (expired []*tailTrace) {
+	if now.Before(r.nextSweep) {
+		return
+	}
+	r.nextSweep = now.Add(time.Second)
+	for id, t := range r.traces {
+		if now.After(t.deadline) {
+			delete(r.traces, id)
+			expired = append(expired, t)
+		}
+	}
+	return
+}
+
+func (r *tailReporter) flush(traces []*tailTrace) (err error) {
+	for _, t := range traces {
+		if !t.keep {
+			continue
+		}
+		for _, sp := range t.spans {
+			sp.context.Flags |= flagSampled
+			if werr := r.next.WriteSpan(sp); werr != nil {
+				err = werr
+			}
+		}
+	}
+	return
+}
+
+// Close report the buffered traces which have to be kept and close next reporter.
+func (r *tailReporter) Close() error {
+	r.mu.Lock()
+	traces := make([]*tailTrace, 0, len(r.traces))
+	for id, t := range r.traces {
+		delete(r.traces, id)
+		traces = append(traces, t)
+	}
+	r.mu.Unlock()
+	r.flush(traces)
+	return r.next.Close()
+}
+
+func hasError(sp *Span) bool {
+	for _, tag := range sp.tags {
+		if tag.Key == TagError {
+			if b, ok := tag.Value.(bool); ok && b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// copySpan copy the span out of pool.
+func copySpan(sp *Span) *Span {
+	return &Span{
+		dapper:        sp.dapper,
+		context:       sp.context,
+		operationName: sp.operationName,
+		startTime:     sp.startTime,
+		duration:      sp.duration,
+		tags:          append([]Tag(nil), sp.tags...),
+		logs:          append(sp.logs[:0:0], sp.logs...),
+		localRoot:     sp.localRoot,
+	}
+}
@@ -0,0 +1,112 @@
+package trace
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordReport struct {
+	names []string
+}
+
+func (r *recordReport) WriteSpan(sp *Span) error {
+	r.names = append(r.names, sp.OperationName())
+	return nil
+}
+
+func (r *recordReport) Close() error { return nil }
+
+func newTailTracer(report reporter) *dapper {
+	return NewTracer("service1", report, false,
+		WithRateLimitingSampler(1),
+		WithTailSampling(100*time.Millisecond, time.Second, 2),
+	).(*dapper)
+}
+
+func TestTailSampling(t *testing.T) {
+	t.Run("head sampled", func(t *testing.T) {
+		report := &recordReport{}
+		tracer := newTailTracer(report)
+		root := tracer.New("root")
+		root.Fork("", "child").Finish(nil)
+		assert.Equal(t, []string{"child"}, report.names)
+		root.Finish(nil)
+		assert.Equal(t, []string{"child", "root"}, report.names)
+	})
+	t.Run("drop normal trace", func(t *testing.T) {
+		report := &recordReport{}
+		tracer := newTailTracer(report)
+		tracer.New("op").Finish(nil)
+		report.names = nil
+		root := tracer.New("op")
+		assert.False(t, root.(*Span).context.isSampled())
+		root.Fork("", "child").Finish(nil)
+		root.Finish(nil)
+		assert.Empty(t, report.names)
+		assert.Empty(t, tracer.reporter.(*tailReporter).traces)
+	})
+	t.Run("keep error trace", func(t *testing.T) {
+		report := &recordReport{}
+		tracer := newTailTracer(report)
+		tracer.New("op").Finish(nil)
+		report.names = nil
+		root := tracer.New("op")
+		child := root.Fork("", "child")
+		child.SetTag(TagString("db.statement", "select 1"))
+		err := errors.New("boom")
+		child.Finish(&err)
+		assert.Empty(t, report.names)
+		root.Finish(nil)
+		assert.Equal(t, []string{"child", "op"}, report.names)
+	})
+	t.Run("keep slow trace", func(t *testing.T) {
+		report := &recordReport{}
+		tracer := newTailTracer(report)
+		tracer.New("op").Finish(nil)
+		report.names = nil
+		root := tracer.New("op")
+		root.(*Span).startTime = time.Now().Add(-time.Second)
+		root.Finish(nil)
+		assert.Equal(t, []string{"op"}, report.names)
+	})
+	t.Run("extracted root", func(t *testing.T) {
+		report := &recordReport{}
+		tracer := newTailTracer(report)
+		header := make(http.Header)
+		header.Set(KratosTraceID, "1:2:0:0")
+		sp, err := tracer.Extract(HTTPFormat, header)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, sp.(*Span).localRoot)
+		perr := errors.New("boom")
+		sp.Finish(&perr)
+		assert.Len(t, report.names, 1)
+	})
+	t.Run("buffer full and timeout", func(t *testing.T) {
+		report := &recordReport{}
+		tracer := newTailTracer(report)
+		tr := tracer.reporter.(*tailReporter)
+		now := time.Now()
+		tr.now = func() time.Time { return now }
+		tracer.New("op").Finish(nil)
+		report.names = nil
+		var roots []Trace
+		for i := 0; i < 3; i++ {
+			root := tracer.New("op")
+			err := errors.New("boom")
+			root.Fork("", "child").Finish(&err)
+			roots = append(roots, root)
+		}
+		assert.Len(t, tr.traces, 2)
+		now = now.Add(2 * time.Second)
+		roots[2].Fork("", "late").Finish(nil)
+		assert.Equal(t, []string{"child", "child"}, report.names)
+		assert.NoError(t, tracer.Close())
+		assert.Equal(t, []string{"child", "child"}, report.names)
+	})
+}
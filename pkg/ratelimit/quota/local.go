@@ -0,0 +1,105 @@
+package quota
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const _sweepInterval = time.Minute
+
+type bucket struct {
+	// token bucket
+	tokens float64
+	// sliding window
+	window int64
+	prev   int64
+	curr   int64
+
+	last    time.Time
+	expires time.Time
+}
+
+// localStore counts quotas in process.
+type localStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+// NewLocalStore returns a store counting quotas in memory of the process.
+func NewLocalStore() Store {
+	return &localStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (s *localStore) Take(_ context.Context, key string, rule *Rule, n int64) (res *Result, err error) {
+	if err = rule.Validate(); err != nil {
+		return
+	}
+	s.mu.Lock()
+	now := s.now()
+	s.sweep(now)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rule.burst()), last: now}
+		s.buckets[key] = b
+	}
+	switch rule.Algorithm {
+	case SlidingWindow:
+		res = s.slidingWindow(b, rule, n, now)
+	default:
+		res = s.tokenBucket(b, rule, n, now)
+	}
+	b.expires = now.Add(2 * rule.window())
+	s.mu.Unlock()
+	return
+}
+
+func (s *localStore) tokenBucket(b *bucket, rule *Rule, n int64, now time.Time) *Result {
+	rate := rule.rate()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(rule.burst()), b.tokens+float64(elapsed)/float64(time.Millisecond)*rate)
+		b.last = now
+	}
+	if b.tokens >= float64(n) {
+		b.tokens -= float64(n)
+		return &Result{Allowed: true, Remaining: int64(b.tokens)}
+	}
+	wait := time.Duration(math.Ceil((float64(n)-b.tokens)/rate)) * time.Millisecond
+	return &Result{RetryAfter: wait}
+}
+
+func (s *localStore) slidingWindow(b *bucket, rule *Rule, n int64, now time.Time) *Result {
+	size := rule.window()
+	window := now.UnixNano() / int64(size)
+	switch {
+	case window == b.window+1:
+		b.prev, b.curr = b.curr, 0
+	case window != b.window:
+		b.prev, b.curr = 0, 0
+	}
+	b.window = window
+	res := slidingWindow(rule, b.prev, b.curr, n, time.Duration(now.UnixNano()%int64(size)))
+	if res.Allowed {
+		b.curr += n
+	}
+	return res
+}
+
+// sweep removes the expired buckets, it must be called with lock held.
+func (s *localStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < _sweepInterval {
+		return
+	}
+	s.swept = now
+	for key, b := range s.buckets {
+		if now.After(b.expires) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStore(now *time.Time) *localStore {
+	s := NewLocalStore().(*localStore)
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := newTestStore(&now)
+	rule := &Rule{Algorithm: TokenBucket, Limit: 10, Window: xtime.Duration(time.Second), Burst: 5}
+	for i := 0; i < 5; i++ {
+		res, err := s.Take(context.TODO(), "foo", rule, 1)
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, int64(4-i), res.Remaining)
+	}
+	res, _ := s.Take(context.TODO(), "foo", rule, 1)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 100*time.Millisecond, res.RetryAfter)
+
+	// other keys have their own bucket
+	res, _ = s.Take(context.TODO(), "bar", rule, 1)
+	assert.True(t, res.Allowed)
+
+	now = now.Add(250 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		res, _ = s.Take(context.TODO(), "foo", rule, 1)
+		assert.True(t, res.Allowed)
+	}
+	res, _ = s.Take(context.TODO(), "foo", rule, 1)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 50*time.Millisecond, res.RetryAfter)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := newTestStore(&now)
+	rule := &Rule{Algorithm: SlidingWindow, Limit: 4, Window: xtime.Duration(time.Second)}
+	for i := 0; i < 4; i++ {
+		res, _ := s.Take(context.TODO(), "foo", rule, 1)
+		assert.True(t, res.Allowed)
+	}
+	res, _ := s.Take(context.TODO(), "foo", rule, 1)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+
+	// half of the previous window is still counted
+	now = now.Add(1500 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		res, _ = s.Take(context.TODO(), "foo", rule, 1)
+		assert.True(t, res.Allowed)
+	}
+	res, _ = s.Take(context.TODO(), "foo", rule, 1)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 250*time.Millisecond, res.RetryAfter)
+
+	// the previous window is dropped after two windows
+	now = now.Add(2 * time.Second)
+	res, _ = s.Take(context.TODO(), "foo", rule, 4)
+	assert.True(t, res.Allowed)
+}
+
+func TestLocalStoreSweep(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := newTestStore(&now)
+	rule := &Rule{Limit: 1, Window: xtime.Duration(time.Second)}
+	s.Take(context.TODO(), "foo", rule, 1)
+	assert.Len(t, s.buckets, 1)
+	now = now.Add(2 * _sweepInterval)
+	s.Take(context.TODO(), "bar", rule, 1)
+	assert.Len(t, s.buckets, 1)
+}
+
+func TestLocalStoreInvalidRule(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := newTestStore(&now)
+	for _, rule := range []*Rule{{Limit: 0}, {Algorithm: SlidingWindow, Limit: -1}} {
+		_, err := s.Take(context.TODO(), "foo", rule, 1)
+		assert.Equal(t, ErrInvalidRule, err)
+	}
+	assert.Len(t, s.buckets, 0)
+}
@@ -0,0 +1,103 @@
+// Package quota provides token bucket and sliding window quotas, which are
+// counted in process by the local store or cluster wide by the redis store.
+package quota
+
+import (
+	"context"
+	"math"
+	"time"
+
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidRule the limit of the rule is not positive.
+var ErrInvalidRule = errors.New("quota: limit of rule must be positive")
+
+// Algorithm quota algorithm type.
+type Algorithm string
+
+const (
+	// TokenBucket refills Limit tokens every Window and allows bursts up to Burst.
+	TokenBucket Algorithm = "token_bucket"
+	// SlidingWindow allows at most Limit requests in any Window, it's
+	// approximated by weighting the count of the previous fixed window.
+	SlidingWindow Algorithm = "sliding_window"
+)
+
+// Rule contains configs of a quota.
+type Rule struct {
+	Algorithm Algorithm
+	// Limit is the number of requests allowed in Window.
+	Limit  int64
+	Window xtime.Duration
+	// Burst is the capacity of token bucket, default is Limit.
+	Burst int64
+}
+
+// Result is the result of a quota take.
+type Result struct {
+	Allowed bool
+	// Remaining is the number of requests still allowed right now.
+	Remaining int64
+	// RetryAfter is the duration to wait before the next request is allowed.
+	RetryAfter time.Duration
+}
+
+// Store counts the quotas.
+type Store interface {
+	// Take takes n requests from the quota of key under rule.
+	Take(ctx context.Context, key string, rule *Rule, n int64) (*Result, error)
+}
+
+// Validate reports ErrInvalidRule if the rule allows nothing, which has no
+// meaningful retry after.
+func (r *Rule) Validate() error {
+	if r.Limit <= 0 {
+		return ErrInvalidRule
+	}
+	return nil
+}
+
+func (r *Rule) window() time.Duration {
+	if r.Window <= 0 {
+		return time.Second
+	}
+	return time.Duration(r.Window)
+}
+
+func (r *Rule) burst() int64 {
+	if r.Burst <= 0 {
+		return r.Limit
+	}
+	return r.Burst
+}
+
+// rate returns the tokens refilled per millisecond, the window may be shorter
+// than a millisecond.
+func (r *Rule) rate() float64 {
+	return float64(r.Limit) / (float64(r.window()) / float64(time.Millisecond))
+}
+
+// slidingWindow calculates the result of taking n requests from a sliding
+// window, prev and curr are the counts of the previous and current fixed
+// window, elapsed is the time passed in the current fixed window.
+func slidingWindow(rule *Rule, prev, curr, n int64, elapsed time.Duration) *Result {
+	window := rule.window()
+	weight := float64(window-elapsed) / float64(window)
+	count := float64(prev)*weight + float64(curr)
+	if count+float64(n) <= float64(rule.Limit) {
+		return &Result{Allowed: true, Remaining: int64(float64(rule.Limit) - count - float64(n))}
+	}
+	// wait until the current window rolls over when it's exhausted by itself,
+	// otherwise until the previous window decays enough.
+	var wait time.Duration
+	if curr+n > rule.Limit || prev == 0 {
+		wait = window - elapsed
+	} else {
+		need := count + float64(n) - float64(rule.Limit)
+		wait = time.Duration(math.Ceil(need / float64(prev) * float64(window)))
+	}
+	return &Result{RetryAfter: wait}
+}
@@ -0,0 +1,133 @@
+package quota
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/cache/redis"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// KEYS[1] the bucket
+	// ARGV[1] capacity, ARGV[2] tokens refilled per millisecond,
+	// ARGV[3] now in millisecond, ARGV[4] requested, ARGV[5] ttl in millisecond
+	_tokenBucketScript = redis.NewScript(1, `
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local requested = tonumber(ARGV[4])
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+if now > ts then
+	tokens = math.min(capacity, tokens + (now - ts) * rate)
+	ts = now
+end
+local allowed = 0
+local wait = 0
+if tokens >= requested then
+	tokens = tokens - requested
+	allowed = 1
+else
+	wait = math.ceil((requested - tokens) / rate)
+end
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "ts", ts)
+redis.call("PEXPIRE", KEYS[1], ARGV[5])
+return {allowed, math.floor(tokens), wait}
+`)
+	// KEYS[1] the current window, KEYS[2] the previous window, they share the
+	// hash tag so that they're in the same slot of redis cluster.
+	// ARGV[1] limit, ARGV[2] window in millisecond, ARGV[3] elapsed in millisecond, ARGV[4] requested
+	_slidingWindowScript = redis.NewScript(2, `
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+local requested = tonumber(ARGV[4])
+local curr = tonumber(redis.call("GET", KEYS[1]) or "0")
+local prev = tonumber(redis.call("GET", KEYS[2]) or "0")
+if prev * (window - elapsed) / window + curr + requested <= limit then
+	curr = redis.call("INCRBY", KEYS[1], requested)
+	redis.call("PEXPIRE", KEYS[1], window * 2)
+	return {1, prev, curr - requested}
+end
+return {0, prev, curr}
+`)
+)
+
+// redisStore counts quotas in redis shared by the cluster.
+type redisStore struct {
+	redis  *redis.Redis
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedisStore returns a store counting quotas in redis, keys are prefixed by
+// prefix, which must not contain the hash tag braces.
+func NewRedisStore(r *redis.Redis, prefix string) Store {
+	return &redisStore{redis: r, prefix: prefix, now: time.Now}
+}
+
+// key returns the redis key of the quota key, the quota key is the hash tag so
+// that all the keys of a quota are in the same slot of redis cluster.
+func (s *redisStore) key(key string) string {
+	return s.prefix + "{" + key + "}"
+}
+
+func (s *redisStore) Take(ctx context.Context, key string, rule *Rule, n int64) (*Result, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	conn := s.redis.Conn(ctx)
+	defer conn.Close()
+	if rule.Algorithm == SlidingWindow {
+		return s.slidingWindow(conn, key, rule, n)
+	}
+	return s.tokenBucket(conn, key, rule, n)
+}
+
+func (s *redisStore) tokenBucket(conn redis.Conn, key string, rule *Rule, n int64) (*Result, error) {
+	now := s.now().UnixNano() / int64(time.Millisecond)
+	ttl := int64(2 * rule.window() / time.Millisecond)
+	reply, err := redis.Int64s(_tokenBucketScript.Do(conn, s.key(key),
+		rule.burst(), strconv.FormatFloat(rule.rate(), 'f', -1, 64), now, n, ttl))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(reply) != 3 {
+		return nil, errors.Errorf("quota: unexpected token bucket reply %v", reply)
+	}
+	return &Result{
+		Allowed:    reply[0] == 1,
+		Remaining:  reply[1],
+		RetryAfter: time.Duration(reply[2]) * time.Millisecond,
+	}, nil
+}
+
+func (s *redisStore) slidingWindow(conn redis.Conn, key string, rule *Rule, n int64) (*Result, error) {
+	size := rule.window()
+	now := s.now().UnixNano()
+	window := now / int64(size)
+	elapsed := time.Duration(now % int64(size))
+	reply, err := redis.Int64s(_slidingWindowScript.Do(conn,
+		s.key(key)+":"+strconv.FormatInt(window, 10),
+		s.key(key)+":"+strconv.FormatInt(window-1, 10),
+		rule.Limit, int64(size/time.Millisecond), int64(elapsed/time.Millisecond), n))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(reply) != 3 {
+		return nil, errors.Errorf("quota: unexpected sliding window reply %v", reply)
+	}
+	res := slidingWindow(rule, reply[1], reply[2], n, elapsed)
+	// the script has the final say, the local calculation only provides
+	// remaining and retry after.
+	res.Allowed = reply[0] == 1
+	return res, nil
+}
@@ -0,0 +1,78 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/cache/redis"
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// scriptConn replies the scripts with reply and records the keys and args.
+type scriptConn struct {
+	redis.Conn
+	args  []interface{}
+	reply []interface{}
+}
+
+func (c *scriptConn) Do(commandName string, args ...interface{}) (interface{}, error) {
+	c.args = args
+	return c.reply, nil
+}
+
+func newTestRedisStore(now time.Time) *redisStore {
+	return &redisStore{prefix: "quota:", now: func() time.Time { return now }}
+}
+
+func TestRedisTokenBucket(t *testing.T) {
+	s := newTestRedisStore(time.Unix(1000, 0))
+	rule := &Rule{Algorithm: TokenBucket, Limit: 10, Window: xtime.Duration(time.Second), Burst: 5}
+	conn := &scriptConn{reply: []interface{}{int64(0), int64(0), int64(100)}}
+	res, err := s.tokenBucket(conn, "foo", rule, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, &Result{RetryAfter: 100 * time.Millisecond}, res)
+	// sha, numkeys, key, capacity, rate, now, requested, ttl
+	assert.Equal(t, []interface{}{1, "quota:{foo}", int64(5), "0.01", int64(1000000), int64(1), int64(2000)}, conn.args[1:])
+
+	conn.reply = []interface{}{int64(1)}
+	_, err = s.tokenBucket(conn, "foo", rule, 1)
+	assert.Error(t, err)
+}
+
+func TestRedisSlidingWindow(t *testing.T) {
+	s := newTestRedisStore(time.Unix(1000, int64(250*time.Millisecond)))
+	rule := &Rule{Algorithm: SlidingWindow, Limit: 4, Window: xtime.Duration(time.Second)}
+	conn := &scriptConn{reply: []interface{}{int64(1), int64(4), int64(0)}}
+	res, err := s.slidingWindow(conn, "foo", rule, 1)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, []interface{}{2, "quota:{foo}:1000", "quota:{foo}:999", int64(4), int64(1000), int64(250), int64(1)}, conn.args[1:])
+
+	// the script has the final say.
+	conn.reply = []interface{}{int64(0), int64(0), int64(0)}
+	res, err = s.slidingWindow(conn, "foo", rule, 1)
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+}
+
+func TestLocalTokenBucketHotKey(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := newTestStore(&now)
+	rule := &Rule{Algorithm: TokenBucket, Limit: 10000, Window: xtime.Duration(time.Second), Burst: 1}
+	res, _ := s.Take(context.TODO(), "foo", rule, 1)
+	assert.True(t, res.Allowed)
+	// 10 tokens per millisecond, the bucket refills within a millisecond.
+	for i := 0; i < 100; i++ {
+		now = now.Add(100 * time.Microsecond)
+		res, _ = s.Take(context.TODO(), "foo", rule, 1)
+		assert.True(t, res.Allowed)
+	}
+
+	rule = &Rule{Algorithm: TokenBucket, Limit: 1, Window: xtime.Duration(time.Microsecond)}
+	res, _ = s.Take(context.TODO(), "bar", rule, 1)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1000.0, rule.rate())
+}
@@ -1,9 +1,29 @@
 package metric
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/go-kratos/kratos/pkg/net/trace"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// _exemplarTraceID the exemplar label of trace id.
+const _exemplarTraceID = "trace_id"
+
+// _nativeBucketFactor the default NativeBucketFactor of histograms, set by
+// env PROM_NATIVE_HISTOGRAM, such as 1.1.
+var _nativeBucketFactor float64
+
+func init() {
+	if v := os.Getenv("PROM_NATIVE_HISTOGRAM"); v != "" {
+		_nativeBucketFactor, _ = strconv.ParseFloat(v, 64)
+	}
+}
+
 // HistogramVecOpts is histogram vector opts.
 type HistogramVecOpts struct {
 	Namespace string
@@ -12,12 +32,28 @@ type HistogramVecOpts struct {
 	Help      string
 	Labels    []string
 	Buckets   []float64
+
+	// NativeBucketFactor enable native histogram if greater than 1, the
+	// width of each bucket is at most NativeBucketFactor times of the previous one.
+	// Default is env PROM_NATIVE_HISTOGRAM.
+	NativeBucketFactor float64
+	// NativeZeroThreshold the observations in [-threshold, threshold] are
+	// counted in zero bucket, default 0.
+	NativeZeroThreshold float64
+	// NativeMaxBuckets halve the resolution when the native buckets exceed
+	// NativeMaxBuckets, default 160.
+	NativeMaxBuckets int
 }
 
+const _defaultNativeMaxBuckets = 160
+
 // HistogramVec gauge vec.
 type HistogramVec interface {
 	// Observe adds a single observation to the histogram.
 	Observe(v int64, labels ...string)
+	// ObserveContext adds a single observation to the histogram, the trace id
+	// of ctx is attached as exemplar if the trace may be reported.
+	ObserveContext(ctx context.Context, v int64, labels ...string)
 }
 
 // Histogram prom histogram collection.
@@ -30,14 +66,25 @@ func NewHistogramVec(cfg *HistogramVecOpts) HistogramVec {
 	if cfg == nil {
 		return nil
 	}
-	vec := prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Namespace: cfg.Namespace,
-			Subsystem: cfg.Subsystem,
-			Name:      cfg.Name,
-			Help:      cfg.Help,
-			Buckets:   cfg.Buckets,
-		}, cfg.Labels)
+	if cfg.NativeBucketFactor == 0 {
+		cfg.NativeBucketFactor = _nativeBucketFactor
+	}
+	opts := prometheus.HistogramOpts{
+		Namespace: cfg.Namespace,
+		Subsystem: cfg.Subsystem,
+		Name:      cfg.Name,
+		Help:      cfg.Help,
+		Buckets:   cfg.Buckets,
+	}
+	if cfg.NativeBucketFactor > 1 {
+		if cfg.NativeMaxBuckets <= 0 {
+			cfg.NativeMaxBuckets = _defaultNativeMaxBuckets
+		}
+		opts.NativeHistogramBucketFactor = cfg.NativeBucketFactor
+		opts.NativeHistogramZeroThreshold = cfg.NativeZeroThreshold
+		opts.NativeHistogramMaxBucketNumber = uint32(cfg.NativeMaxBuckets)
+	}
+	vec := prometheus.NewHistogramVec(opts, cfg.Labels)
 	prometheus.MustRegister(vec)
 	return &promHistogramVec{
 		histogram: vec,
@@ -48,3 +95,39 @@ func NewHistogramVec(cfg *HistogramVecOpts) HistogramVec {
 func (histogram *promHistogramVec) Observe(v int64, labels ...string) {
 	histogram.histogram.WithLabelValues(labels...).Observe(float64(v))
 }
+
+// ObserveContext adds a single observation to the histogram with exemplar.
+func (histogram *promHistogramVec) ObserveContext(ctx context.Context, v int64, labels ...string) {
+	o := histogram.histogram.WithLabelValues(labels...)
+	if ls := exemplarLabels(ctx); ls != nil {
+		if eo, ok := o.(prometheus.ExemplarObserver); ok {
+			eo.ObserveWithExemplar(float64(v), ls)
+			return
+		}
+	}
+	o.Observe(float64(v))
+}
+
+// exemplarLabels returns the trace id of ctx as exemplar labels, nil if
+// there is no trace or the trace won't be reported.
+func exemplarLabels(ctx context.Context) prometheus.Labels {
+	if ctx == nil {
+		return nil
+	}
+	t, ok := trace.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	sp, ok := t.(*trace.Span)
+	if !ok {
+		return nil
+	}
+	sc := sp.Context()
+	if !sc.IsValid() || !sc.IsRecording() {
+		return nil
+	}
+	if sc.TraceIDHigh != 0 {
+		return prometheus.Labels{_exemplarTraceID: fmt.Sprintf("%016x%016x", sc.TraceIDHigh, sc.TraceID)}
+	}
+	return prometheus.Labels{_exemplarTraceID: strconv.FormatUint(sc.TraceID, 16)}
+}
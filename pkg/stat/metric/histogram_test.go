@@ -0,0 +1,102 @@
+package metric
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/go-kratos/kratos/pkg/net/trace"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+type nopReport struct{}
+
+func (nopReport) WriteSpan(*trace.Span) error { return nil }
+func (nopReport) Close() error                { return nil }
+
+func gather(t *testing.T, name string) *dto.Metric {
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range mfs {
+		if mf.GetName() == name {
+			return mf.Metric[0]
+		}
+	}
+	t.Fatalf("metric %s not found", name)
+	return nil
+}
+
+func TestHistogramVecExemplar(t *testing.T) {
+	vec := NewHistogramVec(&HistogramVecOpts{
+		Namespace: "test",
+		Name:      "exemplar_ms",
+		Labels:    []string{"name"},
+		Buckets:   []float64{10, 100},
+	})
+	sp := trace.NewTracer("metric_test", nopReport{}, true).New("op").(*trace.Span)
+	vec.ObserveContext(trace.NewContext(context.Background(), sp), 50, "a")
+	vec.ObserveContext(context.Background(), 5, "a")
+
+	his := gather(t, "test_exemplar_ms").Histogram
+	assert.Equal(t, uint64(2), his.GetSampleCount())
+	assert.Nil(t, his.Bucket[0].Exemplar)
+	e := his.Bucket[1].Exemplar
+	if assert.NotNil(t, e) {
+		assert.Equal(t, "trace_id", e.Label[0].GetName())
+		assert.Equal(t, exemplarLabels(trace.NewContext(context.Background(), sp))["trace_id"], e.Label[0].GetValue())
+		assert.Equal(t, float64(50), e.GetValue())
+	}
+}
+
+func TestNativeHistogramVec(t *testing.T) {
+	vec := NewHistogramVec(&HistogramVecOpts{
+		Namespace:          "test",
+		Name:               "native_ms",
+		Labels:             []string{"name"},
+		Buckets:            []float64{10},
+		NativeBucketFactor: 2,
+	})
+	sp := trace.NewTracer("metric_test", nopReport{}, true).New("op").(*trace.Span)
+	for _, v := range []int64{0, 1, 2, 3, 4, 16} {
+		vec.Observe(v, "a")
+	}
+	vec.ObserveContext(trace.NewContext(context.Background(), sp), 100, "a")
+
+	his := gather(t, "test_native_ms").Histogram
+	assert.Equal(t, uint64(7), his.GetSampleCount())
+	assert.Equal(t, float64(126), his.GetSampleSum())
+	assert.Equal(t, int32(0), his.GetSchema())
+	assert.Equal(t, uint64(1), his.GetZeroCount())
+	// buckets of schema 0: (0.5,1]=0:1 (1,2]=1:1 (2,4]=2:2 (8,16]=4:1 (64,128]=7:1,
+	// the gaps of no more than 2 empty buckets are in the same span.
+	assert.Equal(t, []int64{1, 0, 1, -2, 1, -1, 0, 1}, his.PositiveDelta)
+	assert.Len(t, his.PositiveSpan, 1)
+	assert.Equal(t, int32(0), his.PositiveSpan[0].GetOffset())
+	assert.Equal(t, uint32(8), his.PositiveSpan[0].GetLength())
+	// classic buckets, the +Inf one with exemplar.
+	assert.Len(t, his.Bucket, 2)
+	assert.Equal(t, uint64(5), his.Bucket[0].GetCumulativeCount())
+	assert.True(t, math.IsInf(his.Bucket[1].GetUpperBound(), 1))
+	assert.NotNil(t, his.Bucket[1].Exemplar)
+}
+
+func TestNativeHistogramMaxBuckets(t *testing.T) {
+	vec := NewHistogramVec(&HistogramVecOpts{
+		Namespace:          "test",
+		Name:               "native_max_buckets_ms",
+		NativeBucketFactor: 1.1,
+		NativeMaxBuckets:   4,
+	})
+	for v := int64(1); v <= 64; v *= 2 {
+		vec.Observe(v)
+	}
+	// the resolution is halved from schema 3 once an observation exceeds the buckets.
+	his := gather(t, "test_native_max_buckets_ms").Histogram
+	assert.Equal(t, uint64(7), his.GetSampleCount())
+	assert.True(t, his.GetSchema() < 3)
+}
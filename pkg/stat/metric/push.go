@@ -0,0 +1,333 @@
+package metric
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/pkg/conf/env"
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	_defaultPushInterval = 10 * time.Second
+	_defaultPushTimeout  = 5 * time.Second
+	// _statsdPacketSize keeps a statsd packet in a single ethernet frame.
+	_statsdPacketSize = 1432
+)
+
+// PushConfig push exporter config.
+type PushConfig struct {
+	// Pushgateway the pushgateway url, such as http://127.0.0.1:9091.
+	Pushgateway string
+	// Job the job of pushgateway, default env.AppID.
+	Job string
+	// Grouping extra grouping labels of pushgateway, instance is env.Hostname if absent.
+	Grouping map[string]string
+
+	// StatsD the statsd address, such as 127.0.0.1:8125.
+	StatsD string
+	// DogStatsD send the labels as dogstatsd tags, otherwise the label
+	// values are appended to the metric name.
+	DogStatsD bool
+	// Prefix of the statsd metric names.
+	Prefix string
+
+	// Interval of pushing, default 10s, negative means only push on Push and Close.
+	Interval xtime.Duration
+	// Timeout of each push, default 5s.
+	Timeout xtime.Duration
+}
+
+// Pusher pushes the metrics created by NewCounterVec, NewGaugeVec and
+// NewHistogramVec to pushgateway and statsd, for the batch jobs which
+// can't be scraped.
+type Pusher struct {
+	c        *PushConfig
+	gatherer prometheus.Gatherer
+	gateway  *push.Pusher
+	statsd   *statsdClient
+
+	mu      sync.Mutex
+	closing chan struct{}
+	done    chan struct{}
+	closed  bool
+}
+
+// NewPusher new a pusher of the default prometheus registry, it pushes
+// every Interval until Close.
+func NewPusher(c *PushConfig) (*Pusher, error) {
+	return newPusher(c, prometheus.DefaultGatherer)
+}
+
+func newPusher(c *PushConfig, g prometheus.Gatherer) (*Pusher, error) {
+	if c.Pushgateway == "" && c.StatsD == "" {
+		return nil, errors.New("stat/metric: neither pushgateway nor statsd is set")
+	}
+	if c.Interval == 0 {
+		c.Interval = xtime.Duration(_defaultPushInterval)
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = xtime.Duration(_defaultPushTimeout)
+	}
+	p := &Pusher{c: c, gatherer: g, closing: make(chan struct{}), done: make(chan struct{})}
+	if c.Pushgateway != "" {
+		job := c.Job
+		if job == "" {
+			job = env.AppID
+		}
+		if job == "" {
+			return nil, errors.New("stat/metric: pushgateway job is empty")
+		}
+		p.gateway = push.New(c.Pushgateway, job).
+			Gatherer(g).
+			Client(&http.Client{Timeout: time.Duration(c.Timeout)})
+		if _, ok := c.Grouping["instance"]; !ok {
+			p.gateway.Grouping("instance", env.Hostname)
+		}
+		for k, v := range c.Grouping {
+			p.gateway.Grouping(k, v)
+		}
+	}
+	if c.StatsD != "" {
+		s, err := newStatsdClient(c.StatsD, c.Prefix, c.DogStatsD)
+		if err != nil {
+			return nil, err
+		}
+		p.statsd = s
+	}
+	go p.pushproc()
+	return p, nil
+}
+
+func (p *Pusher) pushproc() {
+	defer close(p.done)
+	if p.c.Interval < 0 {
+		<-p.closing
+		return
+	}
+	ticker := time.NewTicker(time.Duration(p.c.Interval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.closing:
+			return
+		case <-ticker.C:
+			if err := p.Push(); err != nil {
+				fmt.Fprintf(os.Stderr, "stat/metric: push error(%+v)\n", err)
+			}
+		}
+	}
+}
+
+// Push the metrics now.
+func (p *Pusher) Push() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	return p.push()
+}
+
+// push must be called with p.mu held.
+func (p *Pusher) push() (err error) {
+	if p.gateway != nil {
+		if err = p.gateway.Push(); err != nil {
+			err = errors.WithStack(err)
+		}
+	}
+	if p.statsd != nil {
+		mfs, gerr := p.gatherer.Gather()
+		if gerr != nil {
+			return errors.WithStack(gerr)
+		}
+		if serr := p.statsd.send(mfs); serr != nil && err == nil {
+			err = serr
+		}
+	}
+	return
+}
+
+// Close stop pushing and push the metrics for the last time.
+func (p *Pusher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	close(p.closing)
+	p.mu.Unlock()
+	<-p.done
+	p.mu.Lock()
+	err := p.push()
+	if p.statsd != nil {
+		p.statsd.conn.Close()
+	}
+	return err
+}
+
+// statsdClient converts prometheus metrics to statsd lines, counters are
+// sent as the delta since the last push, gauges as the current value,
+// histograms and summaries as the delta of count and sum, plus the buckets
+// or quantiles.
+type statsdClient struct {
+	conn   net.Conn
+	prefix string
+	dog    bool
+	// last the values of counters at the last push.
+	last map[string]float64
+}
+
+func newStatsdClient(addr, prefix string, dog bool) (*statsdClient, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+	return &statsdClient{conn: conn, prefix: prefix, dog: dog, last: make(map[string]float64)}, nil
+}
+
+func (s *statsdClient) send(mfs []*dto.MetricFamily) error {
+	var (
+		packet bytes.Buffer
+		err    error
+	)
+	write := func(line string) {
+		if packet.Len() > 0 && packet.Len()+len(line)+1 > _statsdPacketSize {
+			if _, werr := s.conn.Write(packet.Bytes()); werr != nil && err == nil {
+				err = errors.WithStack(werr)
+			}
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	for _, mf := range mfs {
+		for _, m := range mf.Metric {
+			s.metric(mf, m, write)
+		}
+	}
+	if packet.Len() > 0 {
+		if _, werr := s.conn.Write(packet.Bytes()); werr != nil && err == nil {
+			err = errors.WithStack(werr)
+		}
+	}
+	return err
+}
+
+func (s *statsdClient) metric(mf *dto.MetricFamily, m *dto.Metric, write func(string)) {
+	name := mf.GetName()
+	labels := m.GetLabel()
+	switch mf.GetType() {
+	case dto.MetricType_COUNTER:
+		s.counter(name, labels, m.GetCounter().GetValue(), write)
+	case dto.MetricType_GAUGE:
+		s.gauge(name, labels, m.GetGauge().GetValue(), write)
+	case dto.MetricType_UNTYPED:
+		s.gauge(name, labels, m.GetUntyped().GetValue(), write)
+	case dto.MetricType_HISTOGRAM:
+		h := m.GetHistogram()
+		s.counter(name+"_count", labels, float64(h.GetSampleCount()), write)
+		s.counter(name+"_sum", labels, h.GetSampleSum(), write)
+		for _, b := range h.GetBucket() {
+			s.counter(name+"_bucket", withLabel(labels, "le", formatFloat(b.GetUpperBound())), float64(b.GetCumulativeCount()), write)
+		}
+	case dto.MetricType_SUMMARY:
+		sum := m.GetSummary()
+		s.counter(name+"_count", labels, float64(sum.GetSampleCount()), write)
+		s.counter(name+"_sum", labels, sum.GetSampleSum(), write)
+		for _, q := range sum.GetQuantile() {
+			s.gauge(name, withLabel(labels, "quantile", formatFloat(q.GetQuantile())), q.GetValue(), write)
+		}
+	}
+}
+
+func (s *statsdClient) counter(name string, labels []*dto.LabelPair, v float64, write func(string)) {
+	name, tags := s.name(name, labels)
+	key := name + tags
+	delta := v - s.last[key]
+	s.last[key] = v
+	// the counter is reset, such as the collector is recreated.
+	if delta < 0 {
+		delta = v
+	}
+	if delta == 0 {
+		return
+	}
+	write(name + ":" + formatFloat(delta) + "|c" + tags)
+}
+
+func (s *statsdClient) gauge(name string, labels []*dto.LabelPair, v float64, write func(string)) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+	name, tags := s.name(name, labels)
+	// a negative value means decrement in statsd, so reset the gauge first.
+	if v < 0 {
+		write(name + ":0|g" + tags)
+	}
+	write(name + ":" + formatFloat(v) + "|g" + tags)
+}
+
+// name returns the statsd name of the metric and the dogstatsd tags, the
+// label values are appended to the name if it's not dogstatsd.
+func (s *statsdClient) name(name string, labels []*dto.LabelPair) (string, string) {
+	var b strings.Builder
+	b.WriteString(s.prefix)
+	b.WriteString(statsdEscape(name))
+	if !s.dog {
+		for _, l := range labels {
+			b.WriteByte('.')
+			b.WriteString(statsdEscape(l.GetValue()))
+		}
+		return b.String(), ""
+	}
+	if len(labels) == 0 {
+		return b.String(), ""
+	}
+	tags := make([]string, 0, len(labels))
+	for _, l := range labels {
+		tags = append(tags, statsdEscape(l.GetName())+":"+statsdEscape(l.GetValue()))
+	}
+	sort.Strings(tags)
+	return b.String(), "|#" + strings.Join(tags, ",")
+}
+
+func withLabel(labels []*dto.LabelPair, name, value string) []*dto.LabelPair {
+	ls := make([]*dto.LabelPair, len(labels), len(labels)+1)
+	copy(ls, labels)
+	return append(ls, &dto.LabelPair{Name: &name, Value: &value})
+}
+
+func statsdEscape(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', ',', '#', '@', '\n', ' ':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,107 @@
+package metric
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	xtime "github.com/go-kratos/kratos/pkg/time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPushRegistry(t *testing.T) (*prometheus.Registry, *prometheus.CounterVec, *prometheus.GaugeVec) {
+	reg := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "push_requests", Help: "requests"}, []string{"code"})
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "push_queue", Help: "queue"}, []string{"name"})
+	reg.MustRegister(counter, gauge)
+	return reg, counter, gauge
+}
+
+func readStatsd(t *testing.T, conn net.PacketConn) []string {
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	lines := strings.Split(string(buf[:n]), "\n")
+	sort.Strings(lines)
+	return lines
+}
+
+func TestPushStatsd(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+	reg, counter, gauge := newPushRegistry(t)
+	p, err := newPusher(&PushConfig{StatsD: conn.LocalAddr().String(), Prefix: "kratos", Interval: -1}, reg)
+	assert.NoError(t, err)
+
+	counter.WithLabelValues("200").Add(3)
+	gauge.WithLabelValues("a:b").Set(-2)
+	assert.NoError(t, p.Push())
+	assert.Equal(t, []string{
+		"kratos.push_queue.a_b:-2|g",
+		"kratos.push_queue.a_b:0|g",
+		"kratos.push_requests.200:3|c",
+	}, readStatsd(t, conn))
+
+	// counters are sent as delta.
+	counter.WithLabelValues("200").Add(2)
+	gauge.WithLabelValues("a:b").Set(5)
+	assert.NoError(t, p.Close())
+	assert.Equal(t, []string{
+		"kratos.push_queue.a_b:5|g",
+		"kratos.push_requests.200:2|c",
+	}, readStatsd(t, conn))
+	assert.NoError(t, p.Close())
+}
+
+func TestPushDogStatsd(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+	reg := prometheus.NewRegistry()
+	his := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "push_latency", Help: "latency", Buckets: []float64{10}}, []string{"path"})
+	reg.MustRegister(his)
+	p, err := newPusher(&PushConfig{StatsD: conn.LocalAddr().String(), DogStatsD: true, Interval: xtime.Duration(10 * time.Millisecond)}, reg)
+	assert.NoError(t, err)
+	defer p.Close()
+
+	his.WithLabelValues("/ping").Observe(5)
+	assert.Equal(t, []string{
+		"push_latency_bucket:1|c|#le:10,path:/ping",
+		"push_latency_count:1|c|#path:/ping",
+		"push_latency_sum:5|c|#path:/ping",
+	}, readStatsd(t, conn))
+}
+
+func TestPushGateway(t *testing.T) {
+	var (
+		method string
+		path   string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	reg, counter, _ := newPushRegistry(t)
+	counter.WithLabelValues("200").Inc()
+	p, err := newPusher(&PushConfig{Pushgateway: srv.URL, Job: "batch", Grouping: map[string]string{"instance": "host1"}, Interval: -1}, reg)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Close())
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/metrics/job/batch/instance/host1", path)
+}
+
+func TestNewPusherError(t *testing.T) {
+	_, err := NewPusher(&PushConfig{})
+	assert.Error(t, err)
+}
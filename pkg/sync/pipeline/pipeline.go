@@ -3,11 +3,18 @@ package pipeline
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/go-kratos/kratos/pkg/log"
 	"github.com/go-kratos/kratos/pkg/net/metadata"
+	"github.com/go-kratos/kratos/pkg/net/netutil"
 	"github.com/go-kratos/kratos/pkg/stat/metric"
 	xtime "github.com/go-kratos/kratos/pkg/time"
 )
@@ -33,22 +40,71 @@ var (
 		Help:      "channel length",
 		Labels:    []string{"name", "chan"},
 	})
+	_metricRetry = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: _metricNamespace,
+		Subsystem: _metricSubSystem,
+		Name:      "retry_count",
+		Help:      "retry count",
+		Labels:    []string{"name", "chan"},
+	})
+	_metricDeadLetter = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: _metricNamespace,
+		Subsystem: _metricSubSystem,
+		Name:      "dead_letter_count",
+		Help:      "dead letter values count",
+		Labels:    []string{"name", "chan"},
+	})
 )
 
+// CloseError reports the values not processed when the pipeline is closed.
+type CloseError struct {
+	// Lost number of values dropped.
+	Lost int64
+	// Persisted number of values kept in the WAL for the next Start.
+	Persisted int64
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("pipeline: closed with %d values lost and %d values persisted", e.Lost, e.Persisted)
+}
+
 type message struct {
 	key   string
 	value interface{}
 }
 
+// batch the pending values of a mergeproc.
+type batch struct {
+	vals  map[string][]interface{}
+	count int
+	wal   *wal // nil if the WAL is disabled
+}
+
 // Pipeline pipeline struct
 type Pipeline struct {
-	Do          func(c context.Context, index int, values map[string][]interface{})
-	Split       func(key string) int
+	Do    func(c context.Context, index int, values map[string][]interface{})
+	Split func(key string) int
+	// DoErr is used instead of Do if it's set, the failed batches are retried
+	// by Config.MaxRetry and then handed to DeadLetter.
+	DoErr func(c context.Context, index int, values map[string][]interface{}) error
+	// DeadLetter receives the batches still failed after the retries, they
+	// are dropped if it's nil.
+	DeadLetter func(c context.Context, index int, values map[string][]interface{}, err error)
+	// Marshal and Unmarshal encode the values in the WAL, they're required
+	// if Config.WAL is set.
+	Marshal     func(value interface{}) ([]byte, error)
+	Unmarshal   func(data []byte) (interface{}, error)
 	chans       []chan *message
 	mirrorChans []chan *message
 	config      *Config
 	wait        sync.WaitGroup
 	name        string
+	backoff     netutil.BackoffConfig
+	// ctx is canceled when Close reaches its deadline.
+	ctx       context.Context
+	cancel    func()
+	lost      int64
+	persisted int64
 }
 
 // Config Pipeline config
@@ -63,6 +119,17 @@ type Config struct {
 	Worker int
 	// Name use for metrics
 	Name string
+	// WAL directory of the write-ahead logs, the pending batches are kept in
+	// it and replayed by Start after restarts. Empty disables the WAL.
+	WAL string
+	// WALSync fsyncs the WAL after every write.
+	WALSync bool
+	// MaxRetry max retries of a batch failed by DoErr, zero disables retry.
+	MaxRetry int
+	// RetryBackoff base delay of the exponential retry backoff, default 100ms.
+	RetryBackoff xtime.Duration
+	// RetryMaxBackoff max delay of the retry backoff, default 10s.
+	RetryMaxBackoff xtime.Duration
 }
 
 func (c *Config) fix() {
@@ -81,6 +148,12 @@ func (c *Config) fix() {
 	if c.Name == "" {
 		c.Name = "anonymous"
 	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = xtime.Duration(100 * time.Millisecond)
+	}
+	if c.RetryMaxBackoff <= 0 {
+		c.RetryMaxBackoff = xtime.Duration(10 * time.Second)
+	}
 }
 
 // NewPipeline new pipline
@@ -94,7 +167,14 @@ func NewPipeline(config *Config) (res *Pipeline) {
 		mirrorChans: make([]chan *message, config.Worker),
 		config:      config,
 		name:        config.Name,
+		backoff: netutil.BackoffConfig{
+			BaseDelay: time.Duration(config.RetryBackoff),
+			MaxDelay:  time.Duration(config.RetryMaxBackoff),
+			Factor:    1.6,
+			Jitter:    0.2,
+		},
 	}
+	res.ctx, res.cancel = context.WithCancel(context.Background())
 	for i := 0; i < config.Worker; i++ {
 		res.chans[i] = make(chan *message, config.Buffer)
 		res.mirrorChans[i] = make(chan *message, config.Buffer)
@@ -102,23 +182,116 @@ func NewPipeline(config *Config) (res *Pipeline) {
 	return
 }
 
-// Start start all mergeproc
+// Start start all mergeproc, the pending batches in the WAL are replayed.
 func (p *Pipeline) Start() {
-	if p.Do == nil {
+	if p.Do == nil && p.DoErr == nil {
 		panic("pipeline: do func is nil")
 	}
 	if p.Split == nil {
 		panic("pipeline: split func is nil")
 	}
+	if p.config.WAL != "" && (p.Marshal == nil || p.Unmarshal == nil) {
+		panic("pipeline: marshal or unmarshal func is nil with wal")
+	}
+	batches, err := p.openBatches()
+	if err != nil {
+		panic(fmt.Sprintf("pipeline: open wal error(%v)", err))
+	}
 	var mirror bool
 	p.wait.Add(len(p.chans) + len(p.mirrorChans))
 	for i, ch := range p.chans {
-		go p.mergeproc(mirror, i, ch)
+		go p.mergeproc(mirror, i, ch, batches[i])
 	}
 	mirror = true
 	for i, ch := range p.mirrorChans {
-		go p.mergeproc(mirror, i, ch)
+		go p.mergeproc(mirror, i, ch, batches[len(p.chans)+i])
+	}
+}
+
+func (p *Pipeline) walPath(mirror bool, index int) string {
+	name := p.name
+	if mirror {
+		name += ".mirror"
+	}
+	return filepath.Join(p.config.WAL, fmt.Sprintf("%s.%d.wal", name, index))
+}
+
+// openBatches opens the batches of the mergeprocs, the normal ones first. The
+// WALs of the workers beyond Config.Worker are moved into the worker of
+// index % Worker.
+func (p *Pipeline) openBatches() (batches []*batch, err error) {
+	batches = make([]*batch, len(p.chans)+len(p.mirrorChans))
+	for i := range batches {
+		batches[i] = &batch{vals: make(map[string][]interface{}, p.config.MaxSize)}
+	}
+	if p.config.WAL == "" {
+		return
+	}
+	if err = os.MkdirAll(p.config.WAL, 0755); err != nil {
+		return
+	}
+	orphans, err := p.orphanWALs()
+	if err != nil {
+		return
+	}
+	for i, b := range batches {
+		mirror, index := i >= p.config.Worker, i%p.config.Worker
+		var records []walRecord
+		if b.wal, records, err = openWAL(p.walPath(mirror, index), p.config.WALSync); err != nil {
+			return
+		}
+		for _, path := range orphans[i] {
+			var f *os.File
+			if f, err = os.Open(path); err != nil {
+				return
+			}
+			rs, _, _ := readWAL(f)
+			f.Close()
+			for _, r := range rs {
+				if err = b.wal.append(r.key, r.value); err != nil {
+					return
+				}
+			}
+			if err = os.Remove(path); err != nil {
+				return
+			}
+			records = append(records, rs...)
+		}
+		for _, r := range records {
+			v, e := p.Unmarshal(r.value)
+			if e != nil {
+				log.Error("pipeline: %s unmarshal wal value of key(%s) error(%v)", p.name, r.key, e)
+				continue
+			}
+			b.vals[r.key] = append(b.vals[r.key], v)
+			b.count++
+		}
+	}
+	return
+}
+
+// orphanWALs returns the WALs of the workers beyond Config.Worker indexed as
+// the batches.
+func (p *Pipeline) orphanWALs() (orphans map[int][]string, err error) {
+	paths, err := filepath.Glob(filepath.Join(p.config.WAL, p.name+".*.wal"))
+	if err != nil {
+		return
+	}
+	orphans = make(map[int][]string)
+	for _, path := range paths {
+		s := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), p.name+"."), ".wal")
+		mirror := strings.HasPrefix(s, "mirror.")
+		index, err := strconv.Atoi(strings.TrimPrefix(s, "mirror."))
+		if err != nil || index < p.config.Worker {
+			continue
+		}
+		i := index % p.config.Worker
+		if mirror {
+			i += p.config.Worker
+		}
+		orphans[i] = append(orphans[i], path)
 	}
+	return
 }
 
 // SyncAdd sync add a value to channal, channel shard in split method
@@ -154,28 +327,61 @@ func (p *Pipeline) add(c context.Context, key string, value interface{}) (ch cha
 	return
 }
 
-// Close all goroutinue
+// Close all goroutinue and waits for the pending values to be processed.
 func (p *Pipeline) Close() (err error) {
-	for _, ch := range p.chans {
-		ch <- nil
+	return p.CloseContext(context.Background())
+}
+
+// CloseContext closes all goroutinue and drains the pending values until ctx
+// is done, then the values left are kept in the WAL for the next Start or
+// lost if the WAL is disabled, which is reported by a *CloseError. Do and
+// DoErr should return once their ctx is done.
+func (p *Pipeline) CloseContext(ctx context.Context) (err error) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, ch := range append(p.chans, p.mirrorChans...) {
+			select {
+			case ch <- nil:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	wait := make(chan struct{})
+	go func() {
+		p.wait.Wait()
+		close(wait)
+	}()
+	select {
+	case <-wait:
+	case <-ctx.Done():
+		p.cancel()
+		<-wait
 	}
-	for _, ch := range p.mirrorChans {
-		ch <- nil
+	p.cancel()
+	<-done
+	lost, persisted := atomic.LoadInt64(&p.lost), atomic.LoadInt64(&p.persisted)
+	if lost > 0 || persisted > 0 {
+		err = &CloseError{Lost: lost, Persisted: persisted}
 	}
-	p.wait.Wait()
 	return
 }
 
-func (p *Pipeline) mergeproc(mirror bool, index int, ch <-chan *message) {
+func (p *Pipeline) mergeproc(mirror bool, index int, ch <-chan *message, b *batch) {
 	defer p.wait.Done()
 	var (
 		m       *message
-		vals    = make(map[string][]interface{}, p.config.MaxSize)
 		closed  bool
-		count   int
 		inteval = p.config.Interval
 		timeout = false
+		name    = p.name
+		ctx     = p.ctx
 	)
+	if mirror {
+		ctx = metadata.NewContext(ctx, metadata.MD{metadata.Mirror: "1"})
+		name = "mirror_" + name
+	}
 	if index > 0 {
 		inteval = xtime.Duration(int64(index) * (int64(p.config.Interval) / int64(p.config.Worker)))
 	}
@@ -188,30 +394,28 @@ func (p *Pipeline) mergeproc(mirror bool, index int, ch <-chan *message) {
 				closed = true
 				break
 			}
-			count++
-			vals[m.key] = append(vals[m.key], m.value)
-			if count >= p.config.MaxSize {
+			p.append(b, m)
+			if b.count >= p.config.MaxSize {
 				break
 			}
 			continue
 		case <-timer.C:
 			timeout = true
+		case <-p.ctx.Done():
+			p.abort(b, ch)
+			return
 		}
-		name := p.name
-		process := count
-		if len(vals) > 0 {
-			ctx := context.Background()
-			if mirror {
-				ctx = metadata.NewContext(ctx, metadata.MD{metadata.Mirror: "1"})
-				name = "mirror_" + name
-			}
-			p.Do(ctx, index, vals)
-			vals = make(map[string][]interface{}, p.config.MaxSize)
-			count = 0
+		process := b.count
+		if len(b.vals) > 0 && !p.flush(ctx, name, index, b) {
+			p.abort(b, ch)
+			return
 		}
 		_metricChanLen.Set(float64(len(ch)), name, strconv.Itoa(index))
 		_metricCount.Add(float64(process), name, strconv.Itoa(index))
 		if closed {
+			if b.wal != nil {
+				b.wal.close()
+			}
 			return
 		}
 		if !timer.Stop() && !timeout {
@@ -221,3 +425,84 @@ func (p *Pipeline) mergeproc(mirror bool, index int, ch <-chan *message) {
 		timer.Reset(time.Duration(p.config.Interval))
 	}
 }
+
+// append adds the value to the batch and its WAL.
+func (p *Pipeline) append(b *batch, m *message) {
+	if b.wal != nil {
+		data, err := p.Marshal(m.value)
+		if err == nil {
+			err = b.wal.append(m.key, data)
+		}
+		if err != nil {
+			log.Error("pipeline: %s write wal of key(%s) error(%v)", p.name, m.key, err)
+		}
+	}
+	b.vals[m.key] = append(b.vals[m.key], m.value)
+	b.count++
+}
+
+// flush processes the batch with retries, the batch is handed to DeadLetter
+// if it still fails. It returns false if the pipeline is closed before the
+// batch is done.
+func (p *Pipeline) flush(ctx context.Context, name string, index int, b *batch) bool {
+	for retry := 0; ; retry++ {
+		err := p.do(ctx, index, b.vals)
+		if err == nil {
+			break
+		}
+		if p.ctx.Err() != nil {
+			return false
+		}
+		if retry >= p.config.MaxRetry {
+			log.Error("pipeline: %s do %d values failed after %d retries error(%v)", p.name, b.count, retry, err)
+			_metricDeadLetter.Add(float64(b.count), name, strconv.Itoa(index))
+			if p.DeadLetter != nil {
+				p.DeadLetter(ctx, index, b.vals, err)
+			}
+			break
+		}
+		_metricRetry.Inc(name, strconv.Itoa(index))
+		select {
+		case <-time.After(p.backoff.Backoff(retry)):
+		case <-p.ctx.Done():
+			return false
+		}
+	}
+	b.vals = make(map[string][]interface{}, p.config.MaxSize)
+	b.count = 0
+	if b.wal != nil {
+		if err := b.wal.reset(); err != nil {
+			log.Error("pipeline: %s reset wal error(%v)", p.name, err)
+		}
+	}
+	return true
+}
+
+func (p *Pipeline) do(c context.Context, index int, values map[string][]interface{}) error {
+	if p.DoErr != nil {
+		return p.DoErr(c, index, values)
+	}
+	p.Do(c, index, values)
+	return nil
+}
+
+// abort keeps the values of the batch and the channel in the WAL, or drops
+// them if the WAL is disabled.
+func (p *Pipeline) abort(b *batch, ch <-chan *message) {
+	for drained := false; !drained; {
+		select {
+		case m := <-ch:
+			if m != nil {
+				p.append(b, m)
+			}
+		default:
+			drained = true
+		}
+	}
+	if b.wal != nil {
+		atomic.AddInt64(&p.persisted, int64(b.count))
+		b.wal.close()
+	} else {
+		atomic.AddInt64(&p.lost, int64(b.count))
+	}
+}
@@ -2,8 +2,13 @@ package pipeline
 
 import (
 	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -129,3 +134,210 @@ func TestPipelineSmooth(t *testing.T) {
 		}
 	}
 }
+
+func TestPipelineRetryDeadLetter(t *testing.T) {
+	conf := &Config{
+		MaxSize:      2,
+		Interval:     xtime.Duration(time.Hour),
+		Worker:       1,
+		MaxRetry:     2,
+		RetryBackoff: xtime.Duration(time.Millisecond),
+	}
+	var (
+		mu       sync.Mutex
+		attempts = make(map[string]int)
+		dead     []map[string][]interface{}
+	)
+	p := NewPipeline(conf)
+	p.Split = func(s string) int { return 0 }
+	p.DoErr = func(c context.Context, index int, values map[string][]interface{}) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for k := range values {
+			attempts[k]++
+			if k == "bad" || attempts[k] < 2 {
+				return errors.New("do failed")
+			}
+		}
+		return nil
+	}
+	p.DeadLetter = func(c context.Context, index int, values map[string][]interface{}, err error) {
+		mu.Lock()
+		dead = append(dead, values)
+		mu.Unlock()
+	}
+	p.Start()
+	p.SyncAdd(context.Background(), "ok", 1)
+	p.SyncAdd(context.Background(), "ok", 2)
+	p.SyncAdd(context.Background(), "bad", 3)
+	p.SyncAdd(context.Background(), "bad", 4)
+	if err := p.Close(); err != nil {
+		t.Fatalf("close error(%v)", err)
+	}
+	if attempts["ok"] != 2 || attempts["bad"] != 3 {
+		t.Errorf("expect 2 and 3 attempts, got: %+v", attempts)
+	}
+	expt := []map[string][]interface{}{{"bad": {3, 4}}}
+	if !reflect.DeepEqual(dead, expt) {
+		t.Errorf("expect dead letters %+v, got: %+v", expt, dead)
+	}
+}
+
+// intCodec encodes the int values in the WAL.
+func intCodec(p *Pipeline) {
+	p.Marshal = func(v interface{}) ([]byte, error) {
+		return []byte(strconv.Itoa(v.(int))), nil
+	}
+	p.Unmarshal = func(data []byte) (interface{}, error) {
+		return strconv.Atoi(string(data))
+	}
+}
+
+func TestPipelineWAL(t *testing.T) {
+	dir := t.TempDir()
+	conf := &Config{
+		MaxSize:  10,
+		Interval: xtime.Duration(time.Hour),
+		Worker:   2,
+		WAL:      dir,
+	}
+	p := NewPipeline(conf)
+	p.Split = func(s string) int { n, _ := strconv.Atoi(s); return n }
+	p.DoErr = func(c context.Context, index int, values map[string][]interface{}) error {
+		<-c.Done()
+		return c.Err()
+	}
+	intCodec(p)
+	p.Start()
+	p.SyncAdd(context.Background(), "1", 1)
+	p.SyncAdd(context.Background(), "3", 3)
+	mirrorCtx := metadata.NewContext(context.Background(), metadata.MD{metadata.Mirror: "1"})
+	p.SyncAdd(mirrorCtx, "2", 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := p.CloseContext(ctx)
+	if ce, ok := err.(*CloseError); !ok || ce.Persisted != 3 || ce.Lost != 0 {
+		t.Fatalf("expect 3 values persisted, got: %v", err)
+	}
+
+	// restart with fewer workers, the WAL of worker 1 is moved into worker 0.
+	conf.Worker = 1
+	var (
+		mu   sync.Mutex
+		runs = make(map[string][]interface{})
+	)
+	p = NewPipeline(conf)
+	p.Split = func(s string) int { return 0 }
+	p.Do = func(c context.Context, index int, values map[string][]interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		prefix := metadata.String(c, metadata.Mirror)
+		for k, v := range values {
+			runs[prefix+k] = append(runs[prefix+k], v...)
+		}
+	}
+	intCodec(p)
+	p.Start()
+	if err = p.Close(); err != nil {
+		t.Fatalf("close error(%v)", err)
+	}
+	expt := map[string][]interface{}{"1": {1}, "3": {3}, "12": {2}}
+	if !reflect.DeepEqual(runs, expt) {
+		t.Errorf("expect replayed %+v, got: %+v", expt, runs)
+	}
+	if paths, _ := filepath.Glob(filepath.Join(dir, "*.wal")); len(paths) != 2 {
+		t.Errorf("expect 2 wal files, got: %v", paths)
+	}
+}
+
+func TestPipelineWALCodec(t *testing.T) {
+	p := NewPipeline(&Config{WAL: t.TempDir()})
+	p.Split = func(s string) int { return 0 }
+	p.Do = func(c context.Context, index int, values map[string][]interface{}) {}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expect panic without marshal and unmarshal")
+		}
+	}()
+	p.Start()
+}
+
+func TestPipelineCloseLost(t *testing.T) {
+	conf := &Config{
+		MaxSize:  10,
+		Interval: xtime.Duration(time.Hour),
+		Worker:   1,
+	}
+	p := NewPipeline(conf)
+	p.Split = func(s string) int { return 0 }
+	p.DoErr = func(c context.Context, index int, values map[string][]interface{}) error {
+		<-c.Done()
+		return c.Err()
+	}
+	p.Start()
+	p.SyncAdd(context.Background(), "1", 1)
+	p.SyncAdd(context.Background(), "1", 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if ce, ok := p.CloseContext(ctx).(*CloseError); !ok || ce.Lost != 2 {
+		t.Errorf("expect 2 values lost, got: %v", ce)
+	}
+}
+
+func TestWALTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.wal")
+	w, _, err := openWAL(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.append("a", []byte("1"))
+	w.append("b", []byte("2"))
+	w.close()
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.Write([]byte{1, 2, 3})
+	f.Close()
+
+	w, records, err := openWAL(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.append("c", []byte("3"))
+	w.close()
+	_, records, _ = openWAL(path, false)
+	expt := []walRecord{{"a", []byte("1")}, {"b", []byte("2")}, {"c", []byte("3")}}
+	if !reflect.DeepEqual(records, expt) {
+		t.Errorf("expect records %+v, got: %+v", expt, records)
+	}
+}
+
+func TestWALOversized(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.wal")
+	w, _, err := openWAL(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.append("a", []byte("1"))
+	if err = w.append("b", make([]byte, _walMaxRecordSize)); err != errWALTooLarge {
+		t.Errorf("expect errWALTooLarge, got: %v", err)
+	}
+	w.close()
+	// a garbage header claiming a huge record.
+	header := make([]byte, _walHeaderSize)
+	binary.LittleEndian.PutUint32(header[4:], 0xffffffff)
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.Write(append(header, 1, 2, 3))
+	f.Close()
+
+	w, records, err := openWAL(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.close()
+	expt := []walRecord{{"a", []byte("1")}}
+	if !reflect.DeepEqual(records, expt) {
+		t.Errorf("expect records %+v, got: %+v", expt, records)
+	}
+	if fi, _ := os.Stat(path); fi.Size() != int64(_walHeaderSize+3) {
+		t.Errorf("expect the tail truncated, got size: %d", fi.Size())
+	}
+}
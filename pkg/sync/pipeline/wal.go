@@ -0,0 +1,132 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// errWALCorrupt the WAL record is torn or corrupted.
+var errWALCorrupt = errors.New("pipeline: wal record corrupted")
+
+const (
+	_walHeaderSize = 8 // crc32 + payload length
+	// _walMaxRecordSize caps the payload of a record, a larger length in the
+	// header is a corrupted tail rather than an allocation.
+	_walMaxRecordSize = 16 << 20
+)
+
+// errWALTooLarge the record exceeds _walMaxRecordSize.
+var errWALTooLarge = errors.New("pipeline: wal record too large")
+
+// wal is the write-ahead log of the pending batch of a mergeproc. Each record
+// is a key and an encoded value, the log is reset once the batch is done.
+type wal struct {
+	f    *os.File
+	w    *bufio.Writer
+	sync bool
+}
+
+type walRecord struct {
+	key   string
+	value []byte
+}
+
+// openWAL opens the log and returns the records in it, a torn or corrupted
+// tail left by a crash is truncated.
+func openWAL(path string, sync bool) (*wal, []walRecord, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, size, err := readWAL(f)
+	if err == nil {
+		err = f.Truncate(size)
+	}
+	if err == nil {
+		_, err = f.Seek(size, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return &wal{f: f, w: bufio.NewWriter(f), sync: sync}, records, nil
+}
+
+// readWAL reads the records until the end or the first corrupted record, it
+// returns the size of the valid records.
+func readWAL(r io.Reader) (records []walRecord, size int64, err error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, _walHeaderSize)
+	for {
+		if _, err = io.ReadFull(br, header); err != nil {
+			break
+		}
+		length := binary.LittleEndian.Uint32(header[4:])
+		if length > _walMaxRecordSize {
+			err = errWALCorrupt
+			break
+		}
+		payload := make([]byte, length)
+		if _, err = io.ReadFull(br, payload); err != nil {
+			break
+		}
+		if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header) {
+			err = errWALCorrupt
+			break
+		}
+		n, l := binary.Uvarint(payload)
+		if l <= 0 || uint64(len(payload)-l) < n {
+			err = errWALCorrupt
+			break
+		}
+		records = append(records, walRecord{
+			key:   string(payload[l : l+int(n)]),
+			value: payload[l+int(n):],
+		})
+		size += int64(_walHeaderSize + len(payload))
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF || err == errWALCorrupt {
+		err = nil
+	}
+	return
+}
+
+// append appends a record and flushes it to the file.
+func (w *wal) append(key string, value []byte) (err error) {
+	if binary.MaxVarintLen64+len(key)+len(value) > _walMaxRecordSize {
+		return errWALTooLarge
+	}
+	payload := make([]byte, binary.MaxVarintLen64+len(key)+len(value))
+	n := binary.PutUvarint(payload, uint64(len(key)))
+	n += copy(payload[n:], key)
+	n += copy(payload[n:], value)
+	payload = payload[:n]
+	header := make([]byte, _walHeaderSize)
+	binary.LittleEndian.PutUint32(header, crc32.ChecksumIEEE(payload))
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(payload)))
+	w.w.Write(header)
+	w.w.Write(payload)
+	if err = w.w.Flush(); err != nil || !w.sync {
+		return
+	}
+	return w.f.Sync()
+}
+
+// reset removes all the records.
+func (w *wal) reset() (err error) {
+	w.w.Reset(w.f)
+	if err = w.f.Truncate(0); err != nil {
+		return
+	}
+	_, err = w.f.Seek(0, io.SeekStart)
+	return
+}
+
+func (w *wal) close() error {
+	w.w.Flush()
+	return w.f.Close()
+}
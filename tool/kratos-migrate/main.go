@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/go-kratos/kratos/pkg/database/sql"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	dir     string
+	conf    string
+	section string
+	driver  string
+	dsn     string
+	table   string
+	dryRun  bool
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "migrate"
+	app.Usage = "数据库版本迁移工具"
+	app.HideVersion = true
+	app.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "dir",
+			Value:       "migrations",
+			Usage:       "迁移文件目录，文件名为{版本}_{名称}.up.sql和{版本}_{名称}.down.sql",
+			Destination: &dir,
+		},
+		&cli.StringFlag{
+			Name:        "conf",
+			Value:       "configs/db.toml",
+			Usage:       "数据库配置文件，未指定dsn时使用",
+			Destination: &conf,
+		},
+		&cli.StringFlag{
+			Name:        "section",
+			Value:       "Client",
+			Usage:       "配置文件中数据库配置的名称",
+			Destination: &section,
+		},
+		&cli.StringFlag{
+			Name:        "driver",
+			Usage:       "mysql或postgres，默认使用配置文件中的driver",
+			Destination: &driver,
+		},
+		&cli.StringFlag{
+			Name:        "dsn",
+			Usage:       "数据库连接地址，默认使用配置文件中的dsn",
+			Destination: &dsn,
+		},
+		&cli.StringFlag{
+			Name:        "table",
+			Value:       "schema_migrations",
+			Usage:       "版本表名",
+			Destination: &table,
+		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "只输出将要执行的迁移，不执行",
+			Destination: &dryRun,
+		},
+	}
+	app.Commands = []*cli.Command{
+		{
+			Name:   "up",
+			Usage:  "执行全部未执行的迁移",
+			Action: upAction,
+		},
+		{
+			Name:      "down",
+			Usage:     "回滚最近的n个迁移，默认1个",
+			ArgsUsage: "[n]",
+			Action:    downAction,
+		},
+		{
+			Name:   "status",
+			Usage:  "查看迁移状态",
+			Action: statusAction,
+		},
+		{
+			Name:      "create",
+			Usage:     "创建新的迁移文件",
+			ArgsUsage: "name",
+			Action:    createAction,
+		},
+	}
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func newMigrator() (*sql.Migrator, *sql.DB, error) {
+	c := &sql.Config{}
+	if dsn == "" {
+		cs := make(map[string]*sql.Config)
+		if _, err := toml.DecodeFile(conf, &cs); err != nil {
+			return nil, nil, err
+		}
+		if cs[section] == nil {
+			return nil, nil, fmt.Errorf("%s中找不到数据库配置%s", conf, section)
+		}
+		c = cs[section]
+	} else {
+		c.DSN = dsn
+	}
+	if driver != "" {
+		c.Driver = driver
+	}
+	// migrations only run on master.
+	c.ReadDSN = nil
+	db, err := sql.Open(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := sql.NewMigrator(db, &sql.MigrateConfig{Dir: dir, Table: table, DryRun: dryRun})
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return m, db, nil
+}
+
+func upAction(c *cli.Context) error {
+	m, db, err := newMigrator()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ms, err := m.Up(context.Background())
+	if err == nil || len(ms) > 0 {
+		printMigrations(ms, true)
+	}
+	return err
+}
+
+func downAction(c *cli.Context) (err error) {
+	n := 1
+	if c.NArg() > 0 {
+		if n, err = strconv.Atoi(c.Args().First()); err != nil || n <= 0 {
+			return fmt.Errorf("回滚个数错误: %s", c.Args().First())
+		}
+	}
+	m, db, err := newMigrator()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ms, err := m.Down(context.Background(), n)
+	if err == nil || len(ms) > 0 {
+		printMigrations(ms, false)
+	}
+	return err
+}
+
+func printMigrations(ms []*sql.Migration, up bool) {
+	action, suffix := "执行", "up"
+	if !up {
+		action, suffix = "回滚", "down"
+	}
+	if dryRun {
+		action = "将" + action
+	}
+	if len(ms) == 0 {
+		fmt.Printf("没有需要%s的迁移\n", action)
+		return
+	}
+	for _, m := range ms {
+		fmt.Printf("%s %d_%s\n", action, m.Version, m.Name)
+		if dryRun {
+			stmts := m.Up
+			if !up {
+				stmts = m.Down
+			}
+			fmt.Printf("-- %d_%s.%s.sql\n%s\n\n", m.Version, m.Name, suffix, stmts)
+		}
+	}
+}
+
+func statusAction(c *cli.Context) error {
+	m, db, err := newMigrator()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ms, err := m.Status(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, m := range ms {
+		state := "未执行"
+		switch {
+		case !m.AppliedAt.IsZero() && m.Up == "":
+			state = m.AppliedAt.Format("2006-01-02 15:04:05") + " 文件缺失"
+		case !m.AppliedAt.IsZero():
+			state = m.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%d_%s\t%s\n", m.Version, m.Name, state)
+	}
+	return nil
+}
+
+func createAction(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return fmt.Errorf("请填写迁移名称")
+	}
+	up, down, err := sql.CreateMigration(dir, c.Args().First())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("创建 %s\n创建 %s\n", up, down)
+	return nil
+}
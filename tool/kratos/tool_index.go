@@ -40,6 +40,15 @@ var toolIndexs = []*Tool{
 		Platform:  []string{"darwin", "linux", "windows"},
 		Author:    "kratos",
 	},
+	{
+		Name:      "migrate",
+		Alias:     "kratos-migrate",
+		BuildTime: time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local),
+		Install:   "go get -u github.com/go-kratos/kratos/tool/kratos-migrate@" + Version,
+		Summary:   "数据库版本迁移工具",
+		Platform:  []string{"darwin", "linux", "windows"},
+		Author:    "kratos",
+	},
 	{
 		Name:         "genproject",
 		Alias:        "kratos-gen-project",